@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/config"
+)
+
+// runCacheCommand implementa o subcomando cache:
+//
+//	cache stats  mostra entradas, acertos e falhas do cache de embeddings
+//	cache prune  remove consultas expiradas e as entradas além de cache.max_entries
+//	cache clear  remove todas as entradas
+//
+// Uma ação vazia equivale a "stats".
+func runCacheCommand(w io.Writer, cfg *config.Config, action string) error {
+	if action != "" && action != "stats" && action != "prune" && action != "clear" {
+		return fmt.Errorf("unknown cache subcommand %q (expected stats, prune or clear)", action)
+	}
+
+	embeddingCache, err := cfg.OpenEmbeddingCache()
+	if err != nil {
+		return err
+	}
+	defer embeddingCache.Close()
+
+	switch action {
+	case "prune":
+		removed, err := embeddingCache.Prune(cfg.QueryTTL())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "removed %d entries\n", removed)
+	case "clear":
+		if err := embeddingCache.Clear(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "embedding cache cleared")
+	}
+
+	stats, err := embeddingCache.Stats()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "file:      %s (%.1f MB)\n", stats.Path, float64(stats.FileSize)/(1<<20))
+	fmt.Fprintf(w, "documents: %d\n", stats.Documents)
+	fmt.Fprintf(w, "queries:   %d\n", stats.Queries)
+	fmt.Fprintf(w, "hits:      %d\n", stats.Hits)
+	fmt.Fprintf(w, "misses:    %d\n", stats.Misses)
+	fmt.Fprintf(w, "hit rate:  %.1f%%\n", 100*stats.HitRate())
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/config"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/vectorstore"
+)
+
+// runIndexCommand implementa o subcomando index, sobre a coleção qdrant.collection:
+//
+//	index list                 mostra os índices de payload e os pontos indexados
+//	index add [campo:tipo...]  cria os índices (padrão: os de qdrant.payload_indexes)
+//	index drop campo...        remove os índices dos campos
+//
+// Uma ação vazia equivale a "list".
+func runIndexCommand(ctx context.Context, w io.Writer, cfg *config.Config, action string, args []string) error {
+	if action != "" && action != "list" && action != "add" && action != "drop" {
+		return fmt.Errorf("unknown index subcommand %q (expected list, add or drop)", action)
+	}
+
+	// O armazenamento local percorre todos os pontos nas buscas e não tem índices
+	if cfg.VectorStore.Backend == "local" {
+		return fmt.Errorf("payload indexes are only used by the qdrant backend, not by vector_store.backend local")
+	}
+
+	store, err := vectorstore.NewQdrantVectorStore(cfg.Qdrant.URL, nil)
+	if err != nil {
+		return err
+	}
+	collection := cfg.Qdrant.Collection
+
+	switch action {
+	case "add":
+		indexes, err := cfg.PayloadIndexes()
+		if err != nil {
+			return fmt.Errorf("invalid qdrant.payload_indexes: %w", err)
+		}
+		if len(args) > 0 {
+			indexes = nil
+			for _, arg := range args {
+				index, err := vectorstore.ParsePayloadIndex(arg)
+				if err != nil {
+					return err
+				}
+				indexes = append(indexes, index)
+			}
+		}
+		if len(indexes) == 0 {
+			return fmt.Errorf("no index to create: pass field:type arguments or set qdrant.payload_indexes")
+		}
+		for _, index := range indexes {
+			if err := store.CreatePayloadIndex(ctx, collection, index); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "created %s index on '%s'\n", index.Type, index.Field)
+		}
+	case "drop":
+		if len(args) == 0 {
+			return fmt.Errorf("no index to drop: pass the fields whose index is removed")
+		}
+		for _, field := range args {
+			if err := store.DeletePayloadIndex(ctx, collection, field); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "dropped index on '%s'\n", field)
+		}
+	}
+
+	indexes, err := store.ListPayloadIndexes(ctx, collection)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "collection '%s': %d payload indexes\n", collection, len(indexes))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, index := range indexes {
+		fmt.Fprintf(tw, "  %s\t%s\t%d points\n", index.Field, index.Type, index.Points)
+	}
+	return tw.Flush()
+}
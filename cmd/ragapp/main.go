@@ -7,63 +7,110 @@ import (
 	"os"
 	"strings"
 
-	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/llm"
-	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/loader"
-	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/splitter"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/config"
 	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/vectorstore"
 	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
-)
-
-const (
-	// Configuration
-	pdfDir         = "data/pdfs" // Directory containing PDFs
-	pdfPattern     = "*.pdf"     // Pattern to match PDF files
-	qdrantURL      = "http://localhost:6333"
-	collectionName = "my_collection"
-	embedModel     = "nomic-embed-text"
-	genModel       = "deepseek-r1:8b"
-	vectorSize     = 768 // Dimension for nomic-embed-text
-	chunkSize      = 1000
-	chunkOverlap   = 100
+	"github.com/tmc/langchaingo/schema"
 )
 
 func main() {
 	ctx := context.Background()
 	log.Println("Starting RAG application...")
 
-	// Processar argumentos da linha de comando
-	var mode string
+	// Processar argumentos da linha de comando: ragapp [modo] [flags] [pergunta]
 	var query string
 	var perPdf bool
 
 	// Verificar se o modo está especificado
-	if len(os.Args) > 1 {
-		mode = strings.ToLower(os.Args[1])
+	mode, args := config.SplitCommand(os.Args[1:])
+	mode = strings.ToLower(mode)
+
+	// Os subcomandos config, cache, index e migrate aceitam uma ação própria (print, validate, stats, list...)
+	var configAction string
+	if mode == "config" || mode == "cache" || mode == "index" || mode == "migrate" {
+		configAction, args = config.SplitCommand(args)
+	}
+
+	// Carregar configuração (arquivo, variáveis de ambiente e flags)
+	cfg, args, err := config.Load("ragapp", args)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Subcomando config: mostra ou valida a configuração efetiva
+	if mode == "config" {
+		if err := config.RunCommand(os.Stdout, cfg, configAction); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Subcomando cache: estatísticas e limpeza do cache de embeddings
+	if mode == "cache" {
+		if err := runCacheCommand(os.Stdout, cfg, configAction); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Subcomando index: lista, cria ou remove os índices de payload da coleção
+	if mode == "index" {
+		if err := runIndexCommand(ctx, os.Stdout, cfg, configAction, args); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Subcomando migrate: converte pontos do layout legado (pgk/store) para o layout plano
+	if mode == "migrate" {
+		if err := runMigrateCommand(ctx, os.Stdout, cfg, configAction, args); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
 	// Verificar se uma pergunta foi fornecida
-	if len(os.Args) > 2 {
-		query = os.Args[2]
+	if len(args) > 0 {
+		query = args[0]
 	} else {
 		query = "Qual a habilidade mais importante na era da Inteligência Artificial?"
 	}
 
 	// Se o modo é help, mostrar instruções
 	if mode == "help" {
-		fmt.Println("Uso: ragapp [modo] [pergunta]")
+		fmt.Println("Uso: ragapp [modo] [flags] [pergunta]")
 		fmt.Println("Modos:")
 		fmt.Println("  ingest    - Apenas ingere os documentos (padrão: uma coleção)")
 		fmt.Println("  ingest-per-pdf - Ingere documentos criando uma coleção por PDF")
 		fmt.Println("  query     - Apenas consulta os documentos (padrão: uma coleção)")
 		fmt.Println("  stream    - Consulta com saída em streaming")
 		fmt.Println("  all       - Ingere e consulta (padrão)")
+		fmt.Println("  config    - Mostra (print) ou valida (validate) a configuração efetiva")
+		fmt.Println("  cache     - Mostra (stats), poda (prune) ou limpa (clear) o cache de embeddings")
+		fmt.Println("  index     - Lista (list), cria (add campo:tipo...) ou remove (drop campo...) índices de payload")
+		fmt.Println("  migrate   - Verifica (check) ou converte (run) pontos no layout legado {text, metadata}")
 		fmt.Println("  help      - Mostra esta ajuda")
+		fmt.Println("\nFlags (use \"ragapp help -h\" para a lista completa):")
+		fmt.Println("  -config arquivo.yaml  - Carrega configuração de um arquivo YAML ou TOML (ou RAG_CONFIG)")
+		fmt.Println("  -qdrant-url, -models-generation, ... - Sobrescrevem o arquivo e as variáveis RAG_*")
+		fmt.Println("  -filter \"chave=valor,...\" - Restringe as consultas pelos metadados (=, !=, >, >=, <, <=, a|b, *)")
+		fmt.Println("  -hybrid               - Busca híbrida: embeddings + BM25 (códigos, nomes de funções, SKUs)")
+		fmt.Println("  -vector-store-backend local - Guarda os vetores em um arquivo local (vector_store.path), sem Qdrant")
 		fmt.Println("\nExemplos:")
 		fmt.Println("  ragapp ingest-per-pdf")
 		fmt.Println("  ragapp stream \"Como monitorar o desempenho de containers com Go?\"")
+		fmt.Println("  ragapp query -models-generation llama3 \"Qual o tema do artigo?\"")
+		fmt.Println("  ragapp query -filter relative_path=artigo.pdf \"Qual o tema do artigo?\"")
+		fmt.Println("  ragapp config print -config config.yaml")
+		fmt.Println("  ragapp index add -qdrant-collection artigos page:integer tags:keyword")
+		fmt.Println("  ragapp migrate run my_collection")
 		return
 	}
 
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
 	// Se o modo é ingest-per-pdf, configurar a flag correspondente
 	if mode == "ingest-per-pdf" {
 		perPdf = true
@@ -72,81 +119,102 @@ func main() {
 
 	log.Println("Initializing components...")
 
-	pdfLoader := loader.NewPDFLoader()
-	textSplitter := splitter.NewRecursiveCharacterSplitter(chunkSize, chunkOverlap)
+	// Carregadores de documentos (PDF, Office, Markdown, texto, HTML, CSV/JSONL), escolhidos pela extensão do arquivo
+	docLoader := cfg.DocumentLoader()
+
+	// Embedder do backend configurado (Ollama ou servidor compatível com OpenAI)
+	modelEmbedder, err := cfg.Embedder()
+	if err != nil {
+		log.Fatalf("Failed to initialize embedder: %v", err)
+	}
+
+	// Cache persistente de embeddings (cache.enabled); sem ele, tudo é recalculado
+	embedder, embeddingCache, err := cfg.CachedEmbedder(modelEmbedder)
+	if err != nil {
+		log.Printf("Warning: Embedding cache disabled: %v", err)
+	}
+	if embeddingCache != nil {
+		defer embeddingCache.Close()
+	}
 
-	embedder, err := llm.NewOllamaEmbedder(embedModel)
+	textSplitter, err := cfg.TextSplitter(embedder)
 	if err != nil {
-		log.Fatalf("Failed to initialize Ollama embedder: %v", err)
+		log.Fatalf("Failed to initialize text splitter: %v", err)
 	}
 
-	qdrantStore, err := vectorstore.NewQdrantVectorStore(qdrantURL, embedder)
+	// Vector store do backend configurado (Qdrant ou local, vector_store.backend), com os índices de payload
+	// de qdrant.payload_indexes. O modelo de embedding fica registrado nas coleções criadas, e consultas com
+	// outro modelo falham logo; na busca híbrida (retrieval.hybrid), as coleções guardam também vetores esparsos BM25
+	vectorStore, err := cfg.OpenVectorStore(embedder)
 	if err != nil {
-		log.Fatalf("Failed to initialize Qdrant vector store: %v", err)
+		log.Fatalf("Failed to initialize vector store: %v", err)
 	}
 
-	// Criar QdrantRetriever para suporte a múltiplas coleções
-	qdrantRetriever, err := vectorstore.NewQdrantRetriever(qdrantURL, embedder)
+	// Retriever com suporte a múltiplas coleções
+	retriever, err := cfg.OpenRetriever(embedder)
 	if err != nil {
-		log.Fatalf("Failed to initialize Qdrant retriever: %v", err)
+		log.Fatalf("Failed to initialize retriever: %v", err)
 	}
 
-	generatorLLM, err := llm.NewOllamaLLM(genModel)
+	// Filtro de metadados das consultas (retrieval.filter ou -filter)
+	searchFilter, err := cfg.SearchFilter()
 	if err != nil {
-		log.Fatalf("Failed to initialize Ollama generation LLM: %v", err)
+		log.Fatalf("Invalid filter: %v", err)
+	}
+
+	// Vocabulário BM25 da busca híbrida, compartilhado pela ingestão e pelas consultas (nil sem retrieval.hybrid)
+	sparseEncoder := cfg.SparseEncoder()
+
+	generatorLLM, err := cfg.GenerationLLM()
+	if err != nil {
+		log.Fatalf("Failed to initialize generation LLM: %v", err)
 	}
 
 	log.Println("Components initialized.")
 
 	// Use Cases
-	ingestionUC := usecase.NewIngestionUseCase(pdfLoader, textSplitter, embedder, qdrantStore)
-	queryUC := usecase.NewQueryUseCase(embedder, qdrantRetriever, generatorLLM)
+	// Barra de progresso apenas quando a saída de log é um terminal
+	ingestionOpts := append(cfg.IngestionOptions(), usecase.WithSparseEncoder(sparseEncoder))
+	var progress *progressBar
+	if isTerminal(os.Stderr) {
+		progress = newProgressBar(os.Stderr)
+		ingestionOpts = append(ingestionOpts, usecase.WithIngestionObserver(progress))
+	}
+	ingestionUC := usecase.NewIngestionUseCase(docLoader, textSplitter, embedder, vectorStore, ingestionOpts...)
+	// Opções de geração (generation.*): temperatura, top_p, max_tokens, stop...
+	queryUC := usecase.NewQueryUseCase(embedder, retriever, generatorLLM, usecase.WithLLMOptions(cfg.CallOptions()), usecase.WithSearchFilter(searchFilter), usecase.WithHybridSearch(sparseEncoder, cfg.HybridOptions()))
 
 	// Executar o modo selecionado
 	switch mode {
 	case "ingest":
 		// Apenas ingestão
 		log.Println("--- Starting Ingestion Phase ---")
-		if perPdf {
-			err = ingestionUC.ExecutePerPDF(ctx, pdfDir, pdfPattern, vectorSize)
-		} else {
-			err = ingestionUC.Execute(ctx, pdfDir, pdfPattern, collectionName, vectorSize)
-		}
-		if err != nil {
-			log.Fatalf("Ingestion failed: %v", err)
-		}
+		runIngestion(ctx, ingestionUC, cfg, perPdf, progress)
 		log.Println("--- Ingestion Phase Complete ---")
 
 	case "query":
 		// Apenas consulta padrão (sem streaming)
 		log.Println("--- Starting Query Phase ---")
-		executeStandardQuery(ctx, queryUC, qdrantRetriever, query)
+		executeStandardQuery(ctx, queryUC, retriever, query)
 		log.Println("--- Query Phase Complete ---")
 
 	case "stream":
 		// Consulta com resposta em streaming
 		log.Println("--- Starting Streaming Query Phase ---")
-		executeStreamingQuery(ctx, queryUC, qdrantRetriever, query)
+		executeStreamingQuery(ctx, queryUC, retriever, query, cfg.Qdrant.Collection)
 		log.Println("--- Streaming Query Phase Complete ---")
 
 	default:
 		// Modo padrão: ingestão seguida de consulta
 		log.Println("--- Starting Ingestion Phase ---")
-		if perPdf {
-			err = ingestionUC.ExecutePerPDF(ctx, pdfDir, pdfPattern, vectorSize)
-		} else {
-			err = ingestionUC.Execute(ctx, pdfDir, pdfPattern, collectionName, vectorSize)
-		}
-		if err != nil {
-			log.Fatalf("Ingestion failed: %v", err)
-		}
+		runIngestion(ctx, ingestionUC, cfg, perPdf, progress)
 		log.Println("--- Ingestion Phase Complete ---")
 
 		log.Println("--- Starting Query Phase ---")
 		if perPdf {
-			executeStreamingMultiCollectionQuery(ctx, queryUC, qdrantRetriever, query)
+			executeStreamingMultiCollectionQuery(ctx, queryUC, retriever, query)
 		} else {
-			executeStandardQuery(ctx, queryUC, qdrantRetriever, query)
+			executeStandardQuery(ctx, queryUC, retriever, query)
 		}
 		log.Println("--- Query Phase Complete ---")
 	}
@@ -154,11 +222,32 @@ func main() {
 	log.Println("RAG application finished.")
 }
 
+// runIngestion executa a ingestão (por PDF ou na coleção padrão) e exibe o relatório
+func runIngestion(ctx context.Context, ingestionUC *usecase.IngestionUseCase, cfg *config.Config, perPdf bool, progress *progressBar) {
+	var report *usecase.IngestionReport
+	var err error
+	if perPdf {
+		report, err = ingestionUC.ExecutePerPDF(ctx, cfg.Ingestion.Dir, cfg.Ingestion.Pattern, cfg.Qdrant.VectorSize)
+	} else {
+		report, err = ingestionUC.Execute(ctx, cfg.Ingestion.Dir, cfg.Ingestion.Pattern, cfg.Qdrant.Collection, cfg.Qdrant.VectorSize)
+	}
+	if progress != nil {
+		progress.finish()
+	}
+	printIngestionReport(report)
+	if err != nil {
+		log.Fatalf("Ingestion failed: %v", err)
+	}
+}
+
 // executeStandardQuery executa uma consulta padrão em uma única coleção
-func executeStandardQuery(ctx context.Context, queryUC *usecase.QueryUseCase, retriever *vectorstore.QdrantRetriever, query string) {
+func executeStandardQuery(ctx context.Context, queryUC *usecase.QueryUseCase, retriever vectorstore.CollectionRetriever, query string) {
 	log.Printf("\n=== Query ===\n%s\n", query)
 
-	answer, relevantDocs, err := queryUC.Execute(ctx, query)
+	// Raciocínio do modelo (generation.reasoning: separate) exibido antes da resposta
+	answer, relevantDocs, err := queryUC.Execute(ctx, query, usecase.WithReasoningCallback(func(reasoning string) {
+		log.Printf("\n=== Reasoning ===\n%s\n", reasoning)
+	}))
 	if err != nil {
 		log.Fatalf("Query failed: %v", err)
 	}
@@ -168,20 +257,19 @@ func executeStandardQuery(ctx context.Context, queryUC *usecase.QueryUseCase, re
 	log.Println("\n=== Relevant Documents Retrieved ===")
 	for i, doc := range relevantDocs {
 		log.Printf("--- Document %d (Score: %.4f) ---", i+1, doc.Metadata["score"])
-		log.Printf("Source: %s", doc.Metadata["source"])
+		log.Printf("Source: %s", documentSource(doc))
 		log.Printf("Content: %s\n", doc.PageContent)
 	}
 }
 
 // executeStreamingQuery executa uma consulta com resposta em streaming
-func executeStreamingQuery(ctx context.Context, queryUC *usecase.QueryUseCase, retriever *vectorstore.QdrantRetriever, query string) {
+func executeStreamingQuery(ctx context.Context, queryUC *usecase.QueryUseCase, retriever vectorstore.CollectionRetriever, query, collectionName string) {
 	log.Printf("\n=== Query ===\n%s\n", query)
 	log.Printf("\n=== Answer (streaming) ===\n")
 
-	// Definir callback para exibir cada parte da resposta
-	streamCallback := func(chunk string) {
-		fmt.Print(chunk)
-	}
+	// Definir callbacks para exibir cada parte da resposta e do raciocínio do modelo
+	printer := &streamPrinter{}
+	streamCallback := printer.answer
 
 	// Lista todas as coleções disponíveis
 	collections, err := retriever.ListCollections(ctx)
@@ -191,7 +279,7 @@ func executeStreamingQuery(ctx context.Context, queryUC *usecase.QueryUseCase, r
 
 	// Se houver apenas uma coleção ou for a coleção padrão, use a consulta de streaming padrão
 	if len(collections) <= 1 || (len(collections) == 1 && collections[0] == collectionName) {
-		relevantDocs, err := queryUC.ExecuteWithStreaming(ctx, query, collectionName, streamCallback)
+		relevantDocs, err := queryUC.ExecuteWithStreaming(ctx, query, collectionName, streamCallback, printer.reasoningOption())
 		if err != nil {
 			log.Fatalf("Streaming query failed: %v", err)
 		}
@@ -201,7 +289,7 @@ func executeStreamingQuery(ctx context.Context, queryUC *usecase.QueryUseCase, r
 		log.Println("\n=== Relevant Documents Retrieved ===")
 		for i, doc := range relevantDocs {
 			log.Printf("--- Document %d (Score: %.4f) ---", i+1, doc.Metadata["score"])
-			log.Printf("Source: %s", doc.Metadata["source"])
+			log.Printf("Source: %s", documentSource(doc))
 			log.Printf("Content: %s\n", doc.PageContent)
 		}
 	} else {
@@ -211,14 +299,13 @@ func executeStreamingQuery(ctx context.Context, queryUC *usecase.QueryUseCase, r
 }
 
 // executeStreamingMultiCollectionQuery executa uma consulta em múltiplas coleções com streaming
-func executeStreamingMultiCollectionQuery(ctx context.Context, queryUC *usecase.QueryUseCase, retriever *vectorstore.QdrantRetriever, query string) {
+func executeStreamingMultiCollectionQuery(ctx context.Context, queryUC *usecase.QueryUseCase, retriever vectorstore.CollectionRetriever, query string) {
 	log.Printf("\n=== Multi-Collection Query ===\n%s\n", query)
 	log.Printf("\n=== Answer (streaming from multiple collections) ===\n")
 
-	// Definir callback para exibir cada parte da resposta
-	streamCallback := func(chunk string) {
-		fmt.Print(chunk)
-	}
+	// Definir callbacks para exibir cada parte da resposta e do raciocínio do modelo
+	printer := &streamPrinter{}
+	streamCallback := printer.answer
 
 	// Listar todas as coleções disponíveis
 	collections, err := retriever.ListCollections(ctx)
@@ -227,13 +314,13 @@ func executeStreamingMultiCollectionQuery(ctx context.Context, queryUC *usecase.
 	}
 
 	if len(collections) == 0 {
-		log.Fatalf("No collections found in Qdrant")
+		log.Fatalf("No collections found in the vector store")
 	}
 
 	log.Printf("Found %d collections: %v", len(collections), collections)
 
 	// Executar consulta em todas as coleções encontradas
-	relevantDocs, err := queryUC.ExecuteWithStreamingMultiCollection(ctx, query, collections, 2, streamCallback)
+	relevantDocs, err := queryUC.ExecuteWithStreamingMultiCollection(ctx, query, collections, 2, streamCallback, printer.reasoningOption())
 	if err != nil {
 		log.Fatalf("Multi-collection streaming query failed: %v", err)
 	}
@@ -248,7 +335,54 @@ func executeStreamingMultiCollectionQuery(ctx context.Context, queryUC *usecase.
 		}
 
 		log.Printf("--- Document %d (Collection: %s, Score: %.4f) ---", i+1, collection, doc.Metadata["score"])
-		log.Printf("Source: %s", doc.Metadata["source"])
+		log.Printf("Source: %s", documentSource(doc))
 		log.Printf("Content: %s\n", doc.PageContent)
 	}
 }
+
+// documentSource descreve a origem de um documento recuperado, incluindo a
+// página, o título e o autor quando disponíveis (ex.: "manual.pdf, page 12 of 40")
+func documentSource(doc schema.Document) string {
+	parts := []string{fmt.Sprint(doc.Metadata["source"])}
+	if page, ok := doc.Metadata["page"]; ok {
+		if total, ok := doc.Metadata["total_pages"]; ok {
+			parts = append(parts, fmt.Sprintf("page %v of %v", page, total))
+		} else {
+			parts = append(parts, fmt.Sprintf("page %v", page))
+		}
+	}
+	if title, ok := doc.Metadata["title"].(string); ok && title != "" {
+		parts = append(parts, fmt.Sprintf("%q", title))
+	}
+	if author, ok := doc.Metadata["author"].(string); ok && author != "" {
+		parts = append(parts, "by "+author)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// streamPrinter imprime a resposta em streaming na saída padrão e o
+// raciocínio do modelo (generation.reasoning: separate) na saída de erro
+type streamPrinter struct {
+	inReasoning bool
+}
+
+func (p *streamPrinter) answer(chunk string) {
+	if p.inReasoning {
+		fmt.Fprint(os.Stderr, "\n=== End of reasoning ===\n\n")
+		p.inReasoning = false
+	}
+	fmt.Print(chunk)
+}
+
+func (p *streamPrinter) reasoning(chunk string) {
+	if !p.inReasoning {
+		fmt.Fprint(os.Stderr, "=== Reasoning ===\n")
+		p.inReasoning = true
+	}
+	fmt.Fprint(os.Stderr, chunk)
+}
+
+// reasoningOption envia o raciocínio do modelo para o printer
+func (p *streamPrinter) reasoningOption() usecase.CallOption {
+	return usecase.WithReasoningCallback(p.reasoning)
+}
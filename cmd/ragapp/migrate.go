@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/config"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/vectorstore"
+)
+
+// runMigrateCommand implementa o subcomando migrate, que converte os pontos
+// gravados pelo adaptador legado pgk/store ({"text", "metadata": {...}}) para
+// o layout plano, com os metadados no primeiro nível do payload:
+//
+//	migrate check [coleção...]  conta os pontos no layout legado, sem alterá-los
+//	migrate run [coleção...]    reescreve os payloads desses pontos
+//
+// Sem coleções, usa qdrant.collection. Uma ação vazia equivale a "check".
+func runMigrateCommand(ctx context.Context, w io.Writer, cfg *config.Config, action string, args []string) error {
+	if action != "" && action != "check" && action != "run" {
+		return fmt.Errorf("unknown migrate subcommand %q (expected check or run)", action)
+	}
+
+	// O layout legado só existe em coleções do Qdrant
+	if cfg.VectorStore.Backend == "local" {
+		return fmt.Errorf("payload migration only applies to the qdrant backend, not to vector_store.backend local")
+	}
+
+	store, err := vectorstore.NewQdrantVectorStore(cfg.Qdrant.URL, nil)
+	if err != nil {
+		return err
+	}
+	collections := args
+	if len(collections) == 0 {
+		collections = []string{cfg.Qdrant.Collection}
+	}
+
+	dryRun := action != "run"
+	for _, collection := range collections {
+		report, err := store.MigrateLegacyPayloads(ctx, collection, dryRun)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			fmt.Fprintf(w, "collection '%s': %d of %d points in the legacy layout\n", report.Collection, report.Legacy, report.Scanned)
+		} else {
+			fmt.Fprintf(w, "collection '%s': migrated %d of %d points\n", report.Collection, report.Migrated, report.Scanned)
+		}
+	}
+	if dryRun {
+		fmt.Fprintln(w, "nothing was written; run \"ragapp migrate run\" to migrate")
+	}
+	return nil
+}
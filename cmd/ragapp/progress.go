@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// progressBarWidth é a largura da barra em caracteres
+const progressBarWidth = 30
+
+// progressBar desenha o progresso da ingestão em uma única linha do terminal
+type progressBar struct {
+	w      io.Writer
+	total  int
+	done   int
+	failed int
+	chunks int
+}
+
+// newProgressBar retorna um observador que desenha uma barra de progresso em w
+func newProgressBar(w io.Writer) *progressBar {
+	return &progressBar{w: w}
+}
+
+// OnIngestionEvent atualiza os contadores e redesenha a barra
+func (p *progressBar) OnIngestionEvent(ev usecase.IngestionEvent) {
+	switch ev.Stage {
+	case usecase.StageDiscovered:
+		p.total += ev.TotalFiles
+	case usecase.StageUpserted:
+		p.chunks += ev.Chunks
+	case usecase.StageCompleted, usecase.StageUnchanged:
+		p.done++
+	case usecase.StageFailed:
+		p.done++
+		p.failed++
+	default:
+		return
+	}
+	p.render()
+}
+
+func (p *progressBar) render() {
+	filled := 0
+	if p.total > 0 {
+		filled = p.done * progressBarWidth / p.total
+	}
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+	fmt.Fprintf(p.w, "\r\033[K[%s] %d/%d files, %d chunks, %d failed", bar, p.done, p.total, p.chunks, p.failed)
+}
+
+// finish encerra a linha da barra de progresso
+func (p *progressBar) finish() {
+	if p.total > 0 {
+		fmt.Fprintln(p.w)
+	}
+}
+
+// isTerminal indica se f é um terminal interativo
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// printIngestionReport registra o resumo da ingestão e os arquivos que falharam
+func printIngestionReport(report *usecase.IngestionReport) {
+	if report == nil {
+		return
+	}
+	log.Printf("Ingestion report: %d files found, %d ingested, %d unchanged, %d failed, %d chunks stored, %d sources removed (%dms)",
+		report.FilesFound, report.FilesIngested, report.FilesUnchanged, report.FilesFailed,
+		report.ChunksStored, len(report.RemovedSources), report.DurationMS)
+	for _, f := range report.Failed() {
+		log.Printf("  FAILED %s: %s", f.Path, f.Error)
+	}
+}
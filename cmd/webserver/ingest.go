@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/config"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/vectorstore"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/jobs"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// uploadIngester processa os arquivos enviados em um job de ingestão em segundo plano
+type uploadIngester struct {
+	cfg         *config.Config
+	loader      usecase.DocumentLoader
+	splitter    usecase.TextSplitter
+	embedder    usecase.EmbeddingGenerator
+	vectorStore usecase.VectorStore
+	sparse      usecase.SparseEncoder // Busca híbrida; nil quando desativada
+	vectorSize  int                   // Dimensão dos embeddings, resolvida na inicialização
+}
+
+// run retorna a função executada pelo job para os arquivos informados.
+// Arquivos já marcados como falhos no envio são ignorados.
+func (ing *uploadIngester) run(files []jobs.FileProgress, perPdf bool) jobs.RunFunc {
+	return func(ctx context.Context, observer usecase.IngestionObserver) ([]*usecase.IngestionReport, error) {
+		var reports []*usecase.IngestionReport
+		processedFiles := 0
+
+		for _, file := range files {
+			if file.Stage == usecase.StageFailed {
+				continue
+			}
+			if ctx.Err() != nil {
+				return reports, ctx.Err()
+			}
+
+			report, err := ing.ingestFile(ctx, file, perPdf, observer)
+			if report != nil {
+				reports = append(reports, report)
+			}
+			if err != nil {
+				log.Printf("Erro ao processar %s: %v", file.Name, err)
+				continue
+			}
+			processedFiles++
+		}
+
+		if processedFiles == 0 {
+			return reports, errors.New("nenhum documento válido foi processado")
+		}
+		return reports, nil
+	}
+}
+
+// ingestFile ingere um único documento na coleção padrão ou em uma coleção própria
+func (ing *uploadIngester) ingestFile(ctx context.Context, file jobs.FileProgress, perPdf bool, observer usecase.IngestionObserver) (*usecase.IngestionReport, error) {
+	cfg := ing.cfg
+	store := ing.vectorStore
+	colName := cfg.Qdrant.Collection
+
+	// Falhas antes do pipeline também são reportadas ao observador
+	fail := func(err error) (*usecase.IngestionReport, error) {
+		observer.OnIngestionEvent(usecase.IngestionEvent{Stage: usecase.StageFailed, File: file.Path, Collection: colName, Error: err.Error()})
+		return nil, err
+	}
+
+	if perPdf {
+		// Usar o nome do arquivo (sem extensão) como nome da coleção, como na ingestão pela CLI
+		colName = usecase.CollectionNameForFile(filepath.Base(file.Name))
+
+		// Criar uma nova instância do vector store para esta coleção (no backend local, sobre as mesmas coleções)
+		docVectorStore, err := cfg.OpenVectorStore(ing.embedder, vectorstore.WithCollectionName(colName))
+		if err != nil {
+			log.Printf("Erro ao criar armazenamento de vetores para %s: %v", colName, err)
+			return fail(err)
+		}
+		// No backend local, cada instância mantém o arquivo aberto até ser fechada
+		if closer, ok := docVectorStore.(io.Closer); ok {
+			defer func() {
+				if err := closer.Close(); err != nil {
+					log.Printf("Aviso: falha ao fechar o armazenamento de vetores de %s: %v", colName, err)
+				}
+			}()
+		}
+
+		// Ensure the collection exists for this document
+		if err := docVectorStore.EnsureCollection(ctx, colName, ing.vectorSize); err != nil {
+			log.Printf("Erro ao garantir coleção para %s: %v", colName, err)
+			return fail(err)
+		}
+		store = docVectorStore
+	}
+
+	// Criar e executar o caso de uso de ingestão para este documento
+	opts := append(cfg.IngestionOptions(), usecase.WithIngestionObserver(observer), usecase.WithSparseEncoder(ing.sparse))
+	ingestionUseCase := usecase.NewIngestionUseCase(ing.loader, ing.splitter, ing.embedder, store, opts...)
+
+	// Extract directory and use the single file as the pattern
+	docDir := filepath.Dir(file.Path)
+	docFile := usecase.QuoteFilePattern(filepath.Base(file.Path))
+
+	report, err := ingestionUseCase.Execute(ctx, docDir, docFile, colName, ing.vectorSize)
+	if report == nil && err != nil {
+		return fail(err)
+	}
+	return report, err
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/jobs"
+)
+
+// registerJobRoutes registra as rotas de consulta e cancelamento de jobs de ingestão
+func registerJobRoutes(mux *http.ServeMux, manager *jobs.Manager, historyLimit int) {
+	// Histórico recente de jobs (mais novos primeiro)
+	mux.HandleFunc("GET /api/jobs", func(w http.ResponseWriter, r *http.Request) {
+		limit := historyLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n <= 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Parâmetro limit inválido"})
+				return
+			}
+			limit = n
+		}
+		writeJSON(w, http.StatusOK, manager.List(limit))
+	})
+
+	// Status e progresso por arquivo de um job
+	mux.HandleFunc("GET /api/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job, err := manager.Get(r.PathValue("id"))
+		if err != nil {
+			writeJobError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	})
+
+	// Cancelamento de um job em fila ou em execução
+	mux.HandleFunc("DELETE /api/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := manager.Cancel(r.PathValue("id")); err != nil {
+			writeJobError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]string{"message": "Cancelamento solicitado"})
+	})
+
+	// Progresso do job via SSE
+	mux.HandleFunc("GET /api/jobs/{id}/events", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		events, unsubscribe, err := manager.Subscribe(id)
+		if err != nil {
+			writeJobError(w, err)
+			return
+		}
+		defer unsubscribe()
+
+		// Configurar o stream SSE
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming não suportado", http.StatusInternalServerError)
+			return
+		}
+
+		// Enviar o estado atual antes dos eventos seguintes
+		if job, err := manager.Get(id); err == nil {
+			writeEvent(w, jobs.Event{Type: jobs.EventStatus, Job: job})
+			flusher.Flush()
+		}
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					// Job finalizado: enviar o estado final e sinalizar o fim do stream
+					if job, err := manager.Get(id); err == nil {
+						writeEvent(w, jobs.Event{Type: jobs.EventStatus, Job: job})
+					}
+					fmt.Fprintf(w, "data: [DONE]\n\n")
+					flusher.Flush()
+					return
+				}
+				writeEvent(w, ev)
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// writeEvent escreve um evento SSE nomeado com o tipo do evento
+func writeEvent(w http.ResponseWriter, ev jobs.Event) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+}
+
+// writeJobError traduz erros do gerenciador de jobs em respostas HTTP
+func writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Job não encontrado"})
+	case errors.Is(err, jobs.ErrFinished):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Job já finalizado"})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+// writeJSON escreve v como JSON com o status informado
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,63 +14,104 @@ import (
 	"strings"
 	"time"
 
-	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/llm"
-	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/loader"
-	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/splitter"
-	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/vectorstore"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/config"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/jobs"
 	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
 )
 
-const (
-	pdfDir         = "data/pdfs"
-	uploadDir      = "data/uploads"
-	qdrantURL      = "http://localhost:6333"
-	collectionName = "my_collection"
-	embedModel     = "nomic-embed-text"
-	genModel       = "deepseek-r1:8b"
-	vectorSize     = 768
-	chunkSize      = 1000
-	chunkOverlap   = 100
-	port           = 8020
-)
-
 func main() {
 	ctx := context.Background()
 
+	// Subcomando opcional: webserver config [print|validate] [flags]
+	args := os.Args[1:]
+	var configAction string
+	configCmd := len(args) > 0 && args[0] == "config"
+	if configCmd {
+		configAction, args = config.SplitCommand(args[1:])
+	}
+
+	// Carregar configuração (arquivo, variáveis de ambiente e flags)
+	cfg, _, err := config.Load("webserver", args)
+	if err != nil {
+		log.Fatalf("Falha ao carregar configuração: %v", err)
+	}
+	if configCmd {
+		if err := config.RunCommand(os.Stdout, cfg, configAction); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Configuração inválida:\n%v", err)
+	}
+
 	// Garantir que os diretórios necessários existam
-	os.MkdirAll(pdfDir, 0755)
-	os.MkdirAll(uploadDir, 0755)
+	os.MkdirAll(cfg.Ingestion.Dir, 0755)
+	os.MkdirAll(cfg.Server.UploadDir, 0755)
 
 	// Instanciar componentes do RAG
-	embedder, err := llm.NewOllamaEmbedder(embedModel)
+	modelEmbedder, err := cfg.Embedder()
 	if err != nil {
 		log.Fatalf("Falha ao criar embedder: %v", err)
 	}
 
-	queryLLM, err := llm.NewOllamaLLM(genModel)
+	// Cache persistente de embeddings (cache.enabled)
+	embedder, embeddingCache, err := cfg.CachedEmbedder(modelEmbedder)
+	if err != nil {
+		log.Printf("Aviso: cache de embeddings desativado: %v", err)
+	}
+	if embeddingCache != nil {
+		defer embeddingCache.Close()
+	}
+
+	queryLLM, err := cfg.GenerationLLM()
 	if err != nil {
 		log.Fatalf("Falha ao criar LLM: %v", err)
 	}
 
-	// Instanciar o adaptador do Qdrant para armazenamento de vetores
-	vectorStore, err := vectorstore.NewQdrantVectorStore(qdrantURL, embedder)
+	// Instanciar o armazenamento de vetores do backend configurado (Qdrant ou local), com os índices de payload configurados
+	vectorStore, err := cfg.OpenVectorStore(embedder)
+	if err != nil {
+		log.Fatalf("Falha ao criar armazenamento de vetores: %v", err)
+	}
+
+	// Dimensão dos vetores: detectada a partir do modelo de embedding (qdrant.vector_size = 0) ou conferida com ele
+	vectorSize, err := usecase.ResolveVectorSize(ctx, embedder, cfg.Qdrant.VectorSize)
 	if err != nil {
-		log.Fatalf("Falha ao criar adaptador do Qdrant: %v", err)
+		log.Fatalf("Falha ao determinar a dimensão dos embeddings: %v", err)
 	}
 
-	// Garantir coleção padrão para ingestão inicial
-	if err := vectorStore.EnsureCollection(ctx, collectionName, vectorSize); err != nil {
-		log.Fatalf("Falha ao garantir coleção '%s': %v", collectionName, err)
+	// Garantir coleção padrão para ingestão inicial (e sua compatibilidade com o modelo de embedding)
+	if err := vectorStore.EnsureCollection(ctx, cfg.Qdrant.Collection, vectorSize); err != nil {
+		log.Fatalf("Falha ao garantir coleção '%s': %v", cfg.Qdrant.Collection, err)
 	}
-   
+
 	// Instanciar retriever para consultas multi-coleção
-	retriever, err := vectorstore.NewQdrantRetriever(qdrantURL, embedder)
+	retriever, err := cfg.OpenRetriever(embedder)
 	if err != nil {
-		log.Fatalf("Falha ao criar retriever Qdrant: %v", err)
+		log.Fatalf("Falha ao criar retriever: %v", err)
 	}
 
-	// Instanciar caso de uso de consulta (usa retriever multi-coleção)
-	queryUseCase := usecase.NewQueryUseCase(embedder, retriever, queryLLM)
+	// Jobs de ingestão em segundo plano, com histórico persistido em disco
+	jobStore, err := jobs.NewFileStore(cfg.Server.JobsDir)
+	if err != nil {
+		log.Fatalf("Falha ao criar armazenamento de jobs: %v", err)
+	}
+	jobManager, err := jobs.NewManager(jobStore, cfg.Server.JobWorkers, cfg.Server.JobHistory)
+	if err != nil {
+		log.Fatalf("Falha ao carregar histórico de jobs: %v", err)
+	}
+	docLoader := cfg.DocumentLoader()
+	textSplitter, err := cfg.TextSplitter(embedder)
+	if err != nil {
+		log.Fatalf("Falha ao inicializar o divisor de texto: %v", err)
+	}
+	// Vocabulário BM25 da busca híbrida (retrieval.hybrid), compartilhado pelos jobs de ingestão e pelas consultas
+	sparseEncoder := cfg.SparseEncoder()
+	ingester := &uploadIngester{cfg: cfg, loader: docLoader, splitter: textSplitter, embedder: embedder, vectorStore: vectorStore, sparse: sparseEncoder, vectorSize: vectorSize}
+
+	// Instanciar caso de uso de consulta (usa retriever multi-coleção), com as opções de geração padrão
+	queryUseCase := usecase.NewQueryUseCase(embedder, retriever, queryLLM, usecase.WithLLMOptions(cfg.CallOptions()), usecase.WithHybridSearch(sparseEncoder, cfg.HybridOptions()))
 
 	// Configurar rotas
 	mux := http.NewServeMux()
@@ -81,7 +123,6 @@ func main() {
 	// Rota principal (página inicial)
 	mux.HandleFunc("/", serveIndex)
 
-
 	// API para consultas com streaming
 	mux.HandleFunc("/api/stream", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -95,12 +136,29 @@ func main() {
 			return
 		}
 
-       	// Listar coleções disponíveis para consulta
-       	collections, err := retriever.ListCollections(ctx)
-       	if err != nil {
-       		http.Error(w, fmt.Sprintf("Falha ao listar coleções: %v", err), http.StatusInternalServerError)
-       		return
-       	}
+		// Opções de geração da requisição (temperature, top_p, max_tokens...), sobre as da configuração
+		callOpts, err := parseCallOptions(r.URL.Query())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Parâmetros de geração inválidos: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		// Filtro de metadados da requisição (filter=chave=valor), somado ao da configuração
+		filter, err := parseFilter(r.URL.Query(), cfg.Retrieval.Filter)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Filtro inválido: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		// O contexto da requisição cancela a busca e a geração quando o cliente desconecta
+		reqCtx := r.Context()
+
+		// Listar coleções disponíveis para consulta
+		collections, err := retriever.ListCollections(reqCtx)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Falha ao listar coleções: %v", err), http.StatusInternalServerError)
+			return
+		}
 
 		// Configurar o stream SSE
 		w.Header().Set("Content-Type", "text/event-stream")
@@ -114,19 +172,28 @@ func main() {
 			return
 		}
 
-		// Callback para processar o streaming
+		// Callbacks para processar o streaming: a resposta em eventos padrão e o
+		// raciocínio do modelo (generation.reasoning: separate) em eventos "reasoning"
 		streamCallback := func(chunk string) {
-			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			writeSSE(w, "", chunk)
+			flusher.Flush()
+		}
+		reasoningCallback := func(chunk string) {
+			writeSSE(w, "reasoning", chunk)
 			flusher.Flush()
 		}
 
-       // Executar a query com streaming em todas as coleções
-       _, err = queryUseCase.ExecuteWithStreamingMultiCollection(ctx, question, collections, 2, streamCallback)
-       if err != nil {
-           // Enviar o erro como evento
-           fmt.Fprintf(w, "data: Erro: %s\n\n", err.Error())
-           flusher.Flush()
-       }
+		// Executar a query com streaming em todas as coleções
+		_, err = queryUseCase.With(usecase.WithSearchFilter(filter)).ExecuteWithStreamingMultiCollection(reqCtx, question, collections, 2, streamCallback, usecase.WithCallOptions(callOpts), usecase.WithReasoningCallback(reasoningCallback))
+		if reqCtx.Err() != nil {
+			// Cliente desconectado: não há para quem enviar o restante
+			return
+		}
+		if err != nil {
+			// Enviar o erro como evento
+			writeSSE(w, "", "Erro: "+err.Error())
+			flusher.Flush()
+		}
 
 		// Sinalizar o fim do stream
 		fmt.Fprintf(w, "data: [DONE]\n\n")
@@ -160,98 +227,69 @@ func main() {
 			return
 		}
 
-		// Diretório para salvar os uploads
-		timestamp := time.Now().Format("20060102150405")
-		uploadSubDir := filepath.Join(uploadDir, timestamp)
-		if err := os.MkdirAll(uploadSubDir, 0755); err != nil {
+		// Diretório próprio de cada envio, para que envios simultâneos não se sobrescrevam
+		if err := os.MkdirAll(cfg.Server.UploadDir, 0755); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Erro ao criar diretório de upload"})
+			return
+		}
+		uploadSubDir, err := os.MkdirTemp(cfg.Server.UploadDir, time.Now().Format("20060102150405")+"-")
+		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"error": "Erro ao criar diretório de upload"})
 			return
 		}
 
-		// Processar os arquivos
-		processedFiles := 0
+		// Salvar os arquivos; a ingestão ocorre em segundo plano
+		var jobFiles []jobs.FileProgress
+		accepted := 0
 		for _, fileHeader := range files {
-			// Verificar a extensão
-			if !strings.HasSuffix(strings.ToLower(fileHeader.Filename), ".pdf") {
-				continue // Ignorar arquivos não-PDF
-			}
+			file := jobs.FileProgress{Name: fileHeader.Filename}
 
 			// Salvar o arquivo
-			pdfPath, err := saveUploadedFile(fileHeader, uploadSubDir)
+			docPath, err := saveUploadedFile(fileHeader, uploadSubDir)
 			if err != nil {
 				log.Printf("Erro ao salvar arquivo %s: %v", fileHeader.Filename, err)
+				file.Stage = usecase.StageFailed
+				file.Error = fmt.Sprintf("erro ao salvar arquivo: %v", err)
+				jobFiles = append(jobFiles, file)
 				continue
 			}
 
-			// Processar o PDF
-			if perPdf {
-				// Usar o nome do arquivo (sem extensão) como nome da coleção
-				baseName := strings.TrimSuffix(filepath.Base(fileHeader.Filename), filepath.Ext(fileHeader.Filename))
-				colName := strings.ReplaceAll(baseName, " ", "_")
-				colName = strings.ToLower(colName)
-
-				// Criar uma nova instância do vector store para esta coleção
-				pdfVectorStore, err := vectorstore.NewQdrantVectorStore(qdrantURL, embedder)
-				if err != nil {
-					log.Printf("Erro ao criar adaptador do Qdrant para %s: %v", colName, err)
-					continue
-				}
-
-				// Ensure the collection exists for this PDF
-				if err := pdfVectorStore.EnsureCollection(ctx, colName, vectorSize); err != nil {
-					log.Printf("Erro ao garantir coleção para %s: %v", colName, err)
-					continue
-				}
-
-				// Criar e executar o caso de uso de ingestão para este PDF
-				pdfLoader := loader.NewPDFLoader()
-				textSplitter := splitter.NewRecursiveCharacterSplitter(chunkSize, chunkOverlap)
-				ingestionUseCase := usecase.NewIngestionUseCase(pdfLoader, textSplitter, embedder, pdfVectorStore)
-
-				// Extract directory and use the single PDF file as the pattern
-				pdfDir := filepath.Dir(pdfPath)
-				pdfFile := filepath.Base(pdfPath)
-
-				err = ingestionUseCase.Execute(ctx, pdfDir, pdfFile, colName, vectorSize)
-				if err != nil {
-					log.Printf("Erro ao processar %s: %v", fileHeader.Filename, err)
-					continue
-				}
-			} else {
-				// Usar a coleção padrão para todos os PDFs
-				pdfLoader := loader.NewPDFLoader()
-				textSplitter := splitter.NewRecursiveCharacterSplitter(chunkSize, chunkOverlap)
-				ingestionUseCase := usecase.NewIngestionUseCase(pdfLoader, textSplitter, embedder, vectorStore)
-
-				// Extract directory and use the single PDF file as the pattern
-				pdfDir := filepath.Dir(pdfPath)
-				pdfFile := filepath.Base(pdfPath)
-
-				err = ingestionUseCase.Execute(ctx, pdfDir, pdfFile, collectionName, vectorSize)
-				if err != nil {
-					log.Printf("Erro ao processar %s: %v", fileHeader.Filename, err)
-					continue
-				}
+			// Verificar o tipo pela extensão ou pelo conteúdo
+			if !docLoader.Supports(docPath) {
+				os.Remove(docPath)
+				file.Stage = usecase.StageFailed
+				file.Error = "tipo de arquivo não suportado (use " + strings.Join(docLoader.Extensions(), ", ") + ")"
+				jobFiles = append(jobFiles, file)
+				continue // Ignorar arquivos não suportados
 			}
 
-			processedFiles++
+			file.Name = filepath.Base(docPath)
+			file.Path = docPath
+			jobFiles = append(jobFiles, file)
+			accepted++
 		}
 
-		if processedFiles == 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Nenhum arquivo PDF válido foi processado"})
+		if accepted == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "Nenhum documento válido foi enviado",
+				"files": jobFiles,
+			})
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{
-			"message": fmt.Sprintf("%d arquivos processados com sucesso", processedFiles),
-		})
+		// Enfileirar o job e responder imediatamente com seu ID
+		job := jobManager.Submit(&jobs.Job{PerPDF: perPdf, Files: jobFiles}, ingester.run(jobFiles, perPdf))
+		w.Header().Set("Location", "/api/jobs/"+job.ID)
+		writeJSON(w, http.StatusAccepted, job)
 	})
 
+	// APIs de acompanhamento dos jobs de ingestão
+	registerJobRoutes(mux, jobManager, cfg.Server.JobHistory)
+
 	// Iniciar o servidor
-	serverAddr := fmt.Sprintf(":%d", port)
+	serverAddr := fmt.Sprintf(":%d", cfg.Server.Port)
 	log.Printf("Servidor iniciado em http://localhost%s", serverAddr)
 	log.Fatal(http.ListenAndServe(serverAddr, mux))
 }
@@ -265,22 +303,38 @@ func serveIndex(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "web/templates/index.html")
 }
 
-// saveUploadedFile salva um arquivo enviado e retorna o caminho completo
+// saveUploadedFile salva um arquivo enviado e retorna o caminho completo.
+// Somente o nome base do arquivo é usado, e um arquivo existente nunca é
+// sobrescrito.
 func saveUploadedFile(fileHeader *multipart.FileHeader, destDir string) (string, error) {
+	// Alguns navegadores enviam o caminho completo, inclusive com barras do Windows
+	name := filepath.Base(filepath.FromSlash(strings.ReplaceAll(fileHeader.Filename, "\\", "/")))
+	if name == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+		return "", fmt.Errorf("nome de arquivo inválido: %q", fileHeader.Filename)
+	}
+
 	src, err := fileHeader.Open()
 	if err != nil {
 		return "", err
 	}
 	defer src.Close()
 
-	destPath := filepath.Join(destDir, fileHeader.Filename)
-	dest, err := os.Create(destPath)
+	destPath := filepath.Join(destDir, name)
+	dest, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("o arquivo %s foi enviado mais de uma vez", name)
+	}
 	if err != nil {
 		return "", err
 	}
-	defer dest.Close()
 
 	if _, err := io.Copy(dest, src); err != nil {
+		dest.Close()
+		os.Remove(destPath)
+		return "", err
+	}
+	if err := dest.Close(); err != nil {
+		os.Remove(destPath)
 		return "", err
 	}
 
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// uploadedFile returns the header of a file sent under name in a multipart form.
+func uploadedFile(t *testing.T, name, content string) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("pdfs", "placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	mw.Close()
+
+	form, err := multipart.NewReader(&body, mw.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	fh := form.File["pdfs"][0]
+	// Set after parsing, as the reader already strips directories
+	fh.Filename = name
+	return fh
+}
+
+func TestSaveUploadedFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"relatorio.pdf", "relatorio.pdf"},
+		{"../../etc/relatorio.pdf", "relatorio.pdf"},
+		{"/tmp/relatorio.pdf", "relatorio.pdf"},
+		{`C:\Users\ana\relatorio.pdf`, "relatorio.pdf"},
+		{`..\..\relatorio.pdf`, "relatorio.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path, err := saveUploadedFile(uploadedFile(t, tt.name, "conteúdo"), dir)
+			if err != nil {
+				t.Fatalf("saveUploadedFile: %v", err)
+			}
+			if path != filepath.Join(dir, tt.want) {
+				t.Errorf("saved to %s, want %s", path, filepath.Join(dir, tt.want))
+			}
+			if data, err := os.ReadFile(path); err != nil || string(data) != "conteúdo" {
+				t.Errorf("saved content = %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestSaveUploadedFileRejectsInvalidNames(t *testing.T) {
+	for _, name := range []string{"", ".", "..", "/", "a/..", `..\`} {
+		dir := t.TempDir()
+		if path, err := saveUploadedFile(uploadedFile(t, name, "x"), dir); err == nil {
+			t.Errorf("saveUploadedFile(%q) saved %s", name, path)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("saveUploadedFile(%q) left %d entries", name, len(entries))
+		}
+	}
+}
+
+func TestSaveUploadedFileKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := saveUploadedFile(uploadedFile(t, "relatorio.pdf", "primeiro"), dir); err != nil {
+		t.Fatalf("saveUploadedFile: %v", err)
+	}
+	_, err := saveUploadedFile(uploadedFile(t, "sub/relatorio.pdf", "segundo"), dir)
+	if err == nil || !strings.Contains(err.Error(), "mais de uma vez") {
+		t.Errorf("saving the same name twice returned %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "relatorio.pdf")); string(data) != "primeiro" {
+		t.Errorf("the first file was overwritten with %q", data)
+	}
+}
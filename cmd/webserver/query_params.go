@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// parseCallOptions lê as opções de geração dos parâmetros da consulta:
+// temperature, top_p, top_k, num_ctx, max_tokens, stop (repetido ou separado
+// por vírgulas), seed, keep_alive e reasoning. Parâmetros ausentes mantêm a configuração.
+func parseCallOptions(query url.Values) (usecase.CallOptions, error) {
+	var opts usecase.CallOptions
+
+	if v := query.Get("temperature"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, fmt.Errorf("temperature: %q não é um número", v)
+		}
+		opts.Temperature = &t
+	}
+	if v := query.Get("top_p"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, fmt.Errorf("top_p: %q não é um número", v)
+		}
+		opts.TopP = p
+	}
+
+	for _, p := range []struct {
+		name  string
+		value *int
+	}{
+		{"top_k", &opts.TopK},
+		{"num_ctx", &opts.NumCtx},
+		{"max_tokens", &opts.MaxTokens},
+		{"seed", &opts.Seed},
+	} {
+		v := query.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("%s: %q não é um número inteiro", p.name, v)
+		}
+		*p.value = n
+	}
+
+	for _, v := range query["stop"] {
+		for _, stop := range strings.Split(v, ",") {
+			if stop != "" {
+				opts.Stop = append(opts.Stop, stop)
+			}
+		}
+	}
+	opts.KeepAlive = query.Get("keep_alive")
+	opts.Reasoning = usecase.ReasoningMode(query.Get("reasoning"))
+
+	return opts, opts.Validate()
+}
+
+// parseFilter lê o filtro de metadados dos parâmetros "filter" da consulta
+// (repetidos ou com condições separadas por vírgulas, ex.: filter=relative_path=a.pdf),
+// que se somam às condições de retrieval.filter.
+func parseFilter(query url.Values, base []string) (*usecase.Filter, error) {
+	exprs := append(append([]string(nil), base...), query["filter"]...)
+	return usecase.ParseFilter(exprs...)
+}
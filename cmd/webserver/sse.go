@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeSSE escreve um evento server-sent; event vazio usa o tipo padrão
+// ("message"). Cada linha de data vira uma linha "data:", que o navegador
+// junta novamente com quebras de linha.
+func writeSSE(w io.Writer, event, data string) {
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RunCommand implements the "config" subcommand shared by both binaries:
+//
+//	config print     prints the effective merged configuration as YAML
+//	config validate  validates the effective configuration
+//
+// An empty sub defaults to "print".
+func RunCommand(w io.Writer, cfg *Config, sub string) error {
+	switch sub {
+	case "", "print":
+		return cfg.Print(w)
+	case "validate":
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration:\n%w", err)
+		}
+		fmt.Fprintln(w, "configuration is valid")
+		return nil
+	default:
+		return fmt.Errorf("unknown config subcommand %q (expected print or validate)", sub)
+	}
+}
+
+// SplitCommand removes a leading subcommand word from args, so that flags
+// may follow it ("config print -config app.yaml"). It returns an empty
+// command when args start with a flag.
+func SplitCommand(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+// Print writes the configuration as YAML, noting the file it came from.
+// Secrets are masked.
+func (c *Config) Print(w io.Writer) error {
+	masked := *c
+	if masked.OpenAI.APIKey != "" {
+		masked.OpenAI.APIKey = "********"
+	}
+
+	source := "(none)"
+	if c.File != "" {
+		source = c.File
+	}
+	fmt.Fprintf(w, "# config file: %s\n", source)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&masked); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,737 @@
+// Package config loads the application configuration shared by the ragapp
+// and webserver binaries.
+//
+// Values are resolved in layers, each one overriding the previous:
+// built-in defaults, an optional YAML or TOML file, RAG_* environment
+// variables and finally command-line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/cache"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/llm"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/loader"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/sparse"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/splitter"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/vectorstore"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// Config is the effective, merged application configuration.
+type Config struct {
+	Qdrant      QdrantConfig      `yaml:"qdrant" toml:"qdrant"`
+	VectorStore VectorStoreConfig `yaml:"vector_store" toml:"vector_store"`
+	Models      ModelsConfig      `yaml:"models" toml:"models"`
+	OpenAI      OpenAIConfig      `yaml:"openai" toml:"openai"`
+	Fake        FakeConfig        `yaml:"fake" toml:"fake"`
+	Generation  GenerationConfig  `yaml:"generation" toml:"generation"`
+	Retrieval   RetrievalConfig   `yaml:"retrieval" toml:"retrieval"`
+	Ingestion   IngestionConfig   `yaml:"ingestion" toml:"ingestion"`
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Cache       CacheConfig       `yaml:"cache" toml:"cache"`
+
+	// File is the configuration file the values were read from, if any.
+	File string `yaml:"-" toml:"-"`
+}
+
+// QdrantConfig holds the vector database settings.
+type QdrantConfig struct {
+	URL        string `yaml:"url" toml:"url"`
+	Collection string `yaml:"collection" toml:"collection"`
+	// VectorSize is the embedding dimension; 0 detects it from the
+	// embedding model, a positive value must match it.
+	VectorSize int `yaml:"vector_size" toml:"vector_size"`
+	// PayloadIndexes declares the payload indexes ("field:type", e.g.
+	// "page:integer") created with the collections, speeding up filters.
+	PayloadIndexes []string `yaml:"payload_indexes" toml:"payload_indexes"`
+}
+
+// VectorStoreConfig selects where the vectors are stored: "qdrant" (the
+// server of QdrantConfig) or "local", an embedded store kept in the bbolt
+// file Path, for small corpora and runs without a Qdrant server. The
+// collection and vector size of QdrantConfig apply to both backends.
+type VectorStoreConfig struct {
+	Backend string `yaml:"backend" toml:"backend"`
+	Path    string `yaml:"path" toml:"path"`
+}
+
+// ModelsConfig holds the model names and the backend serving each of them:
+// "ollama", "openai" (any OpenAI-compatible server, see OpenAIConfig) or the
+// offline stand-ins "hash" (embeddings of qdrant.vector_size dimensions, 768
+// when detected) and "fake" (scripted answers, see FakeConfig).
+type ModelsConfig struct {
+	Embedding         string `yaml:"embedding" toml:"embedding"`
+	Generation        string `yaml:"generation" toml:"generation"`
+	EmbeddingBackend  string `yaml:"embedding_backend" toml:"embedding_backend"`
+	GenerationBackend string `yaml:"generation_backend" toml:"generation_backend"`
+}
+
+// OpenAIConfig holds the settings of the OpenAI-compatible server (OpenAI,
+// llama.cpp server, vLLM, LM Studio, LocalAI).
+type OpenAIConfig struct {
+	// BaseURL is the API root including the version, e.g. "http://localhost:8080/v1".
+	BaseURL string `yaml:"base_url" toml:"base_url"`
+	APIKey  string `yaml:"api_key" toml:"api_key"`
+}
+
+// FakeConfig scripts the answers of the "fake" generation backend.
+type FakeConfig struct {
+	// Responses are returned in order, the last one repeated; when empty
+	// the answer echoes the question.
+	Responses    []string `yaml:"responses" toml:"responses"`
+	ChunkSize    int      `yaml:"chunk_size" toml:"chunk_size"` // characters per streamed chunk
+	ChunkDelayMS int      `yaml:"chunk_delay_ms" toml:"chunk_delay_ms"`
+}
+
+// GenerationConfig holds the default options of the answer generation
+// calls (see usecase.CallOptions). Unset (zero) values leave the model's
+// defaults; the web server accepts per-request overrides.
+type GenerationConfig struct {
+	Temperature *float64 `yaml:"temperature" toml:"temperature"`
+	TopP        float64  `yaml:"top_p" toml:"top_p"`
+	TopK        int      `yaml:"top_k" toml:"top_k"`
+	NumCtx      int      `yaml:"num_ctx" toml:"num_ctx"` // Ollama only
+	MaxTokens   int      `yaml:"max_tokens" toml:"max_tokens"`
+	Stop        []string `yaml:"stop" toml:"stop"`
+	Seed        int      `yaml:"seed" toml:"seed"`
+	KeepAlive   string   `yaml:"keep_alive" toml:"keep_alive"` // Ollama only, e.g. "10m" or "-1"
+	// Reasoning handles the <think> blocks of thinking models: "inline",
+	// "hide", "separate" (shown apart from the answer) or "log".
+	Reasoning string `yaml:"reasoning" toml:"reasoning"`
+}
+
+// RetrievalConfig holds the document retrieval settings.
+type RetrievalConfig struct {
+	// Filter holds filter expressions restricting the documents of every
+	// query (see usecase.ParseFilter), e.g. "tenant=acme" or "lang=pt|en".
+	Filter []string `yaml:"filter" toml:"filter"`
+
+	// Hybrid also stores BM25 sparse vectors of the chunks, from a
+	// vocabulary kept in VocabularyPath, and fuses their search results with
+	// those of the embeddings: by reciprocal rank ("rrf", with constant
+	// RRFK) or by score ("weighted", DenseWeight being the weight 0-1 of
+	// the embedding scores). Collections must be created with it enabled.
+	Hybrid         bool    `yaml:"hybrid" toml:"hybrid"`
+	Fusion         string  `yaml:"fusion" toml:"fusion"`
+	RRFK           int     `yaml:"rrf_k" toml:"rrf_k"`
+	DenseWeight    float64 `yaml:"dense_weight" toml:"dense_weight"`
+	VocabularyPath string  `yaml:"vocabulary_path" toml:"vocabulary_path"`
+}
+
+// IngestionConfig holds the document ingestion settings.
+type IngestionConfig struct {
+	// Mode is "incremental" (keep the collection, only re-ingest changed
+	// files) or "recreate" (drop the collection first).
+	Mode string `yaml:"mode" toml:"mode"`
+	Dir  string `yaml:"dir" toml:"dir"`
+	// Pattern holds comma-separated globs relative to Dir; "**" matches any
+	// number of directories and a "!" prefix excludes matching files.
+	Pattern      string `yaml:"pattern" toml:"pattern"`
+	ChunkSize    int    `yaml:"chunk_size" toml:"chunk_size"`
+	ChunkOverlap int    `yaml:"chunk_overlap" toml:"chunk_overlap"`
+
+	// Splitter is "characters" (ChunkSize and ChunkOverlap count characters)
+	// or "tokens" (chunks measured with the tokenizer). Token sizes of 0 use
+	// the preset of the embedding model.
+	Splitter           string `yaml:"splitter" toml:"splitter"`
+	ChunkTokens        int    `yaml:"chunk_tokens" toml:"chunk_tokens"`
+	ChunkOverlapTokens int    `yaml:"chunk_overlap_tokens" toml:"chunk_overlap_tokens"`
+	MaxInputTokens     int    `yaml:"max_input_tokens" toml:"max_input_tokens"`
+	TokenizerEncoding  string `yaml:"tokenizer_encoding" toml:"tokenizer_encoding"`
+
+	// SplitByHeadings splits Markdown and HTML documents by section, storing
+	// the heading breadcrumb of each chunk; BreadcrumbPrefix also prepends it
+	// to the embedded text.
+	SplitByHeadings  bool `yaml:"split_by_headings" toml:"split_by_headings"`
+	BreadcrumbPrefix bool `yaml:"breadcrumb_prefix" toml:"breadcrumb_prefix"`
+
+	// SemanticChunking cuts chunks where the similarity between consecutive
+	// sentences falls below SemanticPercentile (0-100). Chunks smaller than
+	// SemanticMinChunk (0: a quarter of the chunk size) are not cut.
+	SemanticChunking   bool `yaml:"semantic_chunking" toml:"semantic_chunking"`
+	SemanticPercentile int  `yaml:"semantic_percentile" toml:"semantic_percentile"`
+	SemanticMinChunk   int  `yaml:"semantic_min_chunk" toml:"semantic_min_chunk"`
+
+	// ParentRetrieval embeds small chunks but answers from the larger parent
+	// sections they come from: the loaded documents (PDF pages, records,
+	// files) or, when ParentChunkSize is set, sections of that size measured
+	// like the chunks (characters or tokens).
+	ParentRetrieval bool `yaml:"parent_retrieval" toml:"parent_retrieval"`
+	ParentChunkSize int  `yaml:"parent_chunk_size" toml:"parent_chunk_size"`
+
+	// Directory walking
+	MaxFileSizeMB  int  `yaml:"max_file_size_mb" toml:"max_file_size_mb"` // 0 disables the limit
+	FollowSymlinks bool `yaml:"follow_symlinks" toml:"follow_symlinks"`
+	IncludeHidden  bool `yaml:"include_hidden" toml:"include_hidden"`
+
+	// Structured records (CSV, TSV, JSON Lines): fields used as document
+	// text (all fields when empty) and the field identifying a record.
+	RecordContentFields []string `yaml:"record_content_fields" toml:"record_content_fields"`
+	RecordIDField       string   `yaml:"record_id_field" toml:"record_id_field"`
+
+	// Streaming pipeline sizing
+	LoadWorkers      int `yaml:"load_workers" toml:"load_workers"`
+	EmbedBatchSize   int `yaml:"embed_batch_size" toml:"embed_batch_size"`
+	EmbedConcurrency int `yaml:"embed_concurrency" toml:"embed_concurrency"`
+	UpsertBatchSize  int `yaml:"upsert_batch_size" toml:"upsert_batch_size"`
+}
+
+// ServerConfig holds the web server settings.
+type ServerConfig struct {
+	Port      int    `yaml:"port" toml:"port"`
+	UploadDir string `yaml:"upload_dir" toml:"upload_dir"`
+
+	// Background ingestion jobs
+	JobsDir    string `yaml:"jobs_dir" toml:"jobs_dir"`
+	JobWorkers int    `yaml:"job_workers" toml:"job_workers"`
+	JobHistory int    `yaml:"job_history" toml:"job_history"`
+}
+
+// CacheConfig holds the persistent embedding cache settings.
+type CacheConfig struct {
+	Enabled    bool   `yaml:"enabled" toml:"enabled"`
+	Path       string `yaml:"path" toml:"path"`
+	MaxEntries int    `yaml:"max_entries" toml:"max_entries"` // 0 disables the limit
+	// QueryTTLMinutes is how long query embeddings are reused; 0 disables
+	// caching queries.
+	QueryTTLMinutes int `yaml:"query_ttl_minutes" toml:"query_ttl_minutes"`
+}
+
+// Default returns the built-in configuration.
+func Default() *Config {
+	return &Config{
+		Qdrant: QdrantConfig{
+			URL:        "http://localhost:6333",
+			Collection: "my_collection",
+			VectorSize: 0, // Detected from the embedding model
+
+			PayloadIndexes: []string{"source:keyword"}, // Used by incremental ingestion
+		},
+		VectorStore: VectorStoreConfig{
+			Backend: "qdrant",
+			Path:    "data/vectors/vectors.db",
+		},
+		Models: ModelsConfig{
+			Embedding:  "nomic-embed-text",
+			Generation: "deepseek-r1:8b",
+
+			EmbeddingBackend:  "ollama",
+			GenerationBackend: "ollama",
+		},
+		OpenAI: OpenAIConfig{
+			BaseURL: "http://localhost:8080/v1",
+		},
+		Fake: FakeConfig{
+			ChunkSize:    8,
+			ChunkDelayMS: 20,
+		},
+		Generation: GenerationConfig{
+			Reasoning: string(usecase.ReasoningSeparate),
+		},
+		Retrieval: RetrievalConfig{
+			Fusion:         string(usecase.FusionRRF),
+			RRFK:           60,
+			DenseWeight:    0.5,
+			VocabularyPath: "data/sparse/vocabulary.json",
+		},
+		Ingestion: IngestionConfig{
+			Mode:         "incremental",
+			Dir:          "data/pdfs",
+			Pattern:      "**", // Files without a registered loader are skipped
+			ChunkSize:    1000,
+			ChunkOverlap: 100,
+
+			Splitter:          "characters",
+			TokenizerEncoding: splitter.DefaultTokenEncoding,
+
+			SemanticPercentile: 5,
+
+			FollowSymlinks: true,
+
+			LoadWorkers:      4,
+			EmbedBatchSize:   32,
+			EmbedConcurrency: 2,
+			UpsertBatchSize:  256,
+		},
+		Server: ServerConfig{
+			Port:      8020,
+			UploadDir: "data/uploads",
+
+			JobsDir:    "data/jobs",
+			JobWorkers: 1,
+			JobHistory: 50,
+		},
+		Cache: CacheConfig{
+			Path:            "data/cache/embeddings.db",
+			MaxEntries:      200000, // About 600 MB of 768-dimension vectors
+			QueryTTLMinutes: 24 * 60,
+		},
+	}
+}
+
+// Validate checks the configuration for inconsistent or missing values.
+// All problems found are reported together.
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.VectorStore.Backend {
+	case "qdrant":
+		if err := validateURL(c.Qdrant.URL); err != nil {
+			errs = append(errs, fmt.Errorf("qdrant.url: %w", err))
+		}
+	case "local":
+		if c.VectorStore.Path == "" {
+			errs = append(errs, errors.New("vector_store.path: must not be empty with the local backend"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("vector_store.backend: must be qdrant or local, got %q", c.VectorStore.Backend))
+	}
+	if c.Qdrant.Collection == "" {
+		errs = append(errs, errors.New("qdrant.collection: must not be empty"))
+	}
+	if c.Qdrant.VectorSize < 0 {
+		errs = append(errs, fmt.Errorf("qdrant.vector_size: must not be negative, got %d", c.Qdrant.VectorSize))
+	}
+	if _, err := c.PayloadIndexes(); err != nil {
+		errs = append(errs, fmt.Errorf("qdrant.payload_indexes: %w", err))
+	}
+
+	if c.Models.Embedding == "" {
+		errs = append(errs, errors.New("models.embedding: must not be empty"))
+	}
+	if c.Models.Generation == "" {
+		errs = append(errs, errors.New("models.generation: must not be empty"))
+	}
+	switch c.Models.EmbeddingBackend {
+	case "ollama", "openai", "hash":
+	default:
+		errs = append(errs, fmt.Errorf("models.embedding_backend: must be ollama, openai or hash, got %q", c.Models.EmbeddingBackend))
+	}
+	switch c.Models.GenerationBackend {
+	case "ollama", "openai", "fake":
+	default:
+		errs = append(errs, fmt.Errorf("models.generation_backend: must be ollama, openai or fake, got %q", c.Models.GenerationBackend))
+	}
+	if c.Fake.ChunkSize <= 0 {
+		errs = append(errs, fmt.Errorf("fake.chunk_size: must be positive, got %d", c.Fake.ChunkSize))
+	}
+	if c.Fake.ChunkDelayMS < 0 {
+		errs = append(errs, fmt.Errorf("fake.chunk_delay_ms: must not be negative, got %d", c.Fake.ChunkDelayMS))
+	}
+	if err := c.CallOptions().Validate(); err != nil {
+		// Each invalid option is reported as its own generation.* problem
+		optErrs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			optErrs = joined.Unwrap()
+		}
+		for _, e := range optErrs {
+			errs = append(errs, fmt.Errorf("generation.%w", e))
+		}
+	}
+	if _, err := c.SearchFilter(); err != nil {
+		errs = append(errs, fmt.Errorf("retrieval.filter: %w", err))
+	}
+	if err := c.HybridOptions().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("retrieval.%w", err))
+	}
+	if c.Retrieval.Hybrid && c.Retrieval.VocabularyPath == "" {
+		errs = append(errs, errors.New("retrieval.vocabulary_path: must not be empty with hybrid search"))
+	}
+	if c.Models.EmbeddingBackend == "openai" || c.Models.GenerationBackend == "openai" {
+		if err := validateURL(c.OpenAI.BaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("openai.base_url: %w", err))
+		}
+	}
+
+	if c.Ingestion.Mode != "incremental" && c.Ingestion.Mode != "recreate" {
+		errs = append(errs, fmt.Errorf("ingestion.mode: must be incremental or recreate, got %q", c.Ingestion.Mode))
+	}
+	if c.Ingestion.Dir == "" {
+		errs = append(errs, errors.New("ingestion.dir: must not be empty"))
+	}
+	if c.Ingestion.Pattern == "" {
+		errs = append(errs, errors.New("ingestion.pattern: must not be empty"))
+	}
+	if c.Ingestion.Splitter != "characters" && c.Ingestion.Splitter != "tokens" {
+		errs = append(errs, fmt.Errorf("ingestion.splitter: must be characters or tokens, got %q", c.Ingestion.Splitter))
+	}
+	if c.Ingestion.Splitter == "tokens" {
+		chunk, overlap, maxTokens := c.tokenSizes()
+		if chunk <= 0 {
+			errs = append(errs, fmt.Errorf("ingestion.chunk_tokens: must be positive, got %d", chunk))
+		} else if overlap < 0 || overlap >= chunk {
+			errs = append(errs, fmt.Errorf("ingestion.chunk_overlap_tokens: must be between 0 and chunk_tokens (%d), got %d", chunk, overlap))
+		}
+		if maxTokens < 0 {
+			errs = append(errs, fmt.Errorf("ingestion.max_input_tokens: must not be negative, got %d", maxTokens))
+		} else if maxTokens > 0 && chunk > maxTokens {
+			errs = append(errs, fmt.Errorf("ingestion.chunk_tokens: %d exceeds the %d tokens accepted by embedding model %q", chunk, maxTokens, c.Models.Embedding))
+		}
+		if c.Ingestion.TokenizerEncoding == "" {
+			errs = append(errs, errors.New("ingestion.tokenizer_encoding: must not be empty"))
+		}
+	}
+	if c.Ingestion.SemanticPercentile < 0 || c.Ingestion.SemanticPercentile > 100 {
+		errs = append(errs, fmt.Errorf("ingestion.semantic_percentile: must be between 0 and 100, got %d", c.Ingestion.SemanticPercentile))
+	}
+	if c.Ingestion.SemanticMinChunk < 0 {
+		errs = append(errs, fmt.Errorf("ingestion.semantic_min_chunk: must not be negative, got %d", c.Ingestion.SemanticMinChunk))
+	}
+	if c.Ingestion.ParentChunkSize < 0 {
+		errs = append(errs, fmt.Errorf("ingestion.parent_chunk_size: must not be negative, got %d", c.Ingestion.ParentChunkSize))
+	} else if c.Ingestion.ParentRetrieval && c.Ingestion.ParentChunkSize > 0 && c.Ingestion.ParentChunkSize <= c.chunkSize() {
+		errs = append(errs, fmt.Errorf("ingestion.parent_chunk_size: must be larger than the chunk size (%d), got %d", c.chunkSize(), c.Ingestion.ParentChunkSize))
+	}
+	if c.Ingestion.MaxFileSizeMB < 0 {
+		errs = append(errs, fmt.Errorf("ingestion.max_file_size_mb: must not be negative, got %d", c.Ingestion.MaxFileSizeMB))
+	}
+	if c.Ingestion.ChunkSize <= 0 {
+		errs = append(errs, fmt.Errorf("ingestion.chunk_size: must be positive, got %d", c.Ingestion.ChunkSize))
+	}
+	if c.Ingestion.ChunkOverlap < 0 {
+		errs = append(errs, fmt.Errorf("ingestion.chunk_overlap: must not be negative, got %d", c.Ingestion.ChunkOverlap))
+	} else if c.Ingestion.ChunkOverlap >= c.Ingestion.ChunkSize {
+		errs = append(errs, fmt.Errorf("ingestion.chunk_overlap: must be smaller than chunk_size (%d), got %d", c.Ingestion.ChunkSize, c.Ingestion.ChunkOverlap))
+	}
+
+	for _, f := range []struct {
+		key   string
+		value int
+	}{
+		{"ingestion.load_workers", c.Ingestion.LoadWorkers},
+		{"ingestion.embed_batch_size", c.Ingestion.EmbedBatchSize},
+		{"ingestion.embed_concurrency", c.Ingestion.EmbedConcurrency},
+		{"ingestion.upsert_batch_size", c.Ingestion.UpsertBatchSize},
+	} {
+		if f.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be positive, got %d", f.key, f.value))
+		}
+	}
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port: must be between 1 and 65535, got %d", c.Server.Port))
+	}
+	if c.Server.UploadDir == "" {
+		errs = append(errs, errors.New("server.upload_dir: must not be empty"))
+	}
+	if c.Server.JobsDir == "" {
+		errs = append(errs, errors.New("server.jobs_dir: must not be empty"))
+	}
+	if c.Server.JobWorkers <= 0 {
+		errs = append(errs, fmt.Errorf("server.job_workers: must be positive, got %d", c.Server.JobWorkers))
+	}
+	if c.Server.JobHistory <= 0 {
+		errs = append(errs, fmt.Errorf("server.job_history: must be positive, got %d", c.Server.JobHistory))
+	}
+
+	if c.Cache.Enabled && c.Cache.Path == "" {
+		errs = append(errs, errors.New("cache.path: must not be empty when the cache is enabled"))
+	}
+	if c.Cache.MaxEntries < 0 {
+		errs = append(errs, fmt.Errorf("cache.max_entries: must not be negative, got %d", c.Cache.MaxEntries))
+	}
+	if c.Cache.QueryTTLMinutes < 0 {
+		errs = append(errs, fmt.Errorf("cache.query_ttl_minutes: must not be negative, got %d", c.Cache.QueryTTLMinutes))
+	}
+
+	return errors.Join(errs...)
+}
+
+// PipelineOptions returns the ingestion pipeline sizing.
+func (c *Config) PipelineOptions() usecase.PipelineOptions {
+	return usecase.PipelineOptions{
+		LoadWorkers:      c.Ingestion.LoadWorkers,
+		EmbedBatchSize:   c.Ingestion.EmbedBatchSize,
+		EmbedConcurrency: c.Ingestion.EmbedConcurrency,
+		UpsertBatchSize:  c.Ingestion.UpsertBatchSize,
+	}
+}
+
+// IngestionOptions returns the use case options derived from the configuration.
+func (c *Config) IngestionOptions() []usecase.IngestionOption {
+	return []usecase.IngestionOption{
+		usecase.WithIngestionMode(usecase.IngestionMode(c.Ingestion.Mode)),
+		usecase.WithPipelineOptions(c.PipelineOptions()),
+		usecase.WithFileSelection(usecase.FileSelection{
+			MaxFileSize:    int64(c.Ingestion.MaxFileSizeMB) << 20,
+			FollowSymlinks: c.Ingestion.FollowSymlinks,
+			IncludeHidden:  c.Ingestion.IncludeHidden,
+		}),
+	}
+}
+
+// TextSplitter returns the splitter selected by ingestion.splitter, cutting
+// chunks at topic changes when ingestion.semantic_chunking is set (embedding
+// sentences with embedder), splitting Markdown and HTML documents by
+// section when ingestion.split_by_headings is set and producing the chunks
+// from parent sections when ingestion.parent_retrieval is set.
+func (c *Config) TextSplitter(embedder usecase.EmbeddingGenerator) (usecase.TextSplitter, error) {
+	child, err := c.childSplitter(embedder)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Ingestion.ParentRetrieval {
+		return child, nil
+	}
+
+	var parent usecase.TextSplitter
+	if c.Ingestion.ParentChunkSize > 0 {
+		// Parents are never embedded, so they are not held to the model's input limit
+		var base splitter.SizedSplitter
+		if c.Ingestion.Splitter == "tokens" {
+			ts, err := splitter.NewTokenSplitter(c.Ingestion.ParentChunkSize, 0, splitter.WithEncoding(c.Ingestion.TokenizerEncoding))
+			if err != nil {
+				return nil, err
+			}
+			base = ts
+		} else {
+			base = splitter.NewRecursiveCharacterSplitter(c.Ingestion.ParentChunkSize, 0)
+		}
+		parent = base
+		if c.Ingestion.SplitByHeadings {
+			parent = splitter.NewMarkdownSplitter(base)
+		}
+	}
+	return splitter.NewParentChildSplitter(parent, child), nil
+}
+
+// childSplitter returns the splitter producing the embedded chunks.
+func (c *Config) childSplitter(embedder usecase.EmbeddingGenerator) (usecase.TextSplitter, error) {
+	var base splitter.SizedSplitter
+	if c.Ingestion.Splitter == "tokens" {
+		chunk, overlap, maxTokens := c.tokenSizes()
+		ts, err := splitter.NewTokenSplitter(chunk, overlap,
+			splitter.WithEncoding(c.Ingestion.TokenizerEncoding),
+			splitter.WithMaxTokens(maxTokens),
+		)
+		if err != nil {
+			return nil, err
+		}
+		base = ts
+	} else {
+		base = splitter.NewRecursiveCharacterSplitter(c.Ingestion.ChunkSize, c.Ingestion.ChunkOverlap)
+	}
+
+	if c.Ingestion.SemanticChunking {
+		opts := []splitter.SemanticOption{
+			splitter.WithBreakpointPercentile(float64(c.Ingestion.SemanticPercentile)),
+			splitter.WithEmbedBatchSize(c.Ingestion.EmbedBatchSize),
+		}
+		if c.Ingestion.SemanticMinChunk > 0 {
+			opts = append(opts, splitter.WithMinChunkSize(c.Ingestion.SemanticMinChunk))
+		}
+		base = splitter.NewSemanticSplitter(base, embedder, opts...)
+	}
+
+	if !c.Ingestion.SplitByHeadings {
+		return base, nil
+	}
+	return splitter.NewMarkdownSplitter(base, splitter.WithBreadcrumbPrefix(c.Ingestion.BreadcrumbPrefix)), nil
+}
+
+// chunkSize returns the chunk size in the unit of the selected splitter.
+func (c *Config) chunkSize() int {
+	if c.Ingestion.Splitter == "tokens" {
+		chunk, _, _ := c.tokenSizes()
+		return chunk
+	}
+	return c.Ingestion.ChunkSize
+}
+
+// tokenSizes resolves the token chunk size, overlap and input limit, taking
+// the values left at 0 from the embedding model's preset.
+func (c *Config) tokenSizes() (chunk, overlap, maxTokens int) {
+	preset, _ := splitter.PresetFor(c.Models.Embedding)
+	chunk, overlap, maxTokens = c.Ingestion.ChunkTokens, c.Ingestion.ChunkOverlapTokens, c.Ingestion.MaxInputTokens
+	if chunk == 0 {
+		chunk, overlap = preset.ChunkSize, preset.ChunkOverlap
+	}
+	if maxTokens == 0 {
+		maxTokens = preset.MaxTokens
+	}
+	return chunk, overlap, maxTokens
+}
+
+// OpenEmbeddingCache opens the embedding cache file.
+func (c *Config) OpenEmbeddingCache() (*cache.EmbeddingCache, error) {
+	return cache.Open(c.Cache.Path, cache.WithMaxEntries(c.Cache.MaxEntries))
+}
+
+// QueryTTL returns how long query embeddings are cached.
+func (c *Config) QueryTTL() time.Duration {
+	return time.Duration(c.Cache.QueryTTLMinutes) * time.Minute
+}
+
+// CachedEmbedder wraps embedder with the embedding cache when cache.enabled
+// is set, returning the cache to close on exit (nil when disabled). When
+// the cache cannot be opened, embedder is returned with the error.
+func (c *Config) CachedEmbedder(embedder usecase.EmbeddingGenerator) (usecase.EmbeddingGenerator, *cache.EmbeddingCache, error) {
+	if !c.Cache.Enabled {
+		return embedder, nil, nil
+	}
+	ec, err := c.OpenEmbeddingCache()
+	if err != nil {
+		return embedder, nil, err
+	}
+	return cache.NewCachedEmbedder(embedder, ec, c.EmbeddingModelID(), cache.WithQueryTTL(c.QueryTTL())), ec, nil
+}
+
+// EmbeddingModelID identifies the embedding model in the cache keys and the
+// collection metadata. It includes the backend, as the same model name may
+// produce different vectors on another backend.
+func (c *Config) EmbeddingModelID() string {
+	return c.Models.EmbeddingBackend + ":" + c.Models.Embedding
+}
+
+// Embedder returns the embedding generator of models.embedding_backend.
+func (c *Config) Embedder() (usecase.EmbeddingGenerator, error) {
+	switch c.Models.EmbeddingBackend {
+	case "openai":
+		return llm.NewOpenAIEmbedder(c.OpenAI.BaseURL, c.Models.Embedding, llm.WithAPIKey(c.OpenAI.APIKey))
+	case "hash":
+		return llm.NewHashEmbedder(c.Qdrant.VectorSize), nil
+	default:
+		return llm.NewOllamaEmbedder(c.Models.Embedding)
+	}
+}
+
+// GenerationLLM returns the LLM of models.generation_backend, handling the
+// reasoning of thinking models as set by generation.reasoning.
+func (c *Config) GenerationLLM() (usecase.LLM, error) {
+	var model usecase.LLM
+	var err error
+	switch c.Models.GenerationBackend {
+	case "openai":
+		model, err = llm.NewOpenAILLM(c.OpenAI.BaseURL, c.Models.Generation, llm.WithAPIKey(c.OpenAI.APIKey))
+	case "fake":
+		model = llm.NewFakeLLM(
+			llm.WithResponses(c.Fake.Responses...),
+			llm.WithStreamChunks(c.Fake.ChunkSize, time.Duration(c.Fake.ChunkDelayMS)*time.Millisecond),
+		)
+	default:
+		model, err = llm.NewOllamaLLM(c.Models.Generation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return llm.NewReasoningLLM(model, usecase.ReasoningMode(c.Generation.Reasoning)), nil
+}
+
+// CallOptions returns the default options of the generation calls.
+func (c *Config) CallOptions() usecase.CallOptions {
+	g := c.Generation
+	return usecase.CallOptions{
+		Temperature: g.Temperature,
+		TopP:        g.TopP,
+		TopK:        g.TopK,
+		NumCtx:      g.NumCtx,
+		MaxTokens:   g.MaxTokens,
+		Stop:        g.Stop,
+		Seed:        g.Seed,
+		KeepAlive:   g.KeepAlive,
+		Reasoning:   usecase.ReasoningMode(g.Reasoning),
+	}
+}
+
+// PayloadIndexes returns the payload indexes of qdrant.payload_indexes.
+func (c *Config) PayloadIndexes() ([]vectorstore.PayloadIndex, error) {
+	indexes := make([]vectorstore.PayloadIndex, 0, len(c.Qdrant.PayloadIndexes))
+	for _, s := range c.Qdrant.PayloadIndexes {
+		index, err := vectorstore.ParsePayloadIndex(s)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// OpenVectorStore returns the vector store of vector_store.backend, whose
+// GetRelevantDocuments searches qdrant.collection. Collections are created
+// with the payload indexes of qdrant.payload_indexes and, with hybrid
+// search, sparse vectors. opts are applied after those of the configuration.
+func (c *Config) OpenVectorStore(embedder usecase.EmbeddingGenerator, opts ...vectorstore.Option) (usecase.VectorStore, error) {
+	indexes, err := c.PayloadIndexes()
+	if err != nil {
+		return nil, fmt.Errorf("invalid qdrant.payload_indexes: %w", err)
+	}
+	opts = append([]vectorstore.Option{
+		vectorstore.WithCollectionName(c.Qdrant.Collection),
+		vectorstore.WithEmbeddingModel(c.EmbeddingModelID()),
+		vectorstore.WithPayloadIndexes(indexes...),
+		vectorstore.WithSparseVectors(c.Retrieval.Hybrid),
+	}, opts...)
+	if c.VectorStore.Backend == "local" {
+		return vectorstore.NewLocalVectorStore(c.VectorStore.Path, embedder, opts...)
+	}
+	return vectorstore.NewQdrantVectorStore(c.Qdrant.URL, embedder, opts...)
+}
+
+// OpenRetriever returns the retriever of vector_store.backend, searching
+// qdrant.collection by default. With the local backend it shares the
+// collections of OpenVectorStore.
+func (c *Config) OpenRetriever(embedder usecase.EmbeddingGenerator) (vectorstore.CollectionRetriever, error) {
+	opts := []vectorstore.Option{
+		vectorstore.WithCollectionName(c.Qdrant.Collection),
+		vectorstore.WithEmbeddingModel(c.EmbeddingModelID()),
+	}
+	if c.VectorStore.Backend == "local" {
+		return vectorstore.NewLocalVectorStore(c.VectorStore.Path, embedder, opts...)
+	}
+	return vectorstore.NewQdrantVectorStore(c.Qdrant.URL, embedder, opts...)
+}
+
+// SearchFilter returns the filter of retrieval.filter, nil without conditions.
+func (c *Config) SearchFilter() (*usecase.Filter, error) {
+	return usecase.ParseFilter(c.Retrieval.Filter...)
+}
+
+// HybridOptions returns the fusion settings of hybrid search.
+func (c *Config) HybridOptions() usecase.HybridOptions {
+	return usecase.HybridOptions{
+		Fusion:      usecase.FusionMethod(c.Retrieval.Fusion),
+		RRFK:        c.Retrieval.RRFK,
+		DenseWeight: c.Retrieval.DenseWeight,
+	}
+}
+
+// SparseEncoder returns the BM25 encoder of hybrid search, nil when
+// retrieval.hybrid is not set. The same encoder must be shared by the
+// ingestions and queries of a process, as it holds the vocabulary.
+func (c *Config) SparseEncoder() usecase.SparseEncoder {
+	if !c.Retrieval.Hybrid {
+		return nil
+	}
+	return sparse.NewBM25Encoder(c.Retrieval.VocabularyPath)
+}
+
+// DocumentLoader returns the loader registry used for ingestion.
+func (c *Config) DocumentLoader() *loader.Registry {
+	var recordOpts []loader.RecordOption
+	if len(c.Ingestion.RecordContentFields) > 0 {
+		recordOpts = append(recordOpts, loader.WithContentFields(c.Ingestion.RecordContentFields...))
+	}
+	if c.Ingestion.RecordIDField != "" {
+		recordOpts = append(recordOpts, loader.WithIDField(c.Ingestion.RecordIDField))
+	}
+	return loader.NewDefaultRegistry(recordOpts...)
+}
+
+// validateURL ensures raw is an absolute http(s) URL.
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid URL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid URL %q: missing host", raw)
+	}
+	return nil
+}
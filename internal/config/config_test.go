@@ -0,0 +1,84 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("the default configuration is invalid: %v", err)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := Default()
+	cfg.Qdrant.URL = "localhost:6333"
+	cfg.Qdrant.Collection = ""
+	cfg.Ingestion.ChunkOverlap = cfg.Ingestion.ChunkSize
+	cfg.Server.Port = 70000
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted an invalid configuration")
+	}
+	for _, want := range []string{
+		"qdrant.url",
+		"qdrant.collection: must not be empty",
+		"ingestion.chunk_overlap: must be smaller than chunk_size",
+		"server.port: must be between 1 and 65535",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error lacks %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateAfterLoad(t *testing.T) {
+	t.Setenv(EnvConfigFile, "")
+	t.Setenv("RAG_SERVER_PORT", "0")
+
+	cfg, _, err := Load("test", []string{"-qdrant-url", "ftp://example.com"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted the loaded configuration")
+	}
+	for _, want := range []string{"qdrant.url", "scheme must be http or https", "server.port"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error lacks %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateGenerationOptions(t *testing.T) {
+	temperature := 3.0
+	tests := []struct {
+		name  string
+		setup func(*Config)
+		want  []string
+	}{
+		{"one invalid option", func(c *Config) { c.Generation.TopP = 2 }, []string{"generation.top_p: must be between 0 and 1"}},
+		{"several invalid options", func(c *Config) {
+			c.Generation.TopP = 2
+			c.Generation.Temperature = &temperature
+		}, []string{"generation.top_p", "generation.temperature: must be between 0 and 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.setup(cfg)
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatal("Validate accepted invalid generation options")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate error lacks %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile names the environment variable holding the default
+// configuration file path.
+const EnvConfigFile = "RAG_CONFIG"
+
+// envPrefix is prepended to every setting's environment variable name.
+const envPrefix = "RAG_"
+
+// setting binds one configuration key to its flag and environment variable.
+type setting struct {
+	key   string // dotted key, e.g. "qdrant.url"
+	usage string
+	bind  func(c *Config) flag.Value
+}
+
+// flagName returns the command-line flag for the setting ("qdrant-url").
+func (s setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// envName returns the environment variable for the setting ("RAG_QDRANT_URL").
+func (s setting) envName() string {
+	return envPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(s.key))
+}
+
+var settings = []setting{
+	{"qdrant.url", "Qdrant server URL", func(c *Config) flag.Value { return (*stringValue)(&c.Qdrant.URL) }},
+	{"qdrant.collection", "default Qdrant collection", func(c *Config) flag.Value { return (*stringValue)(&c.Qdrant.Collection) }},
+	{"qdrant.vector_size", "embedding vector dimension (0 detects it from the embedding model)", func(c *Config) flag.Value { return (*intValue)(&c.Qdrant.VectorSize) }},
+	{"qdrant.payload_indexes", "comma-separated payload indexes created with the collections, as field:type (keyword, integer, float, bool, datetime or text)", func(c *Config) flag.Value { return (*listValue)(&c.Qdrant.PayloadIndexes) }},
+	{"vector_store.backend", "where vectors are stored: qdrant or local (embedded, in vector_store.path)", func(c *Config) flag.Value { return (*stringValue)(&c.VectorStore.Backend) }},
+	{"vector_store.path", "file of the local vector store", func(c *Config) flag.Value { return (*stringValue)(&c.VectorStore.Path) }},
+	{"models.embedding", "model used for embeddings", func(c *Config) flag.Value { return (*stringValue)(&c.Models.Embedding) }},
+	{"models.generation", "model used for answer generation", func(c *Config) flag.Value { return (*stringValue)(&c.Models.Generation) }},
+	{"models.embedding_backend", "backend serving the embedding model: ollama, openai or hash (offline)", func(c *Config) flag.Value { return (*stringValue)(&c.Models.EmbeddingBackend) }},
+	{"models.generation_backend", "backend serving the generation model: ollama, openai or fake (offline)", func(c *Config) flag.Value { return (*stringValue)(&c.Models.GenerationBackend) }},
+	{"openai.base_url", "OpenAI-compatible API root, including the version (e.g. http://localhost:8080/v1)", func(c *Config) flag.Value { return (*stringValue)(&c.OpenAI.BaseURL) }},
+	{"openai.api_key", "API key of the OpenAI-compatible server", func(c *Config) flag.Value { return (*stringValue)(&c.OpenAI.APIKey) }},
+	{"fake.responses", "comma-separated answers of the fake backend (default: echo the question)", func(c *Config) flag.Value { return (*listValue)(&c.Fake.Responses) }},
+	{"fake.chunk_size", "characters per chunk streamed by the fake backend", func(c *Config) flag.Value { return (*intValue)(&c.Fake.ChunkSize) }},
+	{"fake.chunk_delay_ms", "milliseconds between chunks streamed by the fake backend", func(c *Config) flag.Value { return (*intValue)(&c.Fake.ChunkDelayMS) }},
+	{"generation.temperature", "sampling temperature, 0-2 (default: model's)", func(c *Config) flag.Value { return &optionalFloatValue{&c.Generation.Temperature} }},
+	{"generation.top_p", "nucleus sampling probability, 0-1 (0: model's default)", func(c *Config) flag.Value { return (*floatValue)(&c.Generation.TopP) }},
+	{"generation.top_k", "sample among the k most likely tokens (0: model's default)", func(c *Config) flag.Value { return (*intValue)(&c.Generation.TopK) }},
+	{"generation.num_ctx", "context window in tokens, Ollama only (0: model's default)", func(c *Config) flag.Value { return (*intValue)(&c.Generation.NumCtx) }},
+	{"generation.max_tokens", "largest number of tokens generated (0: no limit)", func(c *Config) flag.Value { return (*intValue)(&c.Generation.MaxTokens) }},
+	{"generation.stop", "comma-separated sequences ending the answer", func(c *Config) flag.Value { return (*listValue)(&c.Generation.Stop) }},
+	{"generation.seed", "sampling seed, for reproducible answers (0: random)", func(c *Config) flag.Value { return (*intValue)(&c.Generation.Seed) }},
+	{"generation.keep_alive", "how long Ollama keeps the model loaded (e.g. 10m, -1: forever)", func(c *Config) flag.Value { return (*stringValue)(&c.Generation.KeepAlive) }},
+	{"generation.reasoning", "<think> reasoning of thinking models: inline, hide, separate (shown apart) or log", func(c *Config) flag.Value { return (*stringValue)(&c.Generation.Reasoning) }},
+	{"retrieval.filter", "conditions restricting the documents of every query, e.g. \"source=data/pdfs/a.pdf,page>=3\"", func(c *Config) flag.Value { return (*filterValue)(&c.Retrieval.Filter) }},
+	{"retrieval.hybrid", "also search BM25 sparse vectors of the chunks and fuse the results (collections must be recreated)", func(c *Config) flag.Value { return (*boolValue)(&c.Retrieval.Hybrid) }},
+	{"retrieval.fusion", "fusion of hybrid search results: rrf (reciprocal rank) or weighted (normalized scores)", func(c *Config) flag.Value { return (*stringValue)(&c.Retrieval.Fusion) }},
+	{"retrieval.rrf_k", "rank constant of reciprocal rank fusion", func(c *Config) flag.Value { return (*intValue)(&c.Retrieval.RRFK) }},
+	{"retrieval.dense_weight", "weight (0-1) of the embedding scores with weighted fusion", func(c *Config) flag.Value { return (*floatValue)(&c.Retrieval.DenseWeight) }},
+	{"retrieval.vocabulary_path", "file holding the BM25 vocabulary of hybrid search", func(c *Config) flag.Value { return (*stringValue)(&c.Retrieval.VocabularyPath) }},
+	{"ingestion.mode", "ingestion mode: incremental or recreate", func(c *Config) flag.Value { return (*stringValue)(&c.Ingestion.Mode) }},
+	{"ingestion.dir", "directory containing the documents to ingest", func(c *Config) flag.Value { return (*stringValue)(&c.Ingestion.Dir) }},
+	{"ingestion.pattern", "comma-separated globs selecting the documents to ingest (\"**\" matches subdirectories, \"!\" excludes)", func(c *Config) flag.Value { return (*stringValue)(&c.Ingestion.Pattern) }},
+	{"ingestion.max_file_size_mb", "skip files larger than this many MB (0: no limit)", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.MaxFileSizeMB) }},
+	{"ingestion.follow_symlinks", "follow symbolic links while walking the directory", func(c *Config) flag.Value { return (*boolValue)(&c.Ingestion.FollowSymlinks) }},
+	{"ingestion.include_hidden", "ingest hidden files and directories", func(c *Config) flag.Value { return (*boolValue)(&c.Ingestion.IncludeHidden) }},
+	{"ingestion.chunk_size", "text chunk size", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.ChunkSize) }},
+	{"ingestion.chunk_overlap", "overlap between consecutive chunks", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.ChunkOverlap) }},
+	{"ingestion.splitter", "text splitter: characters or tokens", func(c *Config) flag.Value { return (*stringValue)(&c.Ingestion.Splitter) }},
+	{"ingestion.chunk_tokens", "tokens per chunk with the tokens splitter (0: embedding model preset)", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.ChunkTokens) }},
+	{"ingestion.chunk_overlap_tokens", "tokens shared by consecutive chunks with the tokens splitter", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.ChunkOverlapTokens) }},
+	{"ingestion.max_input_tokens", "largest chunk accepted by the embedding model, in tokens (0: model preset)", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.MaxInputTokens) }},
+	{"ingestion.tokenizer_encoding", "tiktoken encoding used to count tokens", func(c *Config) flag.Value { return (*stringValue)(&c.Ingestion.TokenizerEncoding) }},
+	{"ingestion.split_by_headings", "split Markdown and HTML documents by heading hierarchy", func(c *Config) flag.Value { return (*boolValue)(&c.Ingestion.SplitByHeadings) }},
+	{"ingestion.breadcrumb_prefix", "prepend the heading breadcrumb to the embedded text of each chunk", func(c *Config) flag.Value { return (*boolValue)(&c.Ingestion.BreadcrumbPrefix) }},
+	{"ingestion.semantic_chunking", "cut chunks where the topic changes, using sentence embeddings", func(c *Config) flag.Value { return (*boolValue)(&c.Ingestion.SemanticChunking) }},
+	{"ingestion.semantic_percentile", "sentence similarity percentile (0-100) below which semantic chunks are cut", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.SemanticPercentile) }},
+	{"ingestion.semantic_min_chunk", "size below which semantic chunks are not cut (0: a quarter of the chunk size)", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.SemanticMinChunk) }},
+	{"ingestion.parent_retrieval", "embed small chunks but answer from their parent sections", func(c *Config) flag.Value { return (*boolValue)(&c.Ingestion.ParentRetrieval) }},
+	{"ingestion.parent_chunk_size", "parent section size, measured like the chunks (0: loaded pages, records or files)", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.ParentChunkSize) }},
+	{"ingestion.record_content_fields", "comma-separated CSV/JSONL fields used as document text (default: all)", func(c *Config) flag.Value { return (*listValue)(&c.Ingestion.RecordContentFields) }},
+	{"ingestion.record_id_field", "CSV/JSONL field identifying a record", func(c *Config) flag.Value { return (*stringValue)(&c.Ingestion.RecordIDField) }},
+	{"ingestion.load_workers", "files loaded and split concurrently", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.LoadWorkers) }},
+	{"ingestion.embed_batch_size", "chunks sent per embedding request", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.EmbedBatchSize) }},
+	{"ingestion.embed_concurrency", "concurrent embedding requests", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.EmbedConcurrency) }},
+	{"ingestion.upsert_batch_size", "points sent per vector store upsert", func(c *Config) flag.Value { return (*intValue)(&c.Ingestion.UpsertBatchSize) }},
+	{"server.port", "web server port", func(c *Config) flag.Value { return (*intValue)(&c.Server.Port) }},
+	{"server.upload_dir", "directory where uploaded files are stored", func(c *Config) flag.Value { return (*stringValue)(&c.Server.UploadDir) }},
+	{"server.jobs_dir", "directory where ingestion job records are stored", func(c *Config) flag.Value { return (*stringValue)(&c.Server.JobsDir) }},
+	{"server.job_workers", "number of ingestion jobs run concurrently", func(c *Config) flag.Value { return (*intValue)(&c.Server.JobWorkers) }},
+	{"server.job_history", "number of finished ingestion jobs kept", func(c *Config) flag.Value { return (*intValue)(&c.Server.JobHistory) }},
+	{"cache.enabled", "cache embeddings on disk, keyed by model and text", func(c *Config) flag.Value { return (*boolValue)(&c.Cache.Enabled) }},
+	{"cache.path", "embedding cache file", func(c *Config) flag.Value { return (*stringValue)(&c.Cache.Path) }},
+	{"cache.max_entries", "embeddings kept in the cache, oldest evicted first (0: no limit)", func(c *Config) flag.Value { return (*intValue)(&c.Cache.MaxEntries) }},
+	{"cache.query_ttl_minutes", "minutes query embeddings are reused (0: queries not cached)", func(c *Config) flag.Value { return (*intValue)(&c.Cache.QueryTTLMinutes) }},
+}
+
+// flagAliases maps short flag names to the settings they set.
+var flagAliases = map[string]string{
+	"filter": "retrieval.filter",
+	"hybrid": "retrieval.hybrid",
+}
+
+// Load resolves the configuration for the named program from args.
+//
+// Flags must precede positional arguments; the remaining positional
+// arguments are returned. The file named by -config (or RAG_CONFIG) is
+// read first, then RAG_* environment variables, then the flags that were
+// explicitly set. The result is not validated.
+func Load(name string, args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv(EnvConfigFile), "path to a YAML or TOML configuration file")
+
+	// Flags are bound to a scratch copy so that -help shows the defaults and
+	// only explicitly set flags override the file and environment layers.
+	scratch := Default()
+	byFlag := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		fs.Var(s.bind(scratch), s.flagName(), fmt.Sprintf("%s (env %s)", s.usage, s.envName()))
+		byFlag[s.flagName()] = s
+	}
+	for alias, key := range flagAliases {
+		for _, s := range settings {
+			if s.key == key {
+				fs.Var(s.bind(scratch), alias, fmt.Sprintf("shorthand for -%s", s.flagName()))
+				byFlag[alias] = s
+			}
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := Default()
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.envName()); ok {
+			if err := s.bind(cfg).Set(v); err != nil {
+				return nil, nil, fmt.Errorf("invalid value %q for %s: %w", v, s.envName(), err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		s, ok := byFlag[f.Name]
+		if !ok || flagErr != nil {
+			return
+		}
+		if err := s.bind(cfg).Set(f.Value.String()); err != nil {
+			flagErr = fmt.Errorf("invalid value %q for -%s: %w", f.Value.String(), f.Name, err)
+		}
+	})
+	if flagErr != nil {
+		return nil, nil, flagErr
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// loadFile overlays the values found in a YAML or TOML file. Unknown keys
+// are rejected so that typos do not go unnoticed.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file '%s': %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse YAML config file '%s': %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return fmt.Errorf("failed to parse TOML config file '%s': %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension for '%s' (expected .yaml, .yml or .toml)", path)
+	}
+
+	c.File = path
+	return nil
+}
+
+// --- flag.Value adapters ---
+
+type stringValue string
+
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type floatValue float64
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return err
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+// optionalFloatValue is a float that may be unset (nil), e.g. with an
+// empty value.
+type optionalFloatValue struct {
+	p **float64
+}
+
+func (v *optionalFloatValue) String() string {
+	if v.p == nil || *v.p == nil {
+		return ""
+	}
+	return strconv.FormatFloat(**v.p, 'g', -1, 64)
+}
+
+func (v *optionalFloatValue) Set(s string) error {
+	if s = strings.TrimSpace(s); s == "" {
+		*v.p = nil
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*v.p = &f
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) IsBoolFlag() bool { return true }
+func (v *boolValue) String() string   { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+// filterValue holds filter expressions. A value is kept whole, its
+// comma-separated conditions being split by usecase.ParseFilter.
+type filterValue []string
+
+func (v *filterValue) String() string { return strings.Join(*v, ",") }
+func (v *filterValue) Set(s string) error {
+	*v = nil
+	if s = strings.TrimSpace(s); s != "" {
+		*v = []string{s}
+	}
+	return nil
+}
+
+// listValue is a comma-separated list of strings.
+type listValue []string
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+func (v *listValue) Set(s string) error {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*v = items
+	return nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfigFile writes a configuration file named name in a temporary
+// directory and returns its path.
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv(EnvConfigFile, "")
+	cfg, args, err := Load("test", []string{"query", "question"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("Load without file, environment or flags = %+v, want the defaults", cfg)
+	}
+	if !reflect.DeepEqual(args, []string{"query", "question"}) {
+		t.Errorf("positional arguments = %q, want [query question]", args)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+qdrant:
+  collection: from_file
+  url: http://file:6333
+models:
+  embedding: file-model
+server:
+  port: 9000
+`,
+		"config.toml": `
+[qdrant]
+collection = "from_file"
+url = "http://file:6333"
+
+[models]
+embedding = "file-model"
+
+[server]
+port = 9000
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfigFile(t, name, content)
+			t.Setenv(EnvConfigFile, "")
+			t.Setenv("RAG_QDRANT_COLLECTION", "from_env")
+			t.Setenv("RAG_SERVER_PORT", "9100")
+
+			cfg, args, err := Load("test", []string{"-config", path, "-qdrant-collection", "from_flag", "ingest"})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			checks := []struct {
+				key       string
+				got, want interface{}
+			}{
+				{"qdrant.collection (file, env and flag)", cfg.Qdrant.Collection, "from_flag"},
+				{"server.port (file and env)", cfg.Server.Port, 9100},
+				{"qdrant.url (file)", cfg.Qdrant.URL, "http://file:6333"},
+				{"models.embedding (file)", cfg.Models.Embedding, "file-model"},
+				{"models.generation (default)", cfg.Models.Generation, Default().Models.Generation},
+				{"ingestion.chunk_size (default)", cfg.Ingestion.ChunkSize, Default().Ingestion.ChunkSize},
+				{"file", cfg.File, path},
+			}
+			for _, c := range checks {
+				if c.got != c.want {
+					t.Errorf("%s = %v, want %v", c.key, c.got, c.want)
+				}
+			}
+			if !reflect.DeepEqual(args, []string{"ingest"}) {
+				t.Errorf("positional arguments = %q, want [ingest]", args)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileFromEnv(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "qdrant:\n  collection: from_file\n")
+	t.Setenv(EnvConfigFile, path)
+
+	cfg, _, err := Load("test", nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Qdrant.Collection != "from_file" || cfg.File != path {
+		t.Errorf("collection %q from %q, want from_file from %s", cfg.Qdrant.Collection, cfg.File, path)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // Config file name and content, "name:content"
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "unknown key in file",
+			file:    "config.yaml:qdrant:\n  colection: typo\n",
+			wantErr: "colection",
+		},
+		{
+			name:    "unsupported file extension",
+			file:    "config.json:{}",
+			wantErr: "unsupported config file extension",
+		},
+		{
+			name:    "invalid environment value",
+			env:     map[string]string{"RAG_SERVER_PORT": "eighty"},
+			wantErr: "RAG_SERVER_PORT",
+		},
+		{
+			name:    "invalid flag value",
+			args:    []string{"-server-port", "eighty"},
+			wantErr: "server-port",
+		},
+		{
+			name:    "missing config file",
+			args:    []string{"-config", filepath.Join(os.TempDir(), "missing-rag-config.yaml")},
+			wantErr: "failed to read config file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvConfigFile, "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				name, content, _ := strings.Cut(tt.file, ":")
+				args = append([]string{"-config", writeConfigFile(t, name, content)}, args...)
+			}
+
+			_, _, err := Load("test", args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
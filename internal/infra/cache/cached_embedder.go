@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// CachedEmbedder implements the usecase.EmbeddingGenerator interface by
+// serving embeddings from an EmbeddingCache and computing only the missing
+// ones with the wrapped embedder. Cache failures are logged and the
+// embeddings computed as if the cache were empty.
+type CachedEmbedder struct {
+	next     usecase.EmbeddingGenerator
+	cache    *EmbeddingCache
+	model    string
+	queryTTL time.Duration
+}
+
+// EmbedderOption configures a CachedEmbedder.
+type EmbedderOption func(*CachedEmbedder)
+
+// WithQueryTTL caches query embeddings for d. Queries are not cached by
+// default, or when d is 0.
+func WithQueryTTL(d time.Duration) EmbedderOption {
+	return func(e *CachedEmbedder) {
+		e.queryTTL = d
+	}
+}
+
+// NewCachedEmbedder wraps next, whose embeddings are produced by model;
+// the model is part of the cache key.
+func NewCachedEmbedder(next usecase.EmbeddingGenerator, cache *EmbeddingCache, model string, opts ...EmbedderOption) *CachedEmbedder {
+	e := &CachedEmbedder{next: next, cache: cache, model: model}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// EmbedDocuments returns the embeddings of texts, embedding only the texts
+// missing from the cache, each once.
+func (e *CachedEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([][sha256.Size]byte, len(texts))
+	for i, text := range texts {
+		keys[i] = Key(e.model, text)
+	}
+	vectors, err := e.cache.GetMany(KindDocument, keys, 0)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		vectors = make([][]float32, len(texts))
+	}
+
+	// Texts missing from the cache, deduplicated by key
+	var missTexts []string
+	var missKeys [][sha256.Size]byte
+	missing := make(map[[sha256.Size]byte][]int)
+	for i, v := range vectors {
+		if v != nil {
+			continue
+		}
+		if _, ok := missing[keys[i]]; !ok {
+			missTexts = append(missTexts, texts[i])
+			missKeys = append(missKeys, keys[i])
+		}
+		missing[keys[i]] = append(missing[keys[i]], i)
+	}
+	if len(missTexts) == 0 {
+		return vectors, nil
+	}
+
+	computed, err := e.next.EmbedDocuments(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+	if len(computed) != len(missTexts) {
+		return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(computed), len(missTexts))
+	}
+	for j, key := range missKeys {
+		for _, i := range missing[key] {
+			vectors[i] = computed[j]
+		}
+	}
+	if err := e.cache.PutMany(KindDocument, missKeys, computed); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return vectors, nil
+}
+
+// EmbedQuery returns the embedding of a query, served from the cache when it
+// was computed less than the query TTL ago.
+func (e *CachedEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	if e.queryTTL <= 0 {
+		return e.next.EmbedQuery(ctx, text)
+	}
+
+	keys := [][sha256.Size]byte{Key(e.model, text)}
+	vectors, err := e.cache.GetMany(KindQuery, keys, e.queryTTL)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	} else if vectors[0] != nil {
+		return vectors[0], nil
+	}
+
+	vector, err := e.next.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.cache.PutMany(KindQuery, keys, [][]float32{vector}); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return vector, nil
+}
+
+// Dimension returns the dimension of the wrapped embedder's vectors.
+func (e *CachedEmbedder) Dimension(ctx context.Context) (int, error) {
+	return usecase.EmbeddingDimension(ctx, e.next)
+}
+
+// Ensure CachedEmbedder implements the interfaces
+var _ usecase.EmbeddingGenerator = (*CachedEmbedder)(nil)
+var _ usecase.DimensionReporter = (*CachedEmbedder)(nil)
@@ -0,0 +1,407 @@
+// Package cache persists embeddings on disk so that re-ingesting unchanged
+// text does not recompute them.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Kind separates the embeddings of ingested documents from those of queries,
+// which expire.
+type Kind byte
+
+const (
+	KindDocument Kind = 'd'
+	KindQuery    Kind = 'q'
+)
+
+// Buckets of the cache file. Entries are keyed by the hash of the model and
+// normalized text; "age" indexes them by creation time for eviction.
+var (
+	bucketDocuments = []byte("documents")
+	bucketQueries   = []byte("queries")
+	bucketAge       = []byte("age")
+	bucketMeta      = []byte("meta")
+
+	metaHits    = []byte("hits")
+	metaMisses  = []byte("misses")
+	metaEntries = []byte("entries")
+)
+
+func (k Kind) bucket() []byte {
+	if k == KindQuery {
+		return bucketQueries
+	}
+	return bucketDocuments
+}
+
+// EmbeddingCache stores embeddings in a bbolt file. Only one process can
+// open the file at a time.
+type EmbeddingCache struct {
+	db         *bolt.DB
+	path       string
+	maxEntries int
+
+	// Hits and misses not stored yet, added to the stored totals on every
+	// write and on Close
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// Option configures an EmbeddingCache.
+type Option func(*EmbeddingCache)
+
+// WithMaxEntries limits the number of cached embeddings; the oldest ones are
+// evicted first. 0 disables the limit.
+func WithMaxEntries(n int) Option {
+	return func(c *EmbeddingCache) {
+		c.maxEntries = n
+	}
+}
+
+// Stats describes the content and effectiveness of the cache.
+type Stats struct {
+	Path      string
+	FileSize  int64
+	Documents int
+	Queries   int
+	Hits      uint64
+	Misses    uint64
+}
+
+// HitRate returns the fraction of lookups served from the cache.
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Open opens or creates the cache file at path, creating its directory.
+func Open(path string, opts ...Option) (*EmbeddingCache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create embedding cache directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("embedding cache %s is in use by another process", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedding cache %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketDocuments, bucketQueries, bucketAge, bucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize embedding cache %s: %w", path, err)
+	}
+
+	c := &EmbeddingCache{db: db, path: path}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Close stores the pending statistics and closes the file.
+func (c *EmbeddingCache) Close() error {
+	err := c.db.Update(c.flushStats)
+	if cerr := c.db.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close embedding cache %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// Key identifies the embedding of text by model. Texts differing only in
+// whitespace share a key.
+func Key(model, text string) [sha256.Size]byte {
+	normalized := strings.Join(strings.Fields(text), " ")
+	return sha256.Sum256([]byte(model + "\x00" + normalized))
+}
+
+// GetMany returns the cached embeddings of keys, nil for the missing ones.
+// Entries older than maxAge are treated as missing; 0 means no expiry.
+func (c *EmbeddingCache) GetMany(kind Kind, keys [][sha256.Size]byte, maxAge time.Duration) ([][]float32, error) {
+	vectors := make([][]float32, len(keys))
+	var oldest int64
+	if maxAge > 0 {
+		oldest = time.Now().Add(-maxAge).UnixNano()
+	}
+
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(kind.bucket())
+		for i, key := range keys {
+			value := b.Get(key[:])
+			if value == nil {
+				continue
+			}
+			created, vector, err := decodeEntry(value)
+			if err != nil {
+				return err
+			}
+			if created < oldest {
+				continue
+			}
+			vectors[i] = vector
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+
+	var hits uint64
+	for _, v := range vectors {
+		if v != nil {
+			hits++
+		}
+	}
+	c.hits.Add(hits)
+	c.misses.Add(uint64(len(keys)) - hits)
+	return vectors, nil
+}
+
+// PutMany stores embeddings, evicting the oldest entries beyond the size limit.
+func (c *EmbeddingCache) PutMany(kind Kind, keys [][sha256.Size]byte, vectors [][]float32) error {
+	if len(keys) != len(vectors) {
+		return fmt.Errorf("number of keys (%d) does not match number of embeddings (%d)", len(keys), len(vectors))
+	}
+	now := time.Now().UnixNano()
+
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b, age := tx.Bucket(kind.bucket()), tx.Bucket(bucketAge)
+		var added uint64
+		for i, key := range keys {
+			if old := b.Get(key[:]); old != nil {
+				created, _, err := decodeEntry(old)
+				if err != nil {
+					return err
+				}
+				if err := age.Delete(ageKey(created, kind, key[:])); err != nil {
+					return err
+				}
+			} else {
+				added++
+			}
+			if err := b.Put(key[:], encodeEntry(now, vectors[i])); err != nil {
+				return err
+			}
+			if err := age.Put(ageKey(now, kind, key[:]), nil); err != nil {
+				return err
+			}
+		}
+		if err := addCounter(tx.Bucket(bucketMeta), metaEntries, added); err != nil {
+			return err
+		}
+		if c.maxEntries > 0 {
+			if _, err := evictOldest(tx, c.maxEntries); err != nil {
+				return err
+			}
+		}
+		return c.flushStats(tx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+	return nil
+}
+
+// Prune removes the queries older than queryTTL (all of them when queryTTL
+// is 0) and the oldest entries beyond the size limit. It returns the number
+// of entries removed.
+func (c *EmbeddingCache) Prune(queryTTL time.Duration) (int, error) {
+	removed := 0
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		oldest := time.Now().Add(-queryTTL).UnixNano()
+		if queryTTL <= 0 {
+			oldest = math.MaxInt64
+		}
+
+		var expired [][]byte
+		cur := tx.Bucket(bucketAge).Cursor()
+		for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+			created, kind, _ := parseAgeKey(k)
+			if created >= oldest {
+				break
+			}
+			if kind == KindQuery {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range expired {
+			if err := deleteByAgeKey(tx, k); err != nil {
+				return err
+			}
+		}
+		removed = len(expired)
+
+		if c.maxEntries > 0 {
+			n, err := evictOldest(tx, c.maxEntries)
+			removed += n
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to prune embedding cache: %w", err)
+	}
+	return removed, nil
+}
+
+// Clear removes every entry and resets the statistics.
+func (c *EmbeddingCache) Clear() error {
+	c.hits.Store(0)
+	c.misses.Store(0)
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketDocuments, bucketQueries, bucketAge, bucketMeta} {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear embedding cache: %w", err)
+	}
+	return nil
+}
+
+// Stats returns the number of entries and the hits and misses recorded so far.
+func (c *EmbeddingCache) Stats() (Stats, error) {
+	stats := Stats{Path: c.path}
+	err := c.db.View(func(tx *bolt.Tx) error {
+		stats.FileSize = tx.Size()
+		stats.Documents = tx.Bucket(bucketDocuments).Stats().KeyN
+		stats.Queries = tx.Bucket(bucketQueries).Stats().KeyN
+		meta := tx.Bucket(bucketMeta)
+		stats.Hits = counter(meta, metaHits) + c.hits.Load()
+		stats.Misses = counter(meta, metaMisses) + c.misses.Load()
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to read embedding cache stats: %w", err)
+	}
+	return stats, nil
+}
+
+// flushStats adds the pending hits and misses to the stored totals. They are
+// lost if the transaction fails.
+func (c *EmbeddingCache) flushStats(tx *bolt.Tx) error {
+	meta := tx.Bucket(bucketMeta)
+	if err := addCounter(meta, metaHits, c.hits.Swap(0)); err != nil {
+		return err
+	}
+	return addCounter(meta, metaMisses, c.misses.Swap(0))
+}
+
+// evictOldest deletes the oldest entries until at most maxEntries remain.
+func evictOldest(tx *bolt.Tx, maxEntries int) (int, error) {
+	excess := int(counter(tx.Bucket(bucketMeta), metaEntries)) - maxEntries
+	if excess <= 0 {
+		return 0, nil
+	}
+	victims := make([][]byte, 0, excess)
+	cur := tx.Bucket(bucketAge).Cursor()
+	for k, _ := cur.First(); k != nil && len(victims) < excess; k, _ = cur.Next() {
+		victims = append(victims, append([]byte(nil), k...))
+	}
+	for _, k := range victims {
+		if err := deleteByAgeKey(tx, k); err != nil {
+			return 0, err
+		}
+	}
+	return len(victims), nil
+}
+
+// deleteByAgeKey deletes an entry and its age index key.
+func deleteByAgeKey(tx *bolt.Tx, k []byte) error {
+	_, kind, key := parseAgeKey(k)
+	if err := tx.Bucket(kind.bucket()).Delete(key); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketAge).Delete(k); err != nil {
+		return err
+	}
+	meta := tx.Bucket(bucketMeta)
+	return setCounter(meta, metaEntries, counter(meta, metaEntries)-1)
+}
+
+// ageKey sorts entries by creation time: 8 bytes of big-endian Unix
+// nanoseconds, the kind and the entry key.
+func ageKey(created int64, kind Kind, key []byte) []byte {
+	k := make([]byte, 9+len(key))
+	binary.BigEndian.PutUint64(k, uint64(created))
+	k[8] = byte(kind)
+	copy(k[9:], key)
+	return k
+}
+
+func parseAgeKey(k []byte) (int64, Kind, []byte) {
+	return int64(binary.BigEndian.Uint64(k)), Kind(k[8]), k[9:]
+}
+
+// encodeEntry stores the creation time followed by the little-endian float32
+// components of the vector.
+func encodeEntry(created int64, vector []float32) []byte {
+	value := make([]byte, 8+4*len(vector))
+	binary.BigEndian.PutUint64(value, uint64(created))
+	for i, f := range vector {
+		binary.LittleEndian.PutUint32(value[8+4*i:], math.Float32bits(f))
+	}
+	return value
+}
+
+func decodeEntry(value []byte) (int64, []float32, error) {
+	if len(value) < 8 || (len(value)-8)%4 != 0 {
+		return 0, nil, fmt.Errorf("corrupted embedding cache entry of %d bytes", len(value))
+	}
+	vector := make([]float32, (len(value)-8)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(value[8+4*i:]))
+	}
+	return int64(binary.BigEndian.Uint64(value)), vector, nil
+}
+
+func counter(b *bolt.Bucket, name []byte) uint64 {
+	if v := b.Get(name); len(v) == 8 {
+		return binary.BigEndian.Uint64(v)
+	}
+	return 0
+}
+
+func addCounter(b *bolt.Bucket, name []byte, delta uint64) error {
+	if delta == 0 {
+		return nil
+	}
+	return setCounter(b, name, counter(b, name)+delta)
+}
+
+func setCounter(b *bolt.Bucket, name []byte, value uint64) error {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, value)
+	return b.Put(name, v)
+}
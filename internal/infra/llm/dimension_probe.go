@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// dimensionProbe remembers the dimension of an embedding model, measured
+// with usecase.ProbeDimension the first time it is asked. Failed probes are
+// retried on the next call.
+type dimensionProbe struct {
+	mu        sync.Mutex
+	dimension int
+}
+
+func (p *dimensionProbe) get(ctx context.Context, embedder usecase.EmbeddingGenerator) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dimension > 0 {
+		return p.dimension, nil
+	}
+	dimension, err := usecase.ProbeDimension(ctx, embedder)
+	if err != nil {
+		return 0, err
+	}
+	p.dimension = dimension
+	return dimension, nil
+}
@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// FakeLLM implements the usecase.LLM interface with scripted answers, for
+// tests and offline demos. Calls consume the scripted responses and errors
+// in order; once the script is exhausted the last response is repeated, and
+// without any response the answer echoes the question (the last line of the
+// prompt). Every prompt is recorded with its call options.
+type FakeLLM struct {
+	mu         sync.Mutex
+	responses  []string
+	errs       []error
+	chunkSize  int
+	chunkDelay time.Duration
+	errAfter   int
+	calls      int
+	prompts    []string
+	options    []usecase.CallOptions
+}
+
+// FakeOption configures a FakeLLM.
+type FakeOption func(*FakeLLM)
+
+// WithResponses scripts the answers of successive calls.
+func WithResponses(responses ...string) FakeOption {
+	return func(l *FakeLLM) {
+		l.responses = responses
+	}
+}
+
+// WithErrors scripts the errors of successive calls: a non-nil error makes
+// the call with the same index fail. Calls beyond the list succeed.
+func WithErrors(errs ...error) FakeOption {
+	return func(l *FakeLLM) {
+		l.errs = errs
+	}
+}
+
+// WithStreamChunks sets how many characters each streamed chunk holds
+// (8 by default) and the pause before each chunk (none by default).
+func WithStreamChunks(size int, delay time.Duration) FakeOption {
+	return func(l *FakeLLM) {
+		l.chunkSize = size
+		l.chunkDelay = delay
+	}
+}
+
+// WithStreamErrorAfter makes streaming calls with a scripted error fail
+// after sending n chunks, instead of before the first one.
+func WithStreamErrorAfter(n int) FakeOption {
+	return func(l *FakeLLM) {
+		l.errAfter = n
+	}
+}
+
+// NewFakeLLM creates a fake LLM.
+func NewFakeLLM(opts ...FakeOption) *FakeLLM {
+	l := &FakeLLM{chunkSize: 8}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.chunkSize <= 0 {
+		l.chunkSize = 8
+	}
+	return l
+}
+
+// Prompts returns the prompts received so far.
+func (l *FakeLLM) Prompts() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.prompts...)
+}
+
+// CallOptions returns the options of the calls received so far, in the
+// order of Prompts.
+func (l *FakeLLM) CallOptions() []usecase.CallOptions {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]usecase.CallOptions(nil), l.options...)
+}
+
+// Call returns the next scripted answer.
+func (l *FakeLLM) Call(ctx context.Context, prompt string, options ...usecase.CallOption) (string, error) {
+	answer, err := l.next(prompt, options)
+	if err != nil {
+		return "", fmt.Errorf("fake llm call failed: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("fake llm call failed: %w", err)
+	}
+	return answer, nil
+}
+
+// CallWithStreaming sends the next scripted answer to callbackFn in chunks.
+func (l *FakeLLM) CallWithStreaming(ctx context.Context, prompt string, callbackFn func(chunk string), options ...usecase.CallOption) error {
+	answer, scriptedErr := l.next(prompt, options)
+	runes := []rune(answer)
+	for sent := 0; len(runes) > 0; sent++ {
+		if scriptedErr != nil && sent >= l.errAfter {
+			break
+		}
+		if l.chunkDelay > 0 {
+			select {
+			case <-time.After(l.chunkDelay):
+			case <-ctx.Done():
+				return fmt.Errorf("fake streaming call failed: %w", ctx.Err())
+			}
+		} else if err := ctx.Err(); err != nil {
+			return fmt.Errorf("fake streaming call failed: %w", err)
+		}
+		n := min(l.chunkSize, len(runes))
+		callbackFn(string(runes[:n]))
+		runes = runes[n:]
+	}
+	if scriptedErr != nil {
+		return fmt.Errorf("fake streaming call failed: %w", scriptedErr)
+	}
+	return nil
+}
+
+// next records the call and returns its scripted answer and error.
+func (l *FakeLLM) next(prompt string, options []usecase.CallOption) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	i := l.calls
+	l.calls++
+	l.prompts = append(l.prompts, prompt)
+	l.options = append(l.options, usecase.NewCallOptions(options...))
+
+	var err error
+	if i < len(l.errs) {
+		err = l.errs[i]
+	}
+	switch {
+	case len(l.responses) == 0:
+		return "Fake answer to: " + lastLine(prompt), err
+	case i < len(l.responses):
+		return l.responses[i], err
+	default:
+		return l.responses[len(l.responses)-1], err
+	}
+}
+
+// lastLine returns the last non-empty line of s.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// Ensure FakeLLM implements the interface
+var _ usecase.LLM = (*FakeLLM)(nil)
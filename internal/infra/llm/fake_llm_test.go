@@ -0,0 +1,114 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/llm"
+)
+
+func TestFakeLLMResponses(t *testing.T) {
+	ctx := context.Background()
+	fake := llm.NewFakeLLM(llm.WithResponses("first", "second"))
+
+	var answers []string
+	for _, prompt := range []string{"one", "two", "three"} {
+		answer, err := fake.Call(ctx, prompt)
+		if err != nil {
+			t.Fatalf("Call(%q): %v", prompt, err)
+		}
+		answers = append(answers, answer)
+	}
+	// The last response is repeated once the script is exhausted
+	if want := []string{"first", "second", "second"}; !reflect.DeepEqual(answers, want) {
+		t.Errorf("answers = %q, want %q", answers, want)
+	}
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(fake.Prompts(), want) {
+		t.Errorf("prompts = %q, want %q", fake.Prompts(), want)
+	}
+}
+
+func TestFakeLLMEcho(t *testing.T) {
+	answer, err := llm.NewFakeLLM().Call(context.Background(), "Context:\n\nsome text\n\nAnswer the question: why?\n")
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if answer != "Fake answer to: Answer the question: why?" {
+		t.Errorf("answer = %q, want the last line of the prompt echoed", answer)
+	}
+}
+
+func TestFakeLLMStreamingChunks(t *testing.T) {
+	fake := llm.NewFakeLLM(llm.WithResponses("Olá, mundo!"), llm.WithStreamChunks(4, time.Millisecond))
+	var chunks []string
+	err := fake.CallWithStreaming(context.Background(), "prompt", func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("CallWithStreaming: %v", err)
+	}
+	// Chunks are counted in characters, not bytes
+	if want := []string{"Olá,", " mun", "do!"}; !reflect.DeepEqual(chunks, want) {
+		t.Errorf("chunks = %q, want %q", chunks, want)
+	}
+}
+
+func TestFakeLLMErrors(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+	fake := llm.NewFakeLLM(llm.WithResponses("fine"), llm.WithErrors(nil, errBoom))
+
+	if answer, err := fake.Call(ctx, "one"); err != nil || answer != "fine" {
+		t.Errorf("first call = %q, %v; want the response", answer, err)
+	}
+	if _, err := fake.Call(ctx, "two"); !errors.Is(err, errBoom) {
+		t.Errorf("second call error = %v, want the scripted one", err)
+	}
+	if answer, err := fake.Call(ctx, "three"); err != nil || answer != "fine" {
+		t.Errorf("call beyond the errors = %q, %v; want the response", answer, err)
+	}
+}
+
+func TestFakeLLMStreamErrorAfter(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name  string
+		opts  []llm.FakeOption
+		sent  string
+		fails bool
+	}{
+		{"before the first chunk", nil, "", true},
+		{"after two chunks", []llm.FakeOption{llm.WithStreamErrorAfter(2)}, "abcd", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]llm.FakeOption{llm.WithResponses("abcdefgh"), llm.WithStreamChunks(2, 0), llm.WithErrors(errBoom)}, tt.opts...)
+			var sent strings.Builder
+			err := llm.NewFakeLLM(opts...).CallWithStreaming(context.Background(), "prompt", func(chunk string) {
+				sent.WriteString(chunk)
+			})
+			if !errors.Is(err, errBoom) {
+				t.Errorf("error = %v, want the scripted one", err)
+			}
+			if sent.String() != tt.sent {
+				t.Errorf("sent %q before failing, want %q", sent.String(), tt.sent)
+			}
+		})
+	}
+}
+
+func TestFakeLLMCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fake := llm.NewFakeLLM(llm.WithStreamChunks(1, time.Hour))
+	if err := fake.CallWithStreaming(ctx, "prompt", func(string) {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("streaming error = %v, want context.Canceled", err)
+	}
+	if _, err := fake.Call(ctx, "prompt"); !errors.Is(err, context.Canceled) {
+		t.Errorf("call error = %v, want context.Canceled", err)
+	}
+}
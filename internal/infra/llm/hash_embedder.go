@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// HashEmbedder implements the usecase.EmbeddingGenerator interface without a
+// model: each lowercased word of the text is hashed into one of dimension
+// buckets (feature hashing) and the counts are L2-normalized. Texts sharing
+// words get similar vectors, which is enough to run ingestion and retrieval
+// offline, in tests and in demos; it captures no meaning beyond word overlap.
+//
+// Embeddings are deterministic across runs and platforms. Texts without any
+// word map to a fixed unit vector.
+type HashEmbedder struct {
+	dimension int
+}
+
+// DefaultHashDimension is the dimension of HashEmbedder vectors when none is given.
+const DefaultHashDimension = 768
+
+// NewHashEmbedder creates an embedder producing vectors of the given
+// dimension (DefaultHashDimension when not positive).
+func NewHashEmbedder(dimension int) *HashEmbedder {
+	if dimension <= 0 {
+		dimension = DefaultHashDimension
+	}
+	return &HashEmbedder{dimension: dimension}
+}
+
+func (e *HashEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		embeddings[i] = e.embed(text)
+	}
+	return embeddings, nil
+}
+
+func (e *HashEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.embed(text), nil
+}
+
+func (e *HashEmbedder) embed(text string) []float32 {
+	vector := make([]float32, e.dimension)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		vector[0] = 1
+		return vector
+	}
+
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		// The top bit picks the sign, so that collisions tend to cancel out
+		sign := float32(1)
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vector[sum%uint64(e.dimension)] += sign
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		// Every word cancelled out
+		vector[0] = 1
+		return vector
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vector {
+		vector[i] *= scale
+	}
+	return vector
+}
+
+// Dimension returns the dimension of the vectors.
+func (e *HashEmbedder) Dimension(ctx context.Context) (int, error) {
+	return e.dimension, nil
+}
+
+var _ usecase.EmbeddingGenerator = (*HashEmbedder)(nil)
+var _ usecase.DimensionReporter = (*HashEmbedder)(nil)
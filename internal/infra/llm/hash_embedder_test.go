@@ -0,0 +1,89 @@
+package llm_test
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/llm"
+)
+
+func TestHashEmbedderDeterministic(t *testing.T) {
+	ctx := context.Background()
+	texts := []string{"Cats purr when content.", "Dogs bark at the mailman.", ""}
+
+	first, err := llm.NewHashEmbedder(64).EmbedDocuments(ctx, texts)
+	if err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+	second, err := llm.NewHashEmbedder(64).EmbedDocuments(ctx, texts)
+	if err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Error("two embedders produced different vectors for the same texts")
+	}
+
+	query, err := llm.NewHashEmbedder(64).EmbedQuery(ctx, texts[0])
+	if err != nil {
+		t.Fatalf("EmbedQuery: %v", err)
+	}
+	if !reflect.DeepEqual(query, first[0]) {
+		t.Error("EmbedQuery and EmbedDocuments disagree on the same text")
+	}
+
+	// Case and punctuation are not words
+	same, _ := llm.NewHashEmbedder(64).EmbedQuery(ctx, "CATS purr, when content")
+	if !reflect.DeepEqual(same, first[0]) {
+		t.Error("the vector depends on case or punctuation")
+	}
+}
+
+func TestHashEmbedderDimension(t *testing.T) {
+	ctx := context.Background()
+	for _, dimension := range []int{1, 8, 384, 768} {
+		vectors, err := llm.NewHashEmbedder(dimension).EmbedDocuments(ctx, []string{"some words to embed", "!!!"})
+		if err != nil {
+			t.Fatalf("EmbedDocuments: %v", err)
+		}
+		for _, v := range vectors {
+			if len(v) != dimension {
+				t.Errorf("dimension %d: got a vector of length %d", dimension, len(v))
+			}
+			var norm float64
+			for _, x := range v {
+				norm += float64(x) * float64(x)
+			}
+			if math.Abs(norm-1) > 1e-5 {
+				t.Errorf("dimension %d: vector norm² = %g, want 1", dimension, norm)
+			}
+		}
+	}
+}
+
+func TestHashEmbedderSimilarity(t *testing.T) {
+	ctx := context.Background()
+	e := llm.NewHashEmbedder(256)
+	query, _ := e.EmbedQuery(ctx, "why do cats purr")
+	related, _ := e.EmbedQuery(ctx, "cats purr when content")
+	unrelated, _ := e.EmbedQuery(ctx, "parrots eat seeds")
+
+	dot := func(a, b []float32) (s float32) {
+		for i := range a {
+			s += a[i] * b[i]
+		}
+		return s
+	}
+	if dot(query, related) <= dot(query, unrelated) {
+		t.Errorf("texts sharing words are not closer: %g <= %g", dot(query, related), dot(query, unrelated))
+	}
+}
+
+func TestHashEmbedderCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := llm.NewHashEmbedder(8).EmbedDocuments(ctx, []string{"a"}); err == nil {
+		t.Error("EmbedDocuments succeeded with a cancelled context")
+	}
+}
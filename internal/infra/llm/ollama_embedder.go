@@ -11,6 +11,7 @@ import (
 
 type OllamaEmbedder struct {
 	embedder embeddings.Embedder
+	probe    dimensionProbe
 }
 
 func NewOllamaEmbedder(modelName string) (*OllamaEmbedder, error) {
@@ -41,4 +42,15 @@ func (e *OllamaEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32
 	return embedding, nil
 }
 
+// Dimension returns the dimension of the model's vectors, embedding a probe
+// text on the first call.
+func (e *OllamaEmbedder) Dimension(ctx context.Context) (int, error) {
+	dimension, err := e.probe.get(ctx, e)
+	if err != nil {
+		return 0, fmt.Errorf("ollama embedder: %w", err)
+	}
+	return dimension, nil
+}
+
 var _ usecase.EmbeddingGenerator = (*OllamaEmbedder)(nil)
+var _ usecase.DimensionReporter = (*OllamaEmbedder)(nil)
@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
 	"github.com/tmc/langchaingo/llms"
@@ -10,8 +11,23 @@ import (
 )
 
 // OllamaLLM implements the usecase.LLM interface using Ollama.
+//
+// Call options are mapped onto langchaingo CallOptions, except NumCtx and
+// KeepAlive, which langchaingo only accepts when creating the client: a
+// client is created (and kept) for each combination used. Note that
+// langchaingo always sends a temperature, 0 when none is set.
 type OllamaLLM struct {
-	llm llms.Model // Use the langchaingo llms.Model interface
+	llm       llms.Model // Use the langchaingo llms.Model interface
+	modelName string
+
+	mu      sync.Mutex
+	runners map[ollamaRunner]llms.Model // Clients with runner options, by options
+}
+
+// ollamaRunner holds the call options that are client options in langchaingo.
+type ollamaRunner struct {
+	numCtx    int
+	keepAlive string
 }
 
 // NewOllamaLLM creates a new OllamaLLM.
@@ -21,24 +37,19 @@ func NewOllamaLLM(modelName string) (*OllamaLLM, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ollama client for generation: %w", err)
 	}
-	return &OllamaLLM{llm: llmInstance}, nil // Store the concrete *ollama.LLM which implements llms.Model
+	return &OllamaLLM{llm: llmInstance, modelName: modelName, runners: make(map[ollamaRunner]llms.Model)}, nil // Store the concrete *ollama.LLM which implements llms.Model
 }
 
 // Call generates text based on the prompt.
-// Note: This simplifies the langchaingo Call interface for the use case.
-// If more complex options are needed, the usecase.LLM interface might need adjustment.
-func (l *OllamaLLM) Call(ctx context.Context, prompt string, options ...func(map[string]interface{})) (string, error) {
-	// Convert usecase options to langchaingo options if necessary (simplified here)
-	langchainOpts := []llms.CallOption{}
-	// Example: If options were defined to map to langchain options
-	// for _, opt := range options {
-	// 	 cfg := make(map[string]interface{})
-	// 	 opt(cfg)
-	// 	 // convert cfg to langchainOpts
-	// }
+func (l *OllamaLLM) Call(ctx context.Context, prompt string, options ...usecase.CallOption) (string, error) {
+	opts := usecase.NewCallOptions(options...)
+	model, err := l.model(opts)
+	if err != nil {
+		return "", fmt.Errorf("ollama llm call failed: %w", err)
+	}
 
 	// Use the Call method of the underlying llms.Model
-	completion, err := l.llm.Call(ctx, prompt, langchainOpts...)
+	completion, err := model.Call(ctx, prompt, langchainOptions(opts)...)
 	if err != nil {
 		return "", fmt.Errorf("ollama llm call failed: %w", err)
 	}
@@ -47,10 +58,14 @@ func (l *OllamaLLM) Call(ctx context.Context, prompt string, options ...func(map
 
 // CallWithStreaming implementa o streaming de respostas do LLM.
 // Recebe um callback que é chamado para cada fragmento da resposta.
-func (l *OllamaLLM) CallWithStreaming(ctx context.Context, prompt string, callbackFn func(chunk string), options ...func(map[string]interface{})) error {
-	// Converter opções de configuração se necessário
-	langchainOpts := []llms.CallOption{}
-	// Implementação futura para mapear opções
+func (l *OllamaLLM) CallWithStreaming(ctx context.Context, prompt string, callbackFn func(chunk string), options ...usecase.CallOption) error {
+	// Converter as opções tipadas para opções do langchaingo
+	opts := usecase.NewCallOptions(options...)
+	model, err := l.model(opts)
+	if err != nil {
+		return fmt.Errorf("ollama streaming call failed: %w", err)
+	}
+	langchainOpts := langchainOptions(opts)
 
 	// Add the streaming function callback as a langchaingo option
 	langchainOpts = append(langchainOpts, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
@@ -61,7 +76,7 @@ func (l *OllamaLLM) CallWithStreaming(ctx context.Context, prompt string, callba
 	// Use the Call method of the underlying llms.Model with the streaming option.
 	// The first return value (completion string) is ignored in streaming mode,
 	// as the content is handled by the callback.
-	_, err := l.llm.Call(ctx, prompt, langchainOpts...)
+	_, err = model.Call(ctx, prompt, langchainOpts...)
 	if err != nil {
 		// Note: Errors during the streaming process itself might be returned here,
 		// or potentially need to be handled within the callback depending on the nature
@@ -74,5 +89,56 @@ func (l *OllamaLLM) CallWithStreaming(ctx context.Context, prompt string, callba
 	return nil
 }
 
+// model returns the client for the runner options of opts.
+func (l *OllamaLLM) model(opts usecase.CallOptions) (llms.Model, error) {
+	runner := ollamaRunner{numCtx: opts.NumCtx, keepAlive: opts.KeepAlive}
+	if runner == (ollamaRunner{}) {
+		return l.llm, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if model, ok := l.runners[runner]; ok {
+		return model, nil
+	}
+	clientOpts := []ollama.Option{ollama.WithModel(l.modelName)}
+	if runner.numCtx > 0 {
+		clientOpts = append(clientOpts, ollama.WithRunnerNumCtx(runner.numCtx))
+	}
+	if runner.keepAlive != "" {
+		clientOpts = append(clientOpts, ollama.WithKeepAlive(runner.keepAlive))
+	}
+	model, err := ollama.New(clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ollama client for generation: %w", err)
+	}
+	l.runners[runner] = model
+	return model, nil
+}
+
+// langchainOptions maps the per-call options onto langchaingo CallOptions.
+func langchainOptions(opts usecase.CallOptions) []llms.CallOption {
+	var langchainOpts []llms.CallOption
+	if opts.Temperature != nil {
+		langchainOpts = append(langchainOpts, llms.WithTemperature(*opts.Temperature))
+	}
+	if opts.TopP > 0 {
+		langchainOpts = append(langchainOpts, llms.WithTopP(opts.TopP))
+	}
+	if opts.TopK > 0 {
+		langchainOpts = append(langchainOpts, llms.WithTopK(opts.TopK))
+	}
+	if opts.MaxTokens > 0 {
+		langchainOpts = append(langchainOpts, llms.WithMaxTokens(opts.MaxTokens))
+	}
+	if len(opts.Stop) > 0 {
+		langchainOpts = append(langchainOpts, llms.WithStopWords(opts.Stop))
+	}
+	if opts.Seed != 0 {
+		langchainOpts = append(langchainOpts, llms.WithSeed(opts.Seed))
+	}
+	return langchainOpts
+}
+
 // Ensure OllamaLLM implements the interface
 var _ usecase.LLM = (*OllamaLLM)(nil)
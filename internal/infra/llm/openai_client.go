@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// openAIClient sends requests to a server implementing the OpenAI HTTP API
+// (OpenAI, llama.cpp server, vLLM, LM Studio, LocalAI...).
+type openAIClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// OpenAIOption configures the OpenAI-compatible embedder and LLM.
+type OpenAIOption func(*openAIClient)
+
+// WithAPIKey sets the key sent as a bearer token. Local servers usually do
+// not require one.
+func WithAPIKey(key string) OpenAIOption {
+	return func(c *openAIClient) {
+		c.apiKey = key
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests (http.DefaultClient
+// by default).
+func WithHTTPClient(client *http.Client) OpenAIOption {
+	return func(c *openAIClient) {
+		c.client = client
+	}
+}
+
+// newOpenAIClient validates baseURL, the API root including the version
+// (e.g. "http://localhost:8080/v1").
+func newOpenAIClient(baseURL string, opts []OpenAIOption) (*openAIClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAI-compatible base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid OpenAI-compatible base URL %q: scheme must be http or https", baseURL)
+	}
+	c := &openAIClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// post sends body as JSON to path and returns the response, which the caller
+// must close. Non-2xx responses are turned into errors.
+func (c *openAIClient) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("request to %s failed, status: %d, response: %s", path, resp.StatusCode, apiErrorMessage(bodyBytes))
+	}
+	return resp, nil
+}
+
+// apiError is the error object returned by OpenAI-compatible servers.
+type apiError struct {
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// apiErrorMessage extracts the error message of a response body, falling
+// back to the raw body.
+func apiErrorMessage(body []byte) string {
+	var e apiError
+	if err := json.Unmarshal(body, &e); err == nil && e.Error != nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// OpenAIEmbedder implements the usecase.EmbeddingGenerator interface using
+// the /embeddings endpoint of an OpenAI-compatible server.
+type OpenAIEmbedder struct {
+	client *openAIClient
+	model  string
+	probe  dimensionProbe
+}
+
+type embeddingsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// NewOpenAIEmbedder creates an embedder for model served at baseURL, the API
+// root including the version (e.g. "http://localhost:8080/v1").
+func NewOpenAIEmbedder(baseURL, model string, opts ...OpenAIOption) (*OpenAIEmbedder, error) {
+	client, err := newOpenAIClient(baseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAIEmbedder{client: client, model: model}, nil
+}
+
+func (e *OpenAIEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings, err := e.embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("openai embed documents failed: %w", err)
+	}
+	return embeddings, nil
+}
+
+func (e *OpenAIEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("openai embed query failed: %w", err)
+	}
+	return embeddings[0], nil
+}
+
+// embed requests the embeddings of texts in one call.
+func (e *OpenAIEmbedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	resp, err := e.client.post(ctx, "/embeddings", embeddingsRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var embResp embeddingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings response: %w", err)
+	}
+	if len(embResp.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(embResp.Data), len(texts))
+	}
+
+	// Embeddings are matched to the inputs by index, whatever their order
+	embeddings := make([][]float32, len(texts))
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(texts) || embeddings[d.Index] != nil {
+			return nil, fmt.Errorf("unexpected embedding index %d", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	return embeddings, nil
+}
+
+// Dimension returns the dimension of the model's vectors, embedding a probe
+// text on the first call.
+func (e *OpenAIEmbedder) Dimension(ctx context.Context) (int, error) {
+	dimension, err := e.probe.get(ctx, e)
+	if err != nil {
+		return 0, fmt.Errorf("openai embedder: %w", err)
+	}
+	return dimension, nil
+}
+
+var _ usecase.EmbeddingGenerator = (*OpenAIEmbedder)(nil)
+var _ usecase.DimensionReporter = (*OpenAIEmbedder)(nil)
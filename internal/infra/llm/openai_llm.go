@@ -0,0 +1,156 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// OpenAILLM implements the usecase.LLM interface using the /chat/completions
+// endpoint of an OpenAI-compatible server. The prompt is sent as a single
+// user message. Call options are sent as request fields; top_k is an
+// extension accepted by llama.cpp server and vLLM, while NumCtx and
+// KeepAlive are ignored (they are server settings).
+type OpenAILLM struct {
+	client *openAIClient
+	model  string
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Stream      bool          `json:"stream"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        float64       `json:"top_p,omitempty"`
+	TopK        int           `json:"top_k,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
+	Seed        int           `json:"seed,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// chatChunk is one server-sent event of a streamed completion.
+type chatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewOpenAILLM creates an LLM for model served at baseURL, the API root
+// including the version (e.g. "http://localhost:8080/v1").
+func NewOpenAILLM(baseURL, model string, opts ...OpenAIOption) (*OpenAILLM, error) {
+	client, err := newOpenAIClient(baseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAILLM{client: client, model: model}, nil
+}
+
+// Call generates text based on the prompt.
+func (l *OpenAILLM) Call(ctx context.Context, prompt string, options ...usecase.CallOption) (string, error) {
+	resp, err := l.client.post(ctx, "/chat/completions", l.request(prompt, false, options))
+	if err != nil {
+		return "", fmt.Errorf("openai llm call failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var chatResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("openai llm call failed: failed to decode response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("openai llm call failed: response has no choices")
+	}
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// CallWithStreaming streams the completion, passing the content of every
+// server-sent delta to callbackFn as it arrives.
+func (l *OpenAILLM) CallWithStreaming(ctx context.Context, prompt string, callbackFn func(chunk string), options ...usecase.CallOption) error {
+	resp, err := l.client.post(ctx, "/chat/completions", l.request(prompt, true, options))
+	if err != nil {
+		return fmt.Errorf("openai streaming call failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := readChatStream(resp.Body, callbackFn); err != nil {
+		return fmt.Errorf("openai streaming call failed: %w", err)
+	}
+	return nil
+}
+
+func (l *OpenAILLM) request(prompt string, stream bool, options []usecase.CallOption) chatRequest {
+	opts := usecase.NewCallOptions(options...)
+	return chatRequest{
+		Model:       l.model,
+		Messages:    []chatMessage{{Role: "user", Content: prompt}},
+		Stream:      stream,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		TopK:        opts.TopK,
+		MaxTokens:   opts.MaxTokens,
+		Stop:        opts.Stop,
+		Seed:        opts.Seed,
+	}
+}
+
+// readChatStream reads server-sent events until "data: [DONE]" or the end of
+// the body. Events other than data lines (comments, event names, keep-alives)
+// are ignored.
+func readChatStream(body io.Reader, callbackFn func(chunk string)) error {
+	reader := bufio.NewReader(body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+		eof := err != nil
+
+		line = strings.TrimRight(line, "\r\n")
+		if data, ok := strings.CutPrefix(line, "data:"); ok {
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return nil
+			}
+			var chunk chatChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return fmt.Errorf("failed to decode stream event: %w", err)
+			}
+			if chunk.Error != nil {
+				return fmt.Errorf("server error: %s", chunk.Error.Message)
+			}
+			for _, choice := range chunk.Choices {
+				if choice.Delta.Content != "" {
+					callbackFn(choice.Delta.Content)
+				}
+			}
+		}
+		if eof {
+			return nil
+		}
+	}
+}
+
+// Ensure OpenAILLM implements the interface
+var _ usecase.LLM = (*OpenAILLM)(nil)
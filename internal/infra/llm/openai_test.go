@@ -0,0 +1,221 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/llm"
+)
+
+// deltaEvent returns a streamed completion event carrying content.
+func deltaEvent(content string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"choices": []interface{}{map[string]interface{}{"delta": map[string]string{"content": content}}},
+	})
+	return "data: " + string(data)
+}
+
+// newStreamServer serves body as the event stream of every chat completion.
+func newStreamServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request to %s, want /v1/chat/completions", r.URL.Path)
+		}
+		var req struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.Model != "test-model" || !req.Stream {
+			t.Errorf("request model %q, stream %v; want test-model, streaming", req.Model, req.Stream)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func streamChunks(t *testing.T, body string) ([]string, error) {
+	t.Helper()
+	server := newStreamServer(t, body)
+	model, err := llm.NewOpenAILLM(server.URL+"/v1", "test-model")
+	if err != nil {
+		t.Fatalf("NewOpenAILLM: %v", err)
+	}
+	var chunks []string
+	err = model.CallWithStreaming(context.Background(), "Hi", func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	return chunks, err
+}
+
+func TestOpenAILLMStreamingChunks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "done",
+			body: ": keep-alive\n\n" +
+				deltaEvent("Hello") + "\n\n" +
+				"event: message\n" + deltaEvent("") + "\n\n" +
+				deltaEvent(", world") + "\r\n\r\n" +
+				"data: [DONE]\n\n" +
+				deltaEvent("after done") + "\n\n",
+			want: []string{"Hello", ", world"},
+		},
+		{
+			name: "partial final line",
+			body: deltaEvent("Hello") + "\n\n" + deltaEvent("!"),
+			want: []string{"Hello", "!"},
+		},
+		{
+			name: "no space after data",
+			body: "data:" + strings.TrimPrefix(deltaEvent("Hi"), "data: ") + "\n\ndata:[DONE]",
+			want: []string{"Hi"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := streamChunks(t, tt.body)
+			if err != nil {
+				t.Fatalf("CallWithStreaming: %v", err)
+			}
+			if !reflect.DeepEqual(chunks, tt.want) {
+				t.Errorf("chunks = %q, want %q", chunks, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAILLMStreamingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "error event",
+			body:    deltaEvent("Hel") + "\n\n" + `data: {"error":{"message":"model overloaded"}}` + "\n\n",
+			want:    []string{"Hel"},
+			wantErr: "model overloaded",
+		},
+		{
+			name:    "invalid event",
+			body:    "data: {not json\n\n",
+			wantErr: "failed to decode stream event",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := streamChunks(t, tt.body)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CallWithStreaming error = %v, want one containing %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(chunks, tt.want) {
+				t.Errorf("chunks = %q, want %q", chunks, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer wrong-key" {
+			t.Errorf("Authorization = %q, want the API key", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"error":{"message":"invalid api key","type":"invalid_request_error"}}`)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	model, err := llm.NewOpenAILLM(server.URL+"/v1", "test-model", llm.WithAPIKey("wrong-key"))
+	if err != nil {
+		t.Fatalf("NewOpenAILLM: %v", err)
+	}
+	embedder, err := llm.NewOpenAIEmbedder(server.URL+"/v1", "test-model", llm.WithAPIKey("wrong-key"))
+	if err != nil {
+		t.Fatalf("NewOpenAIEmbedder: %v", err)
+	}
+
+	calls := map[string]func() error{
+		"Call": func() error {
+			_, err := model.Call(ctx, "Hi")
+			return err
+		},
+		"CallWithStreaming": func() error {
+			return model.CallWithStreaming(ctx, "Hi", func(chunk string) {
+				t.Errorf("unexpected chunk %q", chunk)
+			})
+		},
+		"EmbedDocuments": func() error {
+			_, err := embedder.EmbedDocuments(ctx, []string{"a"})
+			return err
+		},
+	}
+	for name, call := range calls {
+		err := call()
+		if err == nil {
+			t.Errorf("%s succeeded on a 401 response", name)
+			continue
+		}
+		for _, want := range []string{"status: 401", "invalid api key"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s error = %v, want it to contain %q", name, err, want)
+			}
+		}
+	}
+}
+
+func TestOpenAIEmbedDocumentsOrder(t *testing.T) {
+	texts := []string{"first", "second", "third"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("request to %s, want /v1/embeddings", r.URL.Path)
+		}
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if !reflect.DeepEqual(req.Input, texts) {
+			t.Errorf("input = %q, want %q", req.Input, texts)
+		}
+		// Embeddings sent in reverse order, each [index, index]
+		var data []string
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, fmt.Sprintf(`{"object":"embedding","index":%d,"embedding":[%d,%d]}`, i, i, i))
+		}
+		io.WriteString(w, `{"object":"list","data":[`+strings.Join(data, ",")+`]}`)
+	}))
+	defer server.Close()
+
+	embedder, err := llm.NewOpenAIEmbedder(server.URL+"/v1", "test-model")
+	if err != nil {
+		t.Fatalf("NewOpenAIEmbedder: %v", err)
+	}
+	embeddings, err := embedder.EmbedDocuments(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+	want := [][]float32{{0, 0}, {1, 1}, {2, 2}}
+	if !reflect.DeepEqual(embeddings, want) {
+		t.Errorf("embeddings = %v, want %v", embeddings, want)
+	}
+}
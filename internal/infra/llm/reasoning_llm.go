@@ -0,0 +1,235 @@
+package llm
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// ReasoningLLM implements the usecase.LLM interface by separating the
+// <think>…</think> reasoning of thinking models (deepseek-r1, qwq...) from
+// the answer of the wrapped LLM, as selected by usecase.CallOptions.Reasoning:
+// the reasoning is left inline, hidden, passed to OnReasoning or logged.
+// Tags split across streamed chunks are recognized.
+type ReasoningLLM struct {
+	next usecase.LLM
+	mode usecase.ReasoningMode
+}
+
+// NewReasoningLLM wraps next, handling reasoning with mode unless the call
+// options select another one.
+func NewReasoningLLM(next usecase.LLM, mode usecase.ReasoningMode) *ReasoningLLM {
+	if !mode.Valid() {
+		mode = usecase.ReasoningInline
+	}
+	return &ReasoningLLM{next: next, mode: mode}
+}
+
+// Call returns the answer of the wrapped LLM without its reasoning.
+func (l *ReasoningLLM) Call(ctx context.Context, prompt string, options ...usecase.CallOption) (string, error) {
+	opts := usecase.NewCallOptions(options...)
+	mode := l.modeOf(opts)
+	completion, err := l.next.Call(ctx, prompt, options...)
+	if err != nil || mode == usecase.ReasoningInline {
+		return completion, err
+	}
+
+	var answer, reasoning strings.Builder
+	var s thinkSplitter
+	emit := func(text string, part thinkPart) {
+		switch part {
+		case thinkAnswer:
+			answer.WriteString(text)
+		case thinkReasoning:
+			reasoning.WriteString(text)
+		case thinkRetracted:
+			// No tag came before, so the retracted text is the whole answer so far
+			reasoning.WriteString(text)
+			answer.Reset()
+		}
+	}
+	s.write(completion, emit)
+	s.flush(emit)
+	l.deliver(mode, opts, reasoning.String())
+	return answer.String(), nil
+}
+
+// CallWithStreaming streams the answer of the wrapped LLM to callbackFn and,
+// with usecase.ReasoningSeparate, its reasoning to OnReasoning. When the
+// model omits the opening <think> tag, the text streamed before its
+// </think> has already reached callbackFn; it is passed to OnReasoning (or
+// logged) as well.
+func (l *ReasoningLLM) CallWithStreaming(ctx context.Context, prompt string, callbackFn func(chunk string), options ...usecase.CallOption) error {
+	opts := usecase.NewCallOptions(options...)
+	mode := l.modeOf(opts)
+	if mode == usecase.ReasoningInline {
+		return l.next.CallWithStreaming(ctx, prompt, callbackFn, options...)
+	}
+
+	var reasoning strings.Builder
+	var s thinkSplitter
+	emit := func(text string, part thinkPart) {
+		switch {
+		case part == thinkAnswer:
+			callbackFn(text)
+		case mode == usecase.ReasoningSeparate && opts.OnReasoning != nil:
+			opts.OnReasoning(text)
+		case mode == usecase.ReasoningLog:
+			reasoning.WriteString(text)
+		}
+	}
+	err := l.next.CallWithStreaming(ctx, prompt, func(chunk string) {
+		s.write(chunk, emit)
+	}, options...)
+	s.flush(emit)
+	if mode == usecase.ReasoningLog {
+		l.deliver(mode, opts, reasoning.String())
+	}
+	return err
+}
+
+func (l *ReasoningLLM) modeOf(opts usecase.CallOptions) usecase.ReasoningMode {
+	if opts.Reasoning != "" {
+		return opts.Reasoning
+	}
+	return l.mode
+}
+
+// deliver hands the whole reasoning of a call to OnReasoning or the log.
+func (l *ReasoningLLM) deliver(mode usecase.ReasoningMode, opts usecase.CallOptions, reasoning string) {
+	reasoning = strings.TrimSpace(reasoning)
+	if reasoning == "" {
+		return
+	}
+	switch {
+	case mode == usecase.ReasoningSeparate && opts.OnReasoning != nil:
+		opts.OnReasoning(reasoning)
+	case mode == usecase.ReasoningLog:
+		log.Printf("Model reasoning:\n%s", reasoning)
+	}
+}
+
+// thinkPart classifies the text passed on by a thinkSplitter.
+type thinkPart int
+
+const (
+	thinkAnswer thinkPart = iota
+	thinkReasoning
+	// thinkRetracted is answer text already passed on that turned out to be
+	// reasoning, as a </think> followed it without any <think> before.
+	thinkRetracted
+)
+
+// thinkSplitter splits a stream of text into answer and reasoning parts.
+// Text that may be the start of a tag is held back until the next chunk
+// shows whether it is one. Whitespace at the start of the stream and right
+// after a tag is dropped.
+//
+// Some models (or their chat templates) open the reasoning in the prompt
+// and only write the closing tag: a </think> before any <think> ends the
+// reasoning, and the text passed on as answer until then is retracted.
+type thinkSplitter struct {
+	inThink bool
+	pending string // Possible beginning of the next tag
+	started bool   // Non-space text was emitted since the last tag
+	tagSeen bool
+	leading strings.Builder // Answer passed on before any tag
+}
+
+// write splits chunk, passing each part to emit.
+func (s *thinkSplitter) write(chunk string, emit func(text string, part thinkPart)) {
+	buf := s.pending + chunk
+	s.pending = ""
+	for buf != "" {
+		tags := []string{thinkOpen}
+		switch {
+		case s.inThink:
+			tags = []string{thinkClose}
+		case !s.tagSeen:
+			tags = []string{thinkOpen, thinkClose}
+		}
+
+		if i, tag := indexTag(buf, tags); i >= 0 {
+			if tag == thinkClose && !s.inThink {
+				// The reasoning started with the stream
+				if s.leading.Len() > 0 {
+					emit(s.leading.String(), thinkRetracted)
+				}
+				s.inThink = true
+			}
+			s.emit(buf[:i], emit)
+			buf = buf[i+len(tag):]
+			s.inThink = !s.inThink
+			s.started = false
+			s.tagSeen = true
+			s.leading.Reset()
+			continue
+		}
+
+		keep := 0
+		for _, tag := range tags {
+			keep = max(keep, partialTagSuffix(buf, tag))
+		}
+		s.emit(buf[:len(buf)-keep], emit)
+		s.pending = buf[len(buf)-keep:]
+		return
+	}
+}
+
+// flush emits the text held back at the end of the stream.
+func (s *thinkSplitter) flush(emit func(text string, part thinkPart)) {
+	s.emit(s.pending, emit)
+	s.pending = ""
+}
+
+func (s *thinkSplitter) emit(text string, emit func(text string, part thinkPart)) {
+	if !s.started {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		s.started = text != ""
+	}
+	if text == "" {
+		return
+	}
+	if s.inThink {
+		emit(text, thinkReasoning)
+		return
+	}
+	if !s.tagSeen {
+		s.leading.WriteString(text)
+	}
+	emit(text, thinkAnswer)
+}
+
+// indexTag returns the index of the first of tags found in s and the tag,
+// or -1 when none is.
+func indexTag(s string, tags []string) (int, string) {
+	first, found := -1, ""
+	for _, tag := range tags {
+		if i := strings.Index(s, tag); i >= 0 && (first < 0 || i < first) {
+			first, found = i, tag
+		}
+	}
+	return first, found
+}
+
+// partialTagSuffix returns the length of the longest suffix of s that is a
+// proper prefix of tag.
+func partialTagSuffix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// Ensure ReasoningLLM implements the interface
+var _ usecase.LLM = (*ReasoningLLM)(nil)
@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// split runs chunks through a thinkSplitter and returns the answer and the
+// reasoning, with retracted text moved from the first to the second.
+func split(chunks []string) (answer, reasoning string) {
+	var a, r strings.Builder
+	var s thinkSplitter
+	emit := func(text string, part thinkPart) {
+		switch part {
+		case thinkAnswer:
+			a.WriteString(text)
+		case thinkReasoning:
+			r.WriteString(text)
+		case thinkRetracted:
+			if !strings.HasPrefix(a.String(), text) {
+				panic("retracted text " + text + " is not the answer so far " + a.String())
+			}
+			rest := strings.TrimPrefix(a.String(), text)
+			a.Reset()
+			a.WriteString(rest)
+			r.WriteString(text)
+		}
+	}
+	for _, chunk := range chunks {
+		s.write(chunk, emit)
+	}
+	s.flush(emit)
+	return a.String(), r.String()
+}
+
+func TestThinkSplitter(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		answer    string
+		reasoning string
+	}{
+		{"no reasoning", "Cats purr.", "Cats purr.", ""},
+		{"reasoning block", "<think>Recall facts.</think>Cats purr.", "Cats purr.", "Recall facts."},
+		{"whitespace around tags", "\n<think>\nRecall facts.\n</think>\n\nCats purr.", "Cats purr.", "Recall facts.\n"},
+		{"text before the block", "Well <think>hmm</think> ok", "Well ok", "hmm"},
+		{"unterminated block", "<think>Still thinking", "", "Still thinking"},
+		{"empty block", "<think></think>Cats purr.", "Cats purr.", ""},
+		{"missing opening tag", "Recall facts.</think>Cats purr.", "Cats purr.", "Recall facts."},
+		{"missing opening tag with whitespace", "  Recall facts.\n</think>\n\nCats purr.", "Cats purr.", "Recall facts.\n"},
+		{"closing tag first", "</think>Cats purr.", "Cats purr.", ""},
+		{"closing tag after a block", "<think>a</think>b</think>c", "b</think>c", "a"},
+		{"opening tag after a missing one", "a</think>b<think>c</think>d", "bd", "ac"},
+		{"tag-like text", "x <thin y </thinker> z <", "x <thin y </thinker> z <", ""},
+		{"less-than in reasoning", "<think>1 < 2 </ 3</think>yes", "yes", "1 < 2 </ 3"},
+		{"multi-byte runes", "Pensão é</think>Ação rápida", "Ação rápida", "Pensão é"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(desc string, chunks []string) {
+				t.Helper()
+				answer, reasoning := split(chunks)
+				if answer != tt.answer || reasoning != tt.reasoning {
+					t.Errorf("%s %q: answer %q, reasoning %q; want %q and %q", desc, chunks, answer, reasoning, tt.answer, tt.reasoning)
+				}
+			}
+
+			check("one chunk", []string{tt.text})
+			for i := 0; i <= len(tt.text); i++ {
+				check("split", []string{tt.text[:i], tt.text[i:]})
+			}
+			for i := 0; i <= len(tt.text); i++ {
+				for j := i; j <= len(tt.text); j++ {
+					check("split twice", []string{tt.text[:i], tt.text[i:j], tt.text[j:]})
+				}
+			}
+			bytes := make([]string, len(tt.text))
+			for i := 0; i < len(tt.text); i++ {
+				bytes[i] = tt.text[i : i+1]
+			}
+			check("byte by byte", bytes)
+		})
+	}
+}
+
+func TestReasoningLLMMissingOpeningTag(t *testing.T) {
+	ctx := context.Background()
+	completion := "Recall facts.\n</think>\n\nCats purr."
+
+	var reasoning []string
+	l := NewReasoningLLM(NewFakeLLM(WithResponses(completion), WithStreamChunks(3, 0)), usecase.ReasoningSeparate)
+	answer, err := l.Call(ctx, "q", usecase.WithReasoningCallback(func(chunk string) { reasoning = append(reasoning, chunk) }))
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if answer != "Cats purr." || strings.Join(reasoning, "") != "Recall facts." {
+		t.Errorf("Call: answer %q, reasoning %q", answer, reasoning)
+	}
+
+	// The streamed answer cannot be taken back, but the reasoning is complete
+	var streamed strings.Builder
+	reasoning = nil
+	err = l.CallWithStreaming(ctx, "q", func(chunk string) { streamed.WriteString(chunk) },
+		usecase.WithReasoningCallback(func(chunk string) { reasoning = append(reasoning, chunk) }))
+	if err != nil {
+		t.Fatalf("CallWithStreaming: %v", err)
+	}
+	if got := strings.Join(reasoning, ""); got != "Recall facts.\n" {
+		t.Errorf("streamed reasoning = %q", got)
+	}
+	if !strings.HasSuffix(streamed.String(), "Cats purr.") || strings.Contains(streamed.String(), "</think>") {
+		t.Errorf("streamed answer = %q", streamed.String())
+	}
+
+	// Hidden reasoning never reaches the answer of a call
+	hidden := NewReasoningLLM(NewFakeLLM(WithResponses(completion)), usecase.ReasoningHide)
+	if answer, err := hidden.Call(ctx, "q"); err != nil || answer != "Cats purr." {
+		t.Errorf("Call with hidden reasoning = %q, %v", answer, err)
+	}
+}
@@ -0,0 +1,168 @@
+package loader
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// DOCXLoader loads Word documents (.docx) as a single document. Paragraphs
+// become lines, table cells are separated by tabs, and paragraphs styled as
+// headings are kept as metadata.
+type DOCXLoader struct{}
+
+func NewDOCXLoader() *DOCXLoader {
+	return &DOCXLoader{}
+}
+
+func (l *DOCXLoader) Load(ctx context.Context, source string) ([]schema.Document, error) {
+	pkg, err := openOOXML(source)
+	if err != nil {
+		return nil, err
+	}
+	defer pkg.Close()
+
+	rc, err := pkg.open("word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	paragraphs, headings, err := docxParagraphs(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Word document '%s': %w", source, err)
+	}
+
+	content := strings.TrimSpace(strings.Join(paragraphs, "\n"))
+	if content == "" {
+		return nil, fmt.Errorf("Word document '%s' has no text content", source)
+	}
+
+	metadata := map[string]interface{}{
+		"source": source,
+		"format": "docx",
+	}
+	if len(headings) > 0 {
+		metadata["headings"] = headings
+	}
+	if title := pkg.coreTitle(); title != "" {
+		metadata["title"] = title
+	}
+
+	return []schema.Document{{
+		PageContent: content,
+		Metadata:    metadata,
+	}}, nil
+}
+
+// docxParagraphs streams word/document.xml and returns the text of every
+// paragraph and the headings ("## Title") found along the way.
+func docxParagraphs(r io.Reader) ([]string, []string, error) {
+	var paragraphs, headings []string
+	var para, cell strings.Builder
+	var cells []string
+	headingLevel := 0
+	rowDepth := 0 // Table rows are written as one tab separated line
+	inRun, inText := false, false
+
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+				headingLevel = 0
+			case "pStyle":
+				headingLevel = docxHeadingLevel(attr(t, "val"))
+			case "r":
+				inRun = true
+			case "t":
+				inText = inRun
+			case "tab":
+				// Tab stops in paragraph properties are not text
+				if inRun {
+					para.WriteString("\t")
+				}
+			case "br", "cr":
+				if inRun {
+					para.WriteString("\n")
+				}
+			case "tr":
+				if rowDepth == 0 {
+					cells = cells[:0]
+				}
+				rowDepth++
+			case "tc":
+				cell.Reset()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "r":
+				inRun = false
+			case "t":
+				inText = false
+			case "p":
+				text := strings.TrimSpace(para.String())
+				if rowDepth > 0 {
+					if text != "" {
+						if cell.Len() > 0 {
+							cell.WriteString(" ")
+						}
+						cell.WriteString(text)
+					}
+					continue
+				}
+				paragraphs = append(paragraphs, text)
+				if headingLevel > 0 && text != "" {
+					headings = append(headings, strings.Repeat("#", headingLevel)+" "+text)
+				}
+			case "tc":
+				cells = append(cells, cell.String())
+				cell.Reset()
+			case "tr":
+				rowDepth--
+				if rowDepth == 0 {
+					paragraphs = append(paragraphs, strings.Join(cells, "\t"))
+				}
+			}
+		case xml.CharData:
+			if inText {
+				para.Write(t)
+			}
+		}
+	}
+	return paragraphs, headings, nil
+}
+
+// docxHeadingLevel returns the level of built-in heading styles ("Heading1",
+// "Title"), or 0 for other styles.
+func docxHeadingLevel(style string) int {
+	lower := strings.ToLower(style)
+	if lower == "title" {
+		return 1
+	}
+	if n, ok := strings.CutPrefix(lower, "heading"); ok {
+		if level, err := strconv.Atoi(n); err == nil && level >= 1 && level <= 6 {
+			return level
+		}
+	}
+	return 0
+}
+
+// Ensure DOCXLoader implements the interface
+var _ usecase.DocumentLoader = (*DOCXLoader)(nil)
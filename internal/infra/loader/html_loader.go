@@ -0,0 +1,335 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLLoader loads HTML pages (.html, .htm) as a single text document.
+// Scripts, styles, navigation, page headers and footers and forms are dropped, and
+// the main content (<main> or <article>) is preferred over the whole body.
+// The page title and headings are kept as metadata. Headings, preformatted
+// blocks and tables are written as Markdown ("## Title", fenced code, pipe
+// tables), so that the text can be split by section.
+type HTMLLoader struct{}
+
+func NewHTMLLoader() *HTMLLoader {
+	return &HTMLLoader{}
+}
+
+// boilerplateElements are never part of the extracted text.
+var boilerplateElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Nav:      true,
+	atom.Aside:    true,
+}
+
+// boilerplateRoles are ARIA roles of navigation and page chrome.
+var boilerplateRoles = map[string]bool{
+	"navigation":    true,
+	"banner":        true,
+	"contentinfo":   true,
+	"complementary": true,
+	"search":        true,
+}
+
+// blockElements start a new line in the extracted text.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Table: true, atom.Tr: true, atom.Pre: true, atom.Blockquote: true, atom.Br: true,
+	atom.Hr: true, atom.Figure: true, atom.Figcaption: true,
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+func (l *HTMLLoader) Load(ctx context.Context, source string) ([]schema.Document, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HTML file '%s': %w", source, err)
+	}
+	defer f.Close()
+
+	root, err := html.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML file '%s': %w", source, err)
+	}
+
+	content := findElement(root, atom.Main)
+	if content == nil {
+		content = findElement(root, atom.Article)
+	}
+	if content == nil {
+		content = findElement(root, atom.Body)
+	}
+	if content == nil {
+		content = root
+	}
+
+	ex := &htmlExtractor{}
+	ex.walk(content)
+	text := ex.text()
+	if text == "" {
+		return nil, fmt.Errorf("HTML file '%s' has no text content", source)
+	}
+
+	metadata := map[string]interface{}{
+		"source": source,
+		"format": "html",
+	}
+	if len(ex.headings) > 0 {
+		metadata["headings"] = ex.headings
+	}
+	title := ""
+	if t := findElement(root, atom.Title); t != nil {
+		title = collapseSpaces(nodeText(t))
+	}
+	if title = firstNonEmpty(title, ex.firstH1); title != "" {
+		metadata["title"] = title
+	}
+
+	return []schema.Document{{
+		PageContent: text,
+		Metadata:    metadata,
+	}}, nil
+}
+
+// htmlExtractor collects the visible text and headings of a node tree.
+type htmlExtractor struct {
+	b        strings.Builder
+	headings []string
+	firstH1  string
+}
+
+func (ex *htmlExtractor) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		ex.b.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if isBoilerplate(n) {
+			return
+		}
+		if level, ok := headingLevels[n.DataAtom]; ok {
+			if text := collapseSpaces(nodeText(n)); text != "" {
+				heading := strings.Repeat("#", level) + " " + text
+				ex.headings = append(ex.headings, heading)
+				if level == 1 && ex.firstH1 == "" {
+					ex.firstH1 = text
+				}
+				ex.b.WriteString("\n" + heading + "\n")
+			}
+			return
+		}
+		switch n.DataAtom {
+		case atom.Pre:
+			ex.writeCode(n)
+			return
+		case atom.Table:
+			ex.writeTable(n)
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blockElements[n.DataAtom]
+	if block {
+		ex.b.WriteString("\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		ex.walk(c)
+	}
+	if block {
+		ex.b.WriteString("\n")
+	} else if n.DataAtom == atom.Td || n.DataAtom == atom.Th {
+		ex.b.WriteString(" ")
+	}
+}
+
+// writeCode writes a preformatted block as fenced code, keeping its
+// whitespace. The language is taken from a "language-*" class.
+func (ex *htmlExtractor) writeCode(n *html.Node) {
+	code := strings.TrimRight(strings.TrimPrefix(nodeText(n), "\n"), " \t\r\n")
+	if strings.TrimSpace(code) == "" {
+		return
+	}
+	lang := codeLanguage(n)
+	if lang == "" {
+		if c := findElement(n, atom.Code); c != nil {
+			lang = codeLanguage(c)
+		}
+	}
+	ex.b.WriteString("\n```" + lang + "\n" + code + "\n```\n")
+}
+
+// writeTable writes a table as a Markdown pipe table whose first row is the
+// header. Rows of nested tables are flattened into their cell.
+func (ex *htmlExtractor) writeTable(n *html.Node) {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(c *html.Node) {
+		for ; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || isBoilerplate(c) {
+				continue
+			}
+			if c.DataAtom != atom.Tr {
+				collect(c.FirstChild)
+				continue
+			}
+			var cells []string
+			for td := c.FirstChild; td != nil; td = td.NextSibling {
+				if td.DataAtom == atom.Td || td.DataAtom == atom.Th {
+					cell := &htmlExtractor{}
+					for cc := td.FirstChild; cc != nil; cc = cc.NextSibling {
+						cell.walk(cc)
+					}
+					text := collapseSpaces(strings.ReplaceAll(cell.b.String(), "\n", " "))
+					cells = append(cells, strings.ReplaceAll(text, "|", "\\|"))
+				}
+			}
+			if len(cells) > 0 {
+				rows = append(rows, cells)
+			}
+		}
+	}
+	collect(n.FirstChild)
+	if len(rows) == 0 {
+		return
+	}
+
+	ex.b.WriteString("\n")
+	for i, cells := range rows {
+		ex.b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			ex.b.WriteString("|" + strings.Repeat(" --- |", len(cells)) + "\n")
+		}
+	}
+}
+
+// codeLanguage returns the language of a "language-go" or "lang-go" class.
+func codeLanguage(n *html.Node) string {
+	for _, attr := range n.Attr {
+		if attr.Key != "class" {
+			continue
+		}
+		for _, class := range strings.Fields(attr.Val) {
+			if lang, ok := strings.CutPrefix(class, "language-"); ok {
+				return lang
+			}
+			if lang, ok := strings.CutPrefix(class, "lang-"); ok {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+// text returns the collected text with whitespace collapsed inside lines
+// and at most one blank line between blocks. Fenced code is kept as is.
+func (ex *htmlExtractor) text() string {
+	var lines []string
+	blank, inCode := false, false
+	for _, line := range strings.Split(ex.b.String(), "\n") {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+		} else if inCode {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+			continue
+		}
+		line = collapseSpaces(line)
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if boilerplateElements[n.DataAtom] {
+		return true
+	}
+	// Page headers and footers are chrome; those of an article are content
+	if (n.DataAtom == atom.Header || n.DataAtom == atom.Footer) && !insideContent(n) {
+		return true
+	}
+	for _, attr := range n.Attr {
+		switch attr.Key {
+		case "role":
+			if boilerplateRoles[attr.Val] {
+				return true
+			}
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if attr.Val == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// insideContent reports whether n is nested in an article, main or section element.
+func insideContent(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		switch p.DataAtom {
+		case atom.Article, atom.Main, atom.Section:
+			return true
+		}
+	}
+	return false
+}
+
+// findElement returns the first element of the given type in document order.
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// nodeText returns the concatenated text of all descendants of n.
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(nodeText(c))
+	}
+	return b.String()
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Ensure HTMLLoader implements the interface
+var _ usecase.DocumentLoader = (*HTMLLoader)(nil)
@@ -0,0 +1,132 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// MarkdownLoader loads Markdown files (.md, .markdown) as a single document.
+// YAML front matter is removed from the content; its title, if any, and the
+// document headings are kept as metadata.
+type MarkdownLoader struct{}
+
+func NewMarkdownLoader() *MarkdownLoader {
+	return &MarkdownLoader{}
+}
+
+func (l *MarkdownLoader) Load(ctx context.Context, source string) ([]schema.Document, error) {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Markdown file '%s': %w", source, err)
+	}
+
+	body, frontTitle := splitFrontMatter(normalizeText(string(data)))
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("Markdown file '%s' is empty", source)
+	}
+
+	headings := markdownHeadings(body)
+	metadata := map[string]interface{}{
+		"source": source,
+		"format": "markdown",
+	}
+	if len(headings) > 0 {
+		metadata["headings"] = headings
+	}
+	if title := firstNonEmpty(frontTitle, markdownTitle(body)); title != "" {
+		metadata["title"] = title
+	}
+
+	return []schema.Document{{
+		PageContent: strings.TrimSpace(body),
+		Metadata:    metadata,
+	}}, nil
+}
+
+// splitFrontMatter removes a leading "---" delimited YAML front matter block
+// and returns the remaining body and the front matter title, if present.
+func splitFrontMatter(s string) (string, string) {
+	if !strings.HasPrefix(s, "---\n") {
+		return s, ""
+	}
+	end := strings.Index(s[4:], "\n---")
+	if end < 0 {
+		return s, ""
+	}
+	front := s[4 : 4+end]
+	body := strings.TrimPrefix(s[4+end+4:], "\n")
+
+	var title string
+	for _, line := range strings.Split(front, "\n") {
+		if v, ok := strings.CutPrefix(line, "title:"); ok {
+			title = strings.Trim(strings.TrimSpace(v), `"'`)
+			break
+		}
+	}
+	return body, title
+}
+
+// markdownHeadings returns the ATX headings ("# Title") outside fenced code
+// blocks, prefixed by their level, e.g. "## Install".
+func markdownHeadings(body string) []string {
+	var headings []string
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if level, text := markdownHeading(trimmed); level > 0 {
+			headings = append(headings, strings.Repeat("#", level)+" "+text)
+		}
+	}
+	return headings
+}
+
+// markdownHeading parses an ATX heading line, returning level 0 when the
+// line is not a heading.
+func markdownHeading(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level == len(line) || line[level] != ' ' {
+		return 0, ""
+	}
+	text := strings.TrimSpace(strings.TrimRight(line[level:], "# "))
+	if text == "" {
+		return 0, ""
+	}
+	return level, text
+}
+
+// markdownTitle returns the text of the first level 1 heading.
+func markdownTitle(body string) string {
+	for _, h := range markdownHeadings(body) {
+		if strings.HasPrefix(h, "# ") {
+			return strings.TrimPrefix(h, "# ")
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Ensure MarkdownLoader implements the interface
+var _ usecase.DocumentLoader = (*MarkdownLoader)(nil)
@@ -0,0 +1,111 @@
+package loader
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ooxmlPackage is an opened Office Open XML (DOCX, XLSX, PPTX) zip container.
+type ooxmlPackage struct {
+	zr     *zip.ReadCloser
+	source string
+}
+
+func openOOXML(source string) (*ooxmlPackage, error) {
+	zr, err := zip.OpenReader(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Office document '%s': %w", source, err)
+	}
+	return &ooxmlPackage{zr: zr, source: source}, nil
+}
+
+func (p *ooxmlPackage) Close() error {
+	return p.zr.Close()
+}
+
+// open opens a part of the package by its name, e.g. "word/document.xml".
+func (p *ooxmlPackage) open(name string) (io.ReadCloser, error) {
+	name = strings.TrimPrefix(name, "/")
+	for _, f := range p.zr.File {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, fmt.Errorf("part '%s' not found in Office document '%s'", name, p.source)
+}
+
+// has reports whether the package contains the part.
+func (p *ooxmlPackage) has(name string) bool {
+	for _, f := range p.zr.File {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// decode unmarshals an XML part into v.
+func (p *ooxmlPackage) decode(name string, v interface{}) error {
+	rc, err := p.open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse '%s' in Office document '%s': %w", name, p.source, err)
+	}
+	return nil
+}
+
+// relationships returns the targets of the relationships of a part, keyed by
+// relationship ID and resolved to package part names.
+func (p *ooxmlPackage) relationships(part string) (map[string]string, error) {
+	relsName := path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := p.decode(relsName, &rels); err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, r := range rels.Relationships {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join(path.Dir(part), r.Target)
+		}
+	}
+	return targets, nil
+}
+
+// coreTitle returns the document title from docProps/core.xml, if any.
+func (p *ooxmlPackage) coreTitle() string {
+	if !p.has("docProps/core.xml") {
+		return ""
+	}
+	var core struct {
+		Title string `xml:"title"`
+	}
+	if err := p.decode("docProps/core.xml", &core); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(core.Title)
+}
+
+// attr returns the value of the attribute with the given local name.
+func attr(se xml.StartElement, local string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
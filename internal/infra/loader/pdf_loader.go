@@ -4,12 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
-	"github.com/tmc/langchaingo/documentloaders"
+	"github.com/ledongthuc/pdf"
 	"github.com/tmc/langchaingo/schema"
 )
 
+// PDFLoader loads PDF files, returning one document per page with text. The
+// page number, the total number of pages and the title, author and creation
+// date from the document information dictionary are kept as metadata, so
+// they are copied to every chunk of the page.
 type PDFLoader struct{}
 
 func NewPDFLoader() *PDFLoader {
@@ -28,22 +34,132 @@ func (l *PDFLoader) Load(ctx context.Context, source string) ([]schema.Document,
 		return nil, fmt.Errorf("failed to get file info for '%s': %w", source, err)
 	}
 
-	loader := documentloaders.NewPDF(f, fileInfo.Size())
-	docs, err := loader.Load(ctx)
+	reader, err := pdf.NewReader(f, fileInfo.Size())
 	if err != nil {
 		return nil, fmt.Errorf("failed to load documents from PDF '%s': %w", source, err)
 	}
 
-	// Add source metadata
-	for i := range docs {
-		if docs[i].Metadata == nil {
-			docs[i].Metadata = make(map[string]interface{})
+	info := pdfInfo(reader)
+	numPages := reader.NumPage()
+
+	// Fonts are shared between pages, so they are collected only once
+	fonts := make(map[string]*pdf.Font)
+	var docs []schema.Document
+	for i := 1; i <= numPages; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		text, err := page.GetPlainText(fonts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract text from page %d of PDF '%s': %w", i, source, err)
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		metadata := map[string]interface{}{
+			"source":      source,
+			"format":      "pdf",
+			"page":        i,
+			"total_pages": numPages,
 		}
-		docs[i].Metadata["source"] = source
+		for k, v := range info {
+			metadata[k] = v
+		}
+		docs = append(docs, schema.Document{
+			PageContent: text,
+			Metadata:    metadata,
+		})
 	}
 
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("PDF '%s' has no text content (scanned documents are not supported)", source)
+	}
 	return docs, nil
 }
 
+// pdfInfo returns the title, author and creation date of the document
+// information dictionary, omitting missing entries. The creation date is
+// formatted as RFC 3339 when it can be parsed.
+func pdfInfo(reader *pdf.Reader) map[string]interface{} {
+	info := reader.Trailer().Key("Info")
+	metadata := make(map[string]interface{})
+	if info.IsNull() {
+		return metadata
+	}
+
+	if title := strings.TrimSpace(info.Key("Title").Text()); title != "" {
+		metadata["title"] = title
+	}
+	if author := strings.TrimSpace(info.Key("Author").Text()); author != "" {
+		metadata["author"] = author
+	}
+	if raw := strings.TrimSpace(info.Key("CreationDate").Text()); raw != "" {
+		if t, ok := parsePDFDate(raw); ok {
+			metadata["created_at"] = t.Format(time.RFC3339)
+		} else {
+			metadata["created_at"] = raw
+		}
+	}
+	return metadata
+}
+
+// parsePDFDate parses a PDF date string ("D:YYYYMMDDHHmmSSOHH'mm'"). Every
+// part after the year is optional.
+func parsePDFDate(raw string) (time.Time, bool) {
+	s := strings.TrimPrefix(raw, "D:")
+
+	// Split the local time digits from the UTC offset
+	end := 0
+	for end < len(s) && end < 14 && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	digits, zone := s[:end], s[end:]
+	if len(digits) < 4 || len(digits)%2 != 0 {
+		return time.Time{}, false
+	}
+	layouts := map[int]string{
+		4:  "2006",
+		6:  "200601",
+		8:  "20060102",
+		10: "2006010215",
+		12: "200601021504",
+		14: "20060102150405",
+	}
+	t, err := time.Parse(layouts[len(digits)], digits)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	zone = strings.TrimSuffix(strings.ReplaceAll(zone, "'", ":"), ":")
+	switch {
+	case zone == "" || zone == "Z" || strings.HasPrefix(zone, "Z"):
+		return t, true
+	case zone[0] == '+' || zone[0] == '-':
+		if len(zone) == 3 {
+			zone += ":00"
+		}
+		offset, err := time.Parse("-07:00", zone)
+		if err != nil {
+			return t, true
+		}
+		_, secs := offset.Zone()
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.FixedZone("", secs)), true
+	default:
+		return t, true
+	}
+}
+
 // Ensure PDFLoader implements the interface
 var _ usecase.DocumentLoader = (*PDFLoader)(nil)
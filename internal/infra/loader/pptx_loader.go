@@ -0,0 +1,139 @@
+package loader
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// PPTXLoader loads PowerPoint presentations (.pptx), returning one document
+// per slide with text, in presentation order. Slide numbers are kept as
+// metadata, like pages of a PDF.
+type PPTXLoader struct{}
+
+func NewPPTXLoader() *PPTXLoader {
+	return &PPTXLoader{}
+}
+
+func (l *PPTXLoader) Load(ctx context.Context, source string) ([]schema.Document, error) {
+	pkg, err := openOOXML(source)
+	if err != nil {
+		return nil, err
+	}
+	defer pkg.Close()
+
+	const presentationPart = "ppt/presentation.xml"
+	var presentation struct {
+		Slides []struct {
+			Attr []xml.Attr `xml:",any,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	if err := pkg.decode(presentationPart, &presentation); err != nil {
+		return nil, err
+	}
+	rels, err := pkg.relationships(presentationPart)
+	if err != nil {
+		return nil, err
+	}
+
+	title := pkg.coreTitle()
+	total := len(presentation.Slides)
+
+	var docs []schema.Document
+	for i, slide := range presentation.Slides {
+		var relID string
+		for _, a := range slide.Attr {
+			if a.Name.Local == "id" && a.Name.Space != "" {
+				relID = a.Value
+			}
+		}
+		part, ok := rels[relID]
+		if !ok {
+			return nil, fmt.Errorf("slide %d of PowerPoint presentation '%s' has no content part", i+1, source)
+		}
+
+		paragraphs, err := pptxParagraphs(pkg, part)
+		if err != nil {
+			return nil, err
+		}
+		if len(paragraphs) == 0 {
+			continue
+		}
+
+		metadata := map[string]interface{}{
+			"source":       source,
+			"format":       "pptx",
+			"slide":        i + 1,
+			"total_slides": total,
+		}
+		if title != "" {
+			metadata["title"] = title
+		}
+		docs = append(docs, schema.Document{
+			PageContent: strings.Join(paragraphs, "\n"),
+			Metadata:    metadata,
+		})
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("PowerPoint presentation '%s' has no text content", source)
+	}
+	return docs, nil
+}
+
+// pptxParagraphs streams a slide part and returns its non-empty text paragraphs.
+func pptxParagraphs(pkg *ooxmlPackage, part string) ([]string, error) {
+	rc, err := pkg.open(part)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var paragraphs []string
+	var para strings.Builder
+	inText := false
+
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse '%s' in PowerPoint presentation '%s': %w", part, pkg.source, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+			case "t":
+				inText = true
+			case "br":
+				para.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if text := strings.TrimSpace(para.String()); text != "" {
+					paragraphs = append(paragraphs, text)
+				}
+			}
+		case xml.CharData:
+			if inText {
+				para.Write(t)
+			}
+		}
+	}
+	return paragraphs, nil
+}
+
+// Ensure PPTXLoader implements the interface
+var _ usecase.DocumentLoader = (*PPTXLoader)(nil)
@@ -0,0 +1,272 @@
+package loader
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// maxRecordLineSize bounds the length of a single JSON Lines record.
+const maxRecordLineSize = 16 << 20
+
+// reservedRecordKeys are metadata keys written by the loaders, the ingestion
+// pipeline or the vector store. Record fields with these names are stored
+// with a "record_" prefix instead.
+var reservedRecordKeys = map[string]bool{
+	"source":                   true,
+	"format":                   true,
+	"row":                      true,
+	"text":                     true,
+	"score":                    true,
+	usecase.MetadataFileHash:   true,
+	usecase.MetadataChunkIndex: true,
+	usecase.MetadataChunkHash:  true,
+}
+
+// RecordLoader loads structured records from CSV/TSV files (with a header
+// row) and JSON Lines files, returning one document per record. The content
+// fields become the document text; every other field is kept as metadata so
+// that it is stored in the vector store payload.
+type RecordLoader struct {
+	contentFields []string
+	idField       string
+}
+
+// RecordOption configures a RecordLoader.
+type RecordOption func(*RecordLoader)
+
+// WithContentFields sets the fields used as document text, in order. When
+// no content field is set, every field is used as "name: value" lines.
+func WithContentFields(fields ...string) RecordOption {
+	return func(l *RecordLoader) {
+		l.contentFields = fields
+	}
+}
+
+// WithIDField names the field identifying a record; its value is also stored
+// as "record_id" metadata.
+func WithIDField(field string) RecordOption {
+	return func(l *RecordLoader) {
+		l.idField = field
+	}
+}
+
+func NewRecordLoader(opts ...RecordOption) *RecordLoader {
+	l := &RecordLoader{}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+func (l *RecordLoader) Load(ctx context.Context, source string) ([]schema.Document, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open record file '%s': %w", source, err)
+	}
+	defer f.Close()
+
+	var docs []schema.Document
+	switch ext := strings.ToLower(filepath.Ext(source)); ext {
+	case ".jsonl", ".ndjson":
+		docs, err = l.loadJSONLines(f, source)
+	case ".tsv":
+		docs, err = l.loadCSV(f, source, '\t')
+	default:
+		docs, err = l.loadCSV(f, source, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("record file '%s' has no records with content", source)
+	}
+	return docs, nil
+}
+
+// loadCSV reads a delimited file whose first row holds the field names. A
+// zero delimiter is detected from the header row.
+func (l *RecordLoader) loadCSV(r io.Reader, source string, delimiter rune) ([]schema.Document, error) {
+	br := bufio.NewReader(r)
+	if delimiter == 0 {
+		header, err := br.Peek(4096)
+		if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, fmt.Errorf("failed to read CSV file '%s': %w", source, err)
+		}
+		delimiter = detectDelimiter(string(header))
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = delimiter
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header of '%s': %w", source, err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\uFEFF"))
+	}
+
+	var docs []schema.Document
+	for row := 1; ; row++ {
+		values, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV file '%s': %w", source, err)
+		}
+
+		record := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			if i < len(values) && name != "" {
+				record[name] = values[i]
+			}
+		}
+		if doc, ok := l.document(record, header, source, "csv", row); ok {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// loadJSONLines reads one JSON object per line. Invalid lines are skipped
+// with a warning.
+func (l *RecordLoader) loadJSONLines(r io.Reader, source string) ([]schema.Document, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordLineSize)
+
+	var docs []schema.Document
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			log.Printf("Warning: Skipping invalid JSON record at %s:%d: %v", source, row, err)
+			continue
+		}
+
+		fields := make([]string, 0, len(record))
+		for name := range record {
+			fields = append(fields, name)
+		}
+		sort.Strings(fields)
+
+		if doc, ok := l.document(record, fields, source, "jsonl", row); ok {
+			docs = append(docs, doc)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON Lines file '%s': %w", source, err)
+	}
+	return docs, nil
+}
+
+// document builds the document of one record. fields lists the record
+// fields in display order. Records without content are skipped.
+func (l *RecordLoader) document(record map[string]interface{}, fields []string, source, format string, row int) (schema.Document, bool) {
+	contentFields := l.contentFields
+	if len(contentFields) == 0 {
+		contentFields = fields
+	}
+
+	isContent := make(map[string]bool, len(contentFields))
+	var lines []string
+	for _, name := range contentFields {
+		isContent[name] = true
+		text := recordText(record[name])
+		if text == "" {
+			continue
+		}
+		if len(contentFields) == 1 {
+			lines = append(lines, text)
+		} else {
+			lines = append(lines, name+": "+text)
+		}
+	}
+	if len(lines) == 0 {
+		return schema.Document{}, false
+	}
+
+	metadata := map[string]interface{}{
+		"source": source,
+		"format": format,
+		"row":    row,
+	}
+	for name, value := range record {
+		if isContent[name] && len(l.contentFields) > 0 {
+			continue
+		}
+		key := name
+		if reservedRecordKeys[key] {
+			key = "record_" + key
+		}
+		metadata[key] = value
+	}
+	if l.idField != "" {
+		if id := recordText(record[l.idField]); id != "" {
+			metadata["record_id"] = id
+		}
+	}
+
+	return schema.Document{
+		PageContent: strings.Join(lines, "\n"),
+		Metadata:    metadata,
+	}, true
+}
+
+// recordText formats a field value as text. Strings are used as is; other
+// JSON values are encoded.
+func recordText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// detectDelimiter picks the most frequent of comma, semicolon and tab in the
+// first line of a delimited file.
+func detectDelimiter(sample string) rune {
+	if i := strings.IndexByte(sample, '\n'); i >= 0 {
+		sample = sample[:i]
+	}
+	best, bestCount := ',', 0
+	for _, d := range []rune{',', ';', '\t'} {
+		if n := strings.Count(sample, string(d)); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
+
+// Ensure RecordLoader implements the interface
+var _ usecase.DocumentLoader = (*RecordLoader)(nil)
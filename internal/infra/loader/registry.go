@@ -0,0 +1,126 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// Registry dispatches each file to the loader registered for its extension.
+// Files with an unknown extension are identified by sniffing their content
+// type. Registry itself implements usecase.DocumentLoader, so it can be given
+// to IngestionUseCase to ingest directories with mixed document types.
+type Registry struct {
+	byExt  map[string]usecase.DocumentLoader
+	byMIME map[string]usecase.DocumentLoader
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		byExt:  make(map[string]usecase.DocumentLoader),
+		byMIME: make(map[string]usecase.DocumentLoader),
+	}
+}
+
+// NewDefaultRegistry returns a registry with the built-in loaders for PDF,
+// Markdown, plain text, HTML, Office (DOCX, XLSX, PPTX) and record (CSV, TSV,
+// JSON Lines) files. Office files are zip containers, so they are only
+// recognized by extension. recordOpts configure the record loader.
+func NewDefaultRegistry(recordOpts ...RecordOption) *Registry {
+	r := NewRegistry()
+	r.Register(NewPDFLoader(), []string{".pdf"}, []string{"application/pdf"})
+	r.Register(NewMarkdownLoader(), []string{".md", ".markdown"}, nil)
+	r.Register(NewTextLoader(), []string{".txt", ".text"}, []string{"text/plain"})
+	r.Register(NewHTMLLoader(), []string{".html", ".htm"}, []string{"text/html"})
+	r.Register(NewDOCXLoader(), []string{".docx"}, nil)
+	r.Register(NewXLSXLoader(), []string{".xlsx"}, nil)
+	r.Register(NewPPTXLoader(), []string{".pptx"}, nil)
+	r.Register(NewRecordLoader(recordOpts...), []string{".csv", ".tsv", ".jsonl", ".ndjson"}, []string{"text/csv"})
+	return r
+}
+
+// Register associates a loader with file extensions (e.g. ".md") and with
+// sniffed MIME types (e.g. "text/html"), replacing previous registrations.
+func (r *Registry) Register(l usecase.DocumentLoader, exts, mimeTypes []string) {
+	for _, ext := range exts {
+		r.byExt[strings.ToLower(ext)] = l
+	}
+	for _, mt := range mimeTypes {
+		r.byMIME[mt] = l
+	}
+}
+
+// Extensions returns the registered file extensions, sorted.
+func (r *Registry) Extensions() []string {
+	exts := make([]string, 0, len(r.byExt))
+	for ext := range r.byExt {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// Supports reports whether a loader is registered for the file.
+func (r *Registry) Supports(source string) bool {
+	_, err := r.loaderFor(source)
+	return err == nil
+}
+
+func (r *Registry) Load(ctx context.Context, source string) ([]schema.Document, error) {
+	l, err := r.loaderFor(source)
+	if err != nil {
+		return nil, err
+	}
+	return l.Load(ctx, source)
+}
+
+// loaderFor finds the loader by extension, falling back to content sniffing.
+func (r *Registry) loaderFor(source string) (usecase.DocumentLoader, error) {
+	ext := strings.ToLower(filepath.Ext(source))
+	if l, ok := r.byExt[ext]; ok {
+		return l, nil
+	}
+
+	mt, err := sniffContentType(source)
+	if err != nil {
+		return nil, err
+	}
+	if l, ok := r.byMIME[mt]; ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("unsupported document type for '%s' (extension %q, content type %q)", source, ext, mt)
+}
+
+// sniffContentType detects the MIME type of a file from its first 512 bytes,
+// without parameters such as the charset.
+func sniffContentType(source string) (string, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file '%s': %w", source, err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read file '%s': %w", source, err)
+	}
+
+	mt := http.DetectContentType(buf[:n])
+	if i := strings.Index(mt, ";"); i >= 0 {
+		mt = mt[:i]
+	}
+	return mt, nil
+}
+
+// Ensure Registry implements the interface
+var _ usecase.DocumentLoader = (*Registry)(nil)
@@ -0,0 +1,50 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// TextLoader loads plain text files (.txt) as a single document.
+type TextLoader struct{}
+
+func NewTextLoader() *TextLoader {
+	return &TextLoader{}
+}
+
+func (l *TextLoader) Load(ctx context.Context, source string) ([]schema.Document, error) {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text file '%s': %w", source, err)
+	}
+
+	content := normalizeText(string(data))
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("text file '%s' is empty", source)
+	}
+
+	return []schema.Document{{
+		PageContent: content,
+		Metadata: map[string]interface{}{
+			"source": source,
+			"format": "text",
+		},
+	}}, nil
+}
+
+// normalizeText converts line endings to "\n", drops a UTF-8 byte order mark
+// and replaces invalid UTF-8 sequences.
+func normalizeText(s string) string {
+	s = strings.TrimPrefix(s, "\uFEFF")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
+
+// Ensure TextLoader implements the interface
+var _ usecase.DocumentLoader = (*TextLoader)(nil)
@@ -0,0 +1,220 @@
+package loader
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// XLSXLoader loads Excel workbooks (.xlsx), returning one document per
+// non-empty sheet. Each row becomes a line with its cells separated by " | ".
+// The sheet name and position are kept as metadata.
+type XLSXLoader struct{}
+
+func NewXLSXLoader() *XLSXLoader {
+	return &XLSXLoader{}
+}
+
+func (l *XLSXLoader) Load(ctx context.Context, source string) ([]schema.Document, error) {
+	pkg, err := openOOXML(source)
+	if err != nil {
+		return nil, err
+	}
+	defer pkg.Close()
+
+	const workbookPart = "xl/workbook.xml"
+	var workbook struct {
+		Sheets []struct {
+			Name string     `xml:"name,attr"`
+			Attr []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := pkg.decode(workbookPart, &workbook); err != nil {
+		return nil, err
+	}
+	rels, err := pkg.relationships(workbookPart)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := xlsxSharedStrings(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []schema.Document
+	for i, sheet := range workbook.Sheets {
+		var relID string
+		for _, a := range sheet.Attr {
+			if a.Name.Local == "id" && a.Name.Space != "" {
+				relID = a.Value
+			}
+		}
+		part, ok := rels[relID]
+		if !ok {
+			return nil, fmt.Errorf("sheet '%s' of Excel workbook '%s' has no data part", sheet.Name, source)
+		}
+
+		rows, err := xlsxRows(pkg, part, shared)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		docs = append(docs, schema.Document{
+			PageContent: strings.Join(rows, "\n"),
+			Metadata: map[string]interface{}{
+				"source":       source,
+				"format":       "xlsx",
+				"sheet":        sheet.Name,
+				"sheet_index":  i + 1,
+				"total_sheets": len(workbook.Sheets),
+				"rows":         len(rows),
+			},
+		})
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("Excel workbook '%s' has no cell content", source)
+	}
+	return docs, nil
+}
+
+// xlsxSharedStrings returns the shared string table; workbooks without
+// string cells have none.
+func xlsxSharedStrings(pkg *ooxmlPackage) ([]string, error) {
+	const part = "xl/sharedStrings.xml"
+	if !pkg.has(part) {
+		return nil, nil
+	}
+
+	rc, err := pkg.open(part)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var strs []string
+	var si strings.Builder
+	inText := false
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse '%s' in Excel workbook '%s': %w", part, pkg.source, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				si.Reset()
+			case "t":
+				inText = true
+			case "rPh":
+				// Phonetic hints are not part of the string
+				dec.Skip()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "si":
+				strs = append(strs, si.String())
+			}
+		case xml.CharData:
+			if inText {
+				si.Write(t)
+			}
+		}
+	}
+	return strs, nil
+}
+
+// xlsxRows streams a worksheet part and returns its non-empty rows.
+func xlsxRows(pkg *ooxmlPackage, part string, shared []string) ([]string, error) {
+	rc, err := pkg.open(part)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var rows, cells []string
+	var value strings.Builder
+	var cellType string
+	inValue := false
+
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse '%s' in Excel workbook '%s': %w", part, pkg.source, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				cells = cells[:0]
+			case "c":
+				cellType = attr(t, "t")
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				if text := strings.TrimSpace(xlsxCellText(cellType, value.String(), shared)); text != "" {
+					cells = append(cells, text)
+				}
+			case "row":
+				if len(cells) > 0 {
+					rows = append(rows, strings.Join(cells, " | "))
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// xlsxCellText resolves the display text of a cell from its type and raw value.
+func xlsxCellText(cellType, raw string, shared []string) string {
+	switch cellType {
+	case "s":
+		idx, err := strconv.Atoi(raw)
+		if err != nil || idx < 0 || idx >= len(shared) {
+			return ""
+		}
+		return shared[idx]
+	case "b":
+		if raw == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		// Numbers, formulas results ("str"), inline strings and errors
+		return raw
+	}
+}
+
+// Ensure XLSXLoader implements the interface
+var _ usecase.DocumentLoader = (*XLSXLoader)(nil)
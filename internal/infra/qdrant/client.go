@@ -0,0 +1,94 @@
+// Package qdrant is a client of the Qdrant REST API, shared by the vector
+// store adapter and the administration commands. It only encodes requests
+// and decodes responses; the layout of the points is left to the callers.
+package qdrant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Client sends requests to a Qdrant server.
+type Client struct {
+	baseURL string
+	http    *http.Client
+
+	mu      sync.Mutex
+	version string // Server version, once requested by ServerVersion
+}
+
+// NewClient returns a client of the Qdrant server at baseURL, e.g.
+// "http://localhost:6333".
+func NewClient(baseURL string) (*Client, error) {
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid Qdrant base URL: %w", err)
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), http: &http.Client{}}, nil
+}
+
+// BaseURL returns the URL of the server.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// collectionPath returns the path of a collection, followed by the
+// elements of suffix.
+func collectionPath(collectionName string, suffix ...string) string {
+	return "/collections/" + url.PathEscape(collectionName) + strings.Join(suffix, "")
+}
+
+// do sends a request to path, with body encoded as JSON when not nil, and
+// decodes the "result" of the response into result when not nil. what
+// describes the operation in errors, e.g. "delete collection 'docs'".
+// Statuses other than 200 fail, except those in accept, which are returned
+// without decoding the response.
+func (c *Client) do(ctx context.Context, what, method, path string, body, result interface{}, accept ...int) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal %s request: %w", what, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s request: %w", what, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute %s request: %w", what, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if slices.Contains(accept, resp.StatusCode) {
+			return resp.StatusCode, nil
+		}
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("failed to %s, status: %d, response: %s", what, resp.StatusCode, string(bodyBytes))
+	}
+
+	if result != nil {
+		envelope := struct {
+			Result interface{} `json:"result"`
+		}{Result: result}
+		if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode %s response: %w", what, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
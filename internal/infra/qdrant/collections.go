@@ -0,0 +1,125 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+type CreateCollectionRequest struct {
+	Vectors       map[string]VectorParams       `json:"vectors"`
+	SparseVectors map[string]SparseVectorParams `json:"sparse_vectors,omitempty"`
+	Metadata      map[string]interface{}        `json:"metadata,omitempty"` // Requires Qdrant 1.16+
+}
+
+type VectorParams struct {
+	Size     int    `json:"size"`
+	Distance string `json:"distance"` // e.g., "Cosine", "Euclid", "Dot"
+}
+
+// SparseVectorParams configures a sparse vector. The weights are computed by
+// the callers, so Qdrant applies no modifier.
+type SparseVectorParams struct{}
+
+// CollectionInfo is the description of a collection returned by GetCollection.
+type CollectionInfo struct {
+	Config struct {
+		Params struct {
+			// A single unnamed VectorParams or VectorParams by name, see NamedVectors
+			Vectors       json.RawMessage            `json:"vectors"`
+			SparseVectors map[string]json.RawMessage `json:"sparse_vectors"`
+		} `json:"params"`
+		Metadata map[string]interface{} `json:"metadata"`
+	} `json:"config"`
+	PayloadSchema map[string]PayloadSchemaInfo `json:"payload_schema"`
+	PointsCount   int                          `json:"points_count"`
+}
+
+// PayloadSchemaInfo describes the index of a payload field.
+type PayloadSchemaInfo struct {
+	DataType string `json:"data_type"`
+	Points   int    `json:"points"`
+}
+
+// NamedVectors returns the parameters of the named vectors of the
+// collection, nil when it has a single unnamed vector.
+func (info *CollectionInfo) NamedVectors() map[string]VectorParams {
+	var vectors map[string]VectorParams
+	if err := json.Unmarshal(info.Config.Params.Vectors, &vectors); err != nil {
+		return nil
+	}
+	if _, unnamed := vectors["size"]; unnamed {
+		return nil
+	}
+	return vectors
+}
+
+// HasSparseVector reports whether the collection has the named sparse vector.
+func (info *CollectionInfo) HasSparseVector(name string) bool {
+	_, ok := info.Config.Params.SparseVectors[name]
+	return ok
+}
+
+// GetCollection returns the description of a collection; exists is false
+// when it does not exist.
+func (c *Client) GetCollection(ctx context.Context, collectionName string) (info *CollectionInfo, exists bool, err error) {
+	info = &CollectionInfo{}
+	status, err := c.do(ctx, fmt.Sprintf("get collection '%s'", collectionName), http.MethodGet, collectionPath(collectionName), nil, info, http.StatusNotFound)
+	if err != nil || status == http.StatusNotFound {
+		return nil, false, err
+	}
+	return info, true, nil
+}
+
+// CreateCollection creates a collection.
+func (c *Client) CreateCollection(ctx context.Context, collectionName string, createReq CreateCollectionRequest) error {
+	_, err := c.do(ctx, fmt.Sprintf("create collection '%s'", collectionName), http.MethodPut, collectionPath(collectionName), createReq, nil)
+	return err
+}
+
+// DeleteCollection deletes a collection; existed is false when there was
+// none to delete.
+func (c *Client) DeleteCollection(ctx context.Context, collectionName string) (existed bool, err error) {
+	status, err := c.do(ctx, fmt.Sprintf("delete collection '%s'", collectionName), http.MethodDelete, collectionPath(collectionName), nil, nil, http.StatusNotFound)
+	return err == nil && status == http.StatusOK, err
+}
+
+// ListCollections returns the names of the collections.
+func (c *Client) ListCollections(ctx context.Context) ([]string, error) {
+	var result struct {
+		Collections []struct {
+			Name string `json:"name"`
+		} `json:"collections"`
+	}
+	if _, err := c.do(ctx, "list collections", http.MethodGet, "/collections", nil, &result); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(result.Collections))
+	for _, coll := range result.Collections {
+		names = append(names, coll.Name)
+	}
+	return names, nil
+}
+
+type CreateIndexRequest struct {
+	FieldName   string      `json:"field_name"`
+	FieldSchema interface{} `json:"field_schema"` // Type name or parameters object
+}
+
+// CreatePayloadIndex indexes a payload field, waiting for the existing
+// points to be indexed.
+func (c *Client) CreatePayloadIndex(ctx context.Context, collectionName string, createReq CreateIndexRequest) error {
+	what := fmt.Sprintf("create index on '%s' in collection '%s'", createReq.FieldName, collectionName)
+	_, err := c.do(ctx, what, http.MethodPut, collectionPath(collectionName, "/index?wait=true"), createReq, nil)
+	return err
+}
+
+// DeletePayloadIndex drops the index of a payload field. Deleting a missing
+// index is not an error.
+func (c *Client) DeletePayloadIndex(ctx context.Context, collectionName string, field string) error {
+	what := fmt.Sprintf("delete index on '%s' in collection '%s'", field, collectionName)
+	_, err := c.do(ctx, what, http.MethodDelete, collectionPath(collectionName, "/index/", url.PathEscape(field), "?wait=true"), nil, nil, http.StatusNotFound)
+	return err
+}
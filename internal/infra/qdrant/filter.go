@@ -0,0 +1,41 @@
+package qdrant
+
+// Filter is a Qdrant filter. It is also a condition of an enclosing filter
+// (see FieldCondition).
+type Filter struct {
+	Must    []FieldCondition `json:"must,omitempty"`
+	Should  []FieldCondition `json:"should,omitempty"`
+	MustNot []FieldCondition `json:"must_not,omitempty"`
+}
+
+// FieldCondition is one condition of a Qdrant filter: a payload field
+// matched (Match) or bounded (Range), an is_empty test or a nested filter.
+type FieldCondition struct {
+	Key     string        `json:"key,omitempty"`
+	Match   *MatchValue   `json:"match,omitempty"`
+	Range   *RangeValue   `json:"range,omitempty"`
+	IsEmpty *PayloadField `json:"is_empty,omitempty"`
+	*Filter
+}
+
+type MatchValue struct {
+	Value interface{}   `json:"value,omitempty"`
+	Any   []interface{} `json:"any,omitempty"`
+}
+
+// RangeValue bounds a numeric field, or a datetime field with RFC 3339 strings.
+type RangeValue struct {
+	Gt  interface{} `json:"gt,omitempty"`
+	Gte interface{} `json:"gte,omitempty"`
+	Lt  interface{} `json:"lt,omitempty"`
+	Lte interface{} `json:"lte,omitempty"`
+}
+
+type PayloadField struct {
+	Key string `json:"key"`
+}
+
+// MatchKey returns a filter keeping the points whose field key equals value.
+func MatchKey(key string, value interface{}) Filter {
+	return Filter{Must: []FieldCondition{{Key: key, Match: &MatchValue{Value: value}}}}
+}
@@ -0,0 +1,120 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type Point struct {
+	ID      interface{}            `json:"id"`     // UUID string or unsigned integer
+	Vector  map[string]interface{} `json:"vector"` // Dense ([]float32) and sparse (SparseVector) vectors by name
+	Payload map[string]interface{} `json:"payload"`
+}
+
+type SparseVector struct {
+	Indices []uint32  `json:"indices"`
+	Values  []float32 `json:"values"`
+}
+
+type SearchRequest struct {
+	Vector      interface{} `json:"vector"` // NamedVector or NamedSparseVector
+	Filter      *Filter     `json:"filter,omitempty"`
+	Limit       int         `json:"limit"`
+	WithPayload bool        `json:"with_payload"`
+	WithVector  bool        `json:"with_vector"`
+}
+
+type NamedVector struct {
+	Name   string    `json:"name"`
+	Vector []float32 `json:"vector"`
+}
+
+type NamedSparseVector struct {
+	Name   string       `json:"name"`
+	Vector SparseVector `json:"vector"`
+}
+
+type ScoredPoint struct {
+	ID      interface{}            `json:"id"`
+	Version int                    `json:"version"`
+	Score   float64                `json:"score"`
+	Payload map[string]interface{} `json:"payload"`
+	Vector  interface{}            `json:"vector"` // Can be map[string][]float32 or []float32 depending on request
+}
+
+type ScrollRequest struct {
+	Limit       int         `json:"limit"`
+	Offset      interface{} `json:"offset,omitempty"`
+	Filter      *Filter     `json:"filter,omitempty"`
+	WithPayload interface{} `json:"with_payload"` // bool or {"include": [...]}
+	WithVector  bool        `json:"with_vector"`
+}
+
+type ScrollResult struct {
+	Points         []Record    `json:"points"`
+	NextPageOffset interface{} `json:"next_page_offset"` // nil on the last page
+}
+
+type Record struct {
+	ID      interface{}            `json:"id"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// UpdateOperation is one operation of a batch update; only payload
+// overwrites are used.
+type UpdateOperation struct {
+	OverwritePayload *OverwritePayload `json:"overwrite_payload,omitempty"`
+}
+
+// OverwritePayload replaces the whole payload of the points, keeping their vectors.
+type OverwritePayload struct {
+	Payload map[string]interface{} `json:"payload"`
+	Points  []interface{}          `json:"points"`
+}
+
+// Upsert inserts or replaces points, waiting for the operation to complete.
+func (c *Client) Upsert(ctx context.Context, collectionName string, points []Point) error {
+	body := struct {
+		Points []Point `json:"points"`
+	}{Points: points}
+	_, err := c.do(ctx, fmt.Sprintf("upsert points to collection '%s'", collectionName), http.MethodPut, collectionPath(collectionName, "/points?wait=true"), body, nil)
+	return err
+}
+
+// Search returns the points closest to the vector of the request, best first.
+func (c *Client) Search(ctx context.Context, collectionName string, searchReq SearchRequest) ([]ScoredPoint, error) {
+	var result []ScoredPoint
+	if _, err := c.do(ctx, fmt.Sprintf("search collection '%s'", collectionName), http.MethodPost, collectionPath(collectionName, "/points/search"), searchReq, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Scroll returns a page of points; the next one starts at NextPageOffset.
+func (c *Client) Scroll(ctx context.Context, collectionName string, scrollReq ScrollRequest) (*ScrollResult, error) {
+	result := &ScrollResult{}
+	if _, err := c.do(ctx, fmt.Sprintf("scroll collection '%s'", collectionName), http.MethodPost, collectionPath(collectionName, "/points/scroll"), scrollReq, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeletePoints removes the points matching filter, waiting for the
+// operation to complete.
+func (c *Client) DeletePoints(ctx context.Context, collectionName string, filter Filter) error {
+	body := struct {
+		Filter Filter `json:"filter"`
+	}{Filter: filter}
+	_, err := c.do(ctx, fmt.Sprintf("delete points from collection '%s'", collectionName), http.MethodPost, collectionPath(collectionName, "/points/delete?wait=true"), body, nil)
+	return err
+}
+
+// UpdateBatch applies operations in order, waiting for them to complete.
+func (c *Client) UpdateBatch(ctx context.Context, collectionName string, operations []UpdateOperation) error {
+	body := struct {
+		Operations []UpdateOperation `json:"operations"`
+	}{Operations: operations}
+	_, err := c.do(ctx, fmt.Sprintf("update points of collection '%s'", collectionName), http.MethodPost, collectionPath(collectionName, "/points/batch?wait=true"), body, nil)
+	return err
+}
@@ -0,0 +1,88 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// collectionMetadataVersion is the first Qdrant version accepting collection
+// metadata; older versions reject a create request carrying it.
+var collectionMetadataVersion = [2]int{1, 16}
+
+// ServerVersion returns the version reported by the root endpoint of the
+// server, e.g. "1.15.4". It is requested once and remembered.
+func (c *Client) ServerVersion(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != "" {
+		return c.version, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create server version request: %w", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute server version request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to get server version, status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+	// The root endpoint is not wrapped in a "result" envelope
+	var info struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", fmt.Errorf("failed to decode server version response: %w", err)
+	}
+	if info.Version == "" {
+		return "", fmt.Errorf("failed to get server version: response has no version")
+	}
+	c.version = info.Version
+	return c.version, nil
+}
+
+// SupportsCollectionMetadata reports whether the server accepts metadata in
+// CreateCollectionRequest, which Qdrant 1.16 introduced. Versions that cannot
+// be parsed, such as development builds, are assumed to support it.
+func (c *Client) SupportsCollectionMetadata(ctx context.Context) (bool, error) {
+	version, err := c.ServerVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	major, minor, ok := parseVersion(version)
+	if !ok {
+		return true, nil
+	}
+	if major != collectionMetadataVersion[0] {
+		return major > collectionMetadataVersion[0], nil
+	}
+	return minor >= collectionMetadataVersion[1], nil
+}
+
+// parseVersion returns the major and minor numbers of a version such as
+// "1.15.4" or "v1.16.0-rc1".
+func parseVersion(version string) (major, minor int, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
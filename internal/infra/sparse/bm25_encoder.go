@@ -0,0 +1,333 @@
+// Package sparse encodes texts as sparse BM25 vectors for hybrid search.
+package sparse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// BM25 parameters: k1 saturates the term frequency, b normalizes it by the
+// chunk length.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// BM25Encoder implements the usecase.SparseEncoder interface with BM25.
+//
+// Its vocabulary, kept in a JSON file, assigns stable ids to the terms and
+// counts the chunks containing each of them, per collection and source so
+// that re-ingested and deleted files can be taken out exactly. Document
+// vectors hold the length-normalized, saturated term frequencies and query
+// vectors the inverse document frequencies, so that their dot product is
+// the BM25 score. Document frequencies are shared by all collections,
+// keeping scores comparable across them.
+//
+// The vocabulary is read on first use and again whenever another process
+// rewrote the file, unless there are unsaved changes.
+type BM25Encoder struct {
+	path string
+
+	mu      sync.Mutex
+	vocab   *vocabulary
+	modTime time.Time // Of the file when last read or written
+	dirty   bool
+}
+
+// NewBM25Encoder returns an encoder whose vocabulary is stored in path.
+func NewBM25Encoder(path string) *BM25Encoder {
+	return &BM25Encoder{path: path}
+}
+
+// vocabulary is the persistent state of the encoder.
+type vocabulary struct {
+	Terms map[string]uint32 `json:"terms"`
+	// Collections holds the contribution of every source of each collection.
+	Collections map[string]map[string]*sourceStats `json:"collections"`
+
+	// Totals over all collections, derived on load
+	docFreq     map[uint32]int
+	docs        int
+	totalLength int
+}
+
+// sourceStats is the contribution of the chunks of one source.
+type sourceStats struct {
+	Chunks int            `json:"chunks"`
+	Length int            `json:"length"` // Terms in all chunks
+	Terms  map[uint32]int `json:"terms"`  // Chunks containing each term
+}
+
+func newVocabulary() *vocabulary {
+	return &vocabulary{
+		Terms:       make(map[string]uint32),
+		Collections: make(map[string]map[string]*sourceStats),
+		docFreq:     make(map[uint32]int),
+	}
+}
+
+// add counts (sign 1) or discounts (sign -1) the contribution of a source
+// in the totals.
+func (v *vocabulary) add(stats *sourceStats, sign int) {
+	v.docs += sign * stats.Chunks
+	v.totalLength += sign * stats.Length
+	for id, n := range stats.Terms {
+		v.docFreq[id] += sign * n
+		if v.docFreq[id] <= 0 {
+			delete(v.docFreq, id)
+		}
+	}
+}
+
+// termID returns the id of term, assigning the next one to new terms.
+func (v *vocabulary) termID(term string) uint32 {
+	id, ok := v.Terms[term]
+	if !ok {
+		id = uint32(len(v.Terms))
+		v.Terms[term] = id
+	}
+	return id
+}
+
+// idf is the BM25 inverse document frequency of a term found in df chunks.
+func (v *vocabulary) idf(df int) float64 {
+	return math.Log(1 + (float64(v.docs)-float64(df)+0.5)/(float64(df)+0.5))
+}
+
+// EncodeDocuments adds the documents to the vocabulary of the collection,
+// under their "source" metadata, and returns their BM25 vectors.
+func (e *BM25Encoder) EncodeDocuments(ctx context.Context, collectionName string, docs []schema.Document) ([]usecase.SparseVector, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+	v := e.vocab
+
+	sources := v.Collections[collectionName]
+	if sources == nil {
+		sources = make(map[string]*sourceStats)
+		v.Collections[collectionName] = sources
+	}
+
+	freqs := make([]map[uint32]int, len(docs))
+	lengths := make([]int, len(docs))
+	for i, doc := range docs {
+		terms := Tokenize(doc.PageContent)
+		freqs[i] = make(map[uint32]int, len(terms))
+		for _, term := range terms {
+			freqs[i][v.termID(term)]++
+		}
+		lengths[i] = len(terms)
+
+		source, _ := doc.Metadata[usecase.MetadataSource].(string)
+		stats := sources[source]
+		if stats == nil {
+			stats = &sourceStats{}
+			sources[source] = stats
+		}
+		if stats.Terms == nil {
+			stats.Terms = make(map[uint32]int)
+		}
+		added := &sourceStats{Chunks: 1, Length: lengths[i], Terms: make(map[uint32]int, len(freqs[i]))}
+		for id := range freqs[i] {
+			added.Terms[id] = 1
+			stats.Terms[id]++
+		}
+		stats.Chunks++
+		stats.Length += lengths[i]
+		v.add(added, 1)
+	}
+	e.dirty = true
+
+	avgLength := float64(v.totalLength) / float64(max(v.docs, 1))
+	vectors := make([]usecase.SparseVector, len(docs))
+	for i, freq := range freqs {
+		norm := bm25K1 * (1 - bm25B + bm25B*float64(lengths[i])/max(avgLength, 1))
+		weights := make(map[uint32]float32, len(freq))
+		for id, tf := range freq {
+			weights[id] = float32(float64(tf) * (bm25K1 + 1) / (float64(tf) + norm))
+		}
+		vectors[i] = sortedVector(weights)
+	}
+	return vectors, nil
+}
+
+// EncodeQuery returns the vector of the query terms found in the
+// vocabulary, weighted by their inverse document frequency. It is empty when
+// no term is known.
+func (e *BM25Encoder) EncodeQuery(ctx context.Context, query string) (usecase.SparseVector, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.load(); err != nil {
+		return usecase.SparseVector{}, err
+	}
+	v := e.vocab
+
+	weights := make(map[uint32]float32)
+	for _, term := range Tokenize(query) {
+		id, ok := v.Terms[term]
+		if !ok || v.docFreq[id] == 0 {
+			continue
+		}
+		weights[id] = float32(v.idf(v.docFreq[id]))
+	}
+	return sortedVector(weights), nil
+}
+
+// RemoveSource takes the chunks of source out of the vocabulary of the collection.
+func (e *BM25Encoder) RemoveSource(ctx context.Context, collectionName string, source string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.load(); err != nil {
+		return err
+	}
+	v := e.vocab
+
+	stats, ok := v.Collections[collectionName][source]
+	if !ok {
+		return nil
+	}
+	v.add(stats, -1)
+	delete(v.Collections[collectionName], source)
+	e.dirty = true
+	return nil
+}
+
+// DeleteCollection takes every chunk of the collection out of the vocabulary.
+func (e *BM25Encoder) DeleteCollection(ctx context.Context, collectionName string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.load(); err != nil {
+		return err
+	}
+	v := e.vocab
+
+	sources, ok := v.Collections[collectionName]
+	if !ok {
+		return nil
+	}
+	for _, stats := range sources {
+		v.add(stats, -1)
+	}
+	delete(v.Collections, collectionName)
+	e.dirty = true
+	return nil
+}
+
+// Save writes the vocabulary if it changed, replacing the file atomically.
+func (e *BM25Encoder) Save(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(e.vocab)
+	if err != nil {
+		return fmt.Errorf("failed to encode sparse vocabulary: %w", err)
+	}
+	dir := filepath.Dir(e.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create sparse vocabulary directory '%s': %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(e.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save sparse vocabulary: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save sparse vocabulary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save sparse vocabulary: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), e.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save sparse vocabulary: %w", err)
+	}
+
+	if info, err := os.Stat(e.path); err == nil {
+		e.modTime = info.ModTime()
+	}
+	e.dirty = false
+	return nil
+}
+
+// load reads the vocabulary file on first use or when it was modified since.
+// A missing file is an empty vocabulary. e.mu must be held.
+func (e *BM25Encoder) load() error {
+	if e.dirty {
+		return nil
+	}
+	info, err := os.Stat(e.path)
+	if errors.Is(err, os.ErrNotExist) {
+		if e.vocab == nil {
+			e.vocab = newVocabulary()
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read sparse vocabulary '%s': %w", e.path, err)
+	}
+	if e.vocab != nil && info.ModTime().Equal(e.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return fmt.Errorf("failed to read sparse vocabulary '%s': %w", e.path, err)
+	}
+	v := newVocabulary()
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode sparse vocabulary '%s': %w", e.path, err)
+	}
+	if v.Terms == nil {
+		v.Terms = make(map[string]uint32)
+	}
+	if v.Collections == nil {
+		v.Collections = make(map[string]map[string]*sourceStats)
+	}
+	for _, sources := range v.Collections {
+		for _, stats := range sources {
+			v.add(stats, 1)
+		}
+	}
+
+	e.vocab = v
+	e.modTime = info.ModTime()
+	return nil
+}
+
+// sortedVector returns the weights as a vector sorted by term id.
+func sortedVector(weights map[uint32]float32) usecase.SparseVector {
+	v := usecase.SparseVector{
+		Indices: make([]uint32, 0, len(weights)),
+		Values:  make([]float32, 0, len(weights)),
+	}
+	for id := range weights {
+		v.Indices = append(v.Indices, id)
+	}
+	sort.Slice(v.Indices, func(i, j int) bool { return v.Indices[i] < v.Indices[j] })
+	for _, id := range v.Indices {
+		v.Values = append(v.Values, weights[id])
+	}
+	return v
+}
+
+// Ensure BM25Encoder implements the interface
+var _ usecase.SparseEncoder = (*BM25Encoder)(nil)
@@ -0,0 +1,248 @@
+package sparse
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func doc(source, text string) schema.Document {
+	return schema.Document{PageContent: text, Metadata: map[string]interface{}{usecase.MetadataSource: source}}
+}
+
+// weight returns the weight of term in v, or 0 when absent.
+func weight(e *BM25Encoder, v usecase.SparseVector, term string) float32 {
+	id, ok := e.vocab.Terms[term]
+	if !ok {
+		return 0
+	}
+	for i, index := range v.Indices {
+		if index == id {
+			return v.Values[i]
+		}
+	}
+	return 0
+}
+
+// dot is the BM25 score of a document vector for a query vector.
+func dot(a, b usecase.SparseVector) float64 {
+	var sum float64
+	for i, ia := range a.Indices {
+		for j, ib := range b.Indices {
+			if ia == ib {
+				sum += float64(a.Values[i]) * float64(b.Values[j])
+			}
+		}
+	}
+	return sum
+}
+
+func newTestEncoder(t *testing.T) *BM25Encoder {
+	t.Helper()
+	return NewBM25Encoder(filepath.Join(t.TempDir(), "sparse", "vocabulary.json"))
+}
+
+func encode(t *testing.T, e *BM25Encoder, collection string, docs ...schema.Document) []usecase.SparseVector {
+	t.Helper()
+	vectors, err := e.EncodeDocuments(context.Background(), collection, docs)
+	if err != nil {
+		t.Fatalf("EncodeDocuments: %v", err)
+	}
+	return vectors
+}
+
+func encodeQuery(t *testing.T, e *BM25Encoder, query string) usecase.SparseVector {
+	t.Helper()
+	v, err := e.EncodeQuery(context.Background(), query)
+	if err != nil {
+		t.Fatalf("EncodeQuery: %v", err)
+	}
+	return v
+}
+
+func TestBM25DocumentFrequencies(t *testing.T) {
+	e := newTestEncoder(t)
+	encode(t, e, "docs",
+		doc("a.txt", "cats purr cats sleep"),
+		doc("a.txt", "dogs bark"),
+		doc("b.txt", "cats and parrots"),
+	)
+
+	v := e.vocab
+	if v.docs != 3 {
+		t.Errorf("docs = %d, want 3", v.docs)
+	}
+	if v.totalLength != 4+2+2 {
+		t.Errorf("totalLength = %d, want 8", v.totalLength)
+	}
+	// A term repeated in a chunk counts once in its document frequency
+	for term, want := range map[string]int{"cats": 2, "purr": 1, "dogs": 1, "parrots": 1} {
+		if got := v.docFreq[v.Terms[term]]; got != want {
+			t.Errorf("document frequency of %q = %d, want %d", term, got, want)
+		}
+	}
+	if _, ok := v.Terms["and"]; ok {
+		t.Error("the stop word \"and\" is in the vocabulary")
+	}
+	stats := v.Collections["docs"]["a.txt"]
+	if stats == nil || stats.Chunks != 2 || stats.Length != 6 || stats.Terms[v.Terms["cats"]] != 1 {
+		t.Errorf("stats of a.txt = %+v, want 2 chunks of 6 terms, cats in one", stats)
+	}
+}
+
+func TestBM25QueryWeights(t *testing.T) {
+	e := newTestEncoder(t)
+	encode(t, e, "docs",
+		doc("a.txt", "cats purr"),
+		doc("b.txt", "cats sleep"),
+		doc("c.txt", "cats hunt mice"),
+	)
+
+	q := encodeQuery(t, e, "Do cats hunt unicorns?")
+	if len(q.Indices) != 2 {
+		t.Fatalf("query vector = %+v, want the two known terms", q)
+	}
+	for i := 1; i < len(q.Indices); i++ {
+		if q.Indices[i-1] >= q.Indices[i] {
+			t.Errorf("indices %v are not sorted", q.Indices)
+		}
+	}
+	// idf = ln(1 + (N - df + 0.5) / (df + 0.5)) with N = 3
+	if got, want := float64(weight(e, q, "cats")), math.Log(1+0.5/3.5); math.Abs(got-want) > 1e-6 {
+		t.Errorf("weight of cats = %g, want %g", got, want)
+	}
+	if got, want := float64(weight(e, q, "hunt")), math.Log(1+2.5/1.5); math.Abs(got-want) > 1e-6 {
+		t.Errorf("weight of hunt = %g, want %g", got, want)
+	}
+
+	if q := encodeQuery(t, e, "unicorns"); !q.IsEmpty() {
+		t.Errorf("query of unknown terms = %+v, want empty", q)
+	}
+}
+
+func TestBM25DocumentWeights(t *testing.T) {
+	e := newTestEncoder(t)
+	vectors := encode(t, e, "docs",
+		doc("a.txt", "mice mice mice cats"),
+		doc("b.txt", "mice dogs"),
+	)
+
+	// Repeated terms weigh more, with diminishing returns
+	mice, cats := weight(e, vectors[0], "mice"), weight(e, vectors[0], "cats")
+	if mice <= cats || mice >= 3*cats {
+		t.Errorf("weights of mice (3 times) and cats (once) = %g and %g", mice, cats)
+	}
+	// Saturated term frequencies stay below k1 + 1
+	for _, v := range vectors {
+		for _, w := range v.Values {
+			if w <= 0 || w >= bm25K1+1 {
+				t.Errorf("weight %g is outside (0, k1+1)", w)
+			}
+		}
+	}
+	// The shorter chunk scores higher for a term found once in each
+	q := encodeQuery(t, e, "mice")
+	if dot(vectors[1], q) <= dot(vectors[0], q)/3 {
+		t.Errorf("scores for mice = %g and %g", dot(vectors[0], q), dot(vectors[1], q))
+	}
+}
+
+func TestBM25RemoveSource(t *testing.T) {
+	e := newTestEncoder(t)
+	ctx := context.Background()
+	encode(t, e, "docs", doc("a.txt", "cats purr"), doc("b.txt", "cats sleep"))
+	before := encodeQuery(t, e, "cats")
+
+	encode(t, e, "docs", doc("c.txt", "cats hunt"))
+	if err := e.RemoveSource(ctx, "docs", "c.txt"); err != nil {
+		t.Fatalf("RemoveSource: %v", err)
+	}
+	if after := encodeQuery(t, e, "cats"); after.Values[0] != before.Values[0] {
+		t.Errorf("weight of cats after adding and removing c.txt = %g, want %g", after.Values[0], before.Values[0])
+	}
+	if q := encodeQuery(t, e, "hunt"); !q.IsEmpty() {
+		t.Errorf("query of a removed term = %+v, want empty", q)
+	}
+	if e.vocab.docs != 2 || e.vocab.totalLength != 4 {
+		t.Errorf("totals = %d chunks of %d terms, want 2 of 4", e.vocab.docs, e.vocab.totalLength)
+	}
+
+	// Removing an unknown source changes nothing
+	if err := e.RemoveSource(ctx, "docs", "missing.txt"); err != nil {
+		t.Fatalf("RemoveSource of an unknown source: %v", err)
+	}
+	if err := e.RemoveSource(ctx, "other", "a.txt"); err != nil {
+		t.Fatalf("RemoveSource in an unknown collection: %v", err)
+	}
+	if e.vocab.docs != 2 {
+		t.Errorf("docs = %d after removing unknown sources, want 2", e.vocab.docs)
+	}
+}
+
+func TestBM25DeleteCollection(t *testing.T) {
+	e := newTestEncoder(t)
+	encode(t, e, "a", doc("a.txt", "cats purr"))
+	encode(t, e, "b", doc("b.txt", "cats sleep"), doc("c.txt", "dogs bark"))
+
+	// Document frequencies are shared by all collections
+	if df := e.vocab.docFreq[e.vocab.Terms["cats"]]; df != 2 {
+		t.Errorf("document frequency of cats = %d, want 2", df)
+	}
+
+	if err := e.DeleteCollection(context.Background(), "b"); err != nil {
+		t.Fatalf("DeleteCollection: %v", err)
+	}
+	if _, ok := e.vocab.Collections["b"]; ok {
+		t.Error("collection b is still in the vocabulary")
+	}
+	if df := e.vocab.docFreq[e.vocab.Terms["cats"]]; df != 1 {
+		t.Errorf("document frequency of cats = %d, want 1", df)
+	}
+	if q := encodeQuery(t, e, "dogs"); !q.IsEmpty() {
+		t.Errorf("query of a term of the deleted collection = %+v, want empty", q)
+	}
+	if e.vocab.docs != 1 {
+		t.Errorf("docs = %d, want 1", e.vocab.docs)
+	}
+}
+
+func TestBM25SaveAndReload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sparse", "vocabulary.json")
+	e := NewBM25Encoder(path)
+	encode(t, e, "docs", doc("a.txt", "cats purr"), doc("b.txt", "dogs bark"))
+	want := encodeQuery(t, e, "cats bark")
+	if err := e.Save(ctx); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded := NewBM25Encoder(path)
+	got := encodeQuery(t, reloaded, "cats bark")
+	if len(got.Indices) != 2 || got.Indices[0] != want.Indices[0] || got.Values[1] != want.Values[1] {
+		t.Errorf("query vector after reload = %+v, want %+v", got, want)
+	}
+
+	// Term ids stay stable, and the totals are derived again
+	vectors := encode(t, reloaded, "docs", doc("c.txt", "cats"))
+	if vectors[0].Indices[0] != e.vocab.Terms["cats"] {
+		t.Errorf("cats got id %d after reload, want %d", vectors[0].Indices[0], e.vocab.Terms["cats"])
+	}
+	if reloaded.vocab.docs != 3 || reloaded.vocab.docFreq[reloaded.vocab.Terms["cats"]] != 2 {
+		t.Errorf("after reload and one more chunk: %d chunks, cats in %d, want 3 and 2", reloaded.vocab.docs, reloaded.vocab.docFreq[reloaded.vocab.Terms["cats"]])
+	}
+}
+
+func TestBM25MissingVocabulary(t *testing.T) {
+	e := newTestEncoder(t)
+	if q := encodeQuery(t, e, "cats"); !q.IsEmpty() {
+		t.Errorf("query against an empty vocabulary = %+v", q)
+	}
+	// Nothing changed, so nothing is written
+	if err := e.Save(context.Background()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+}
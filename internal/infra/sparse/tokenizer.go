@@ -0,0 +1,131 @@
+package sparse
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxTermLength is the longest term kept, in characters; longer ones are
+// usually hashes or encoded data.
+const maxTermLength = 64
+
+// Tokenize splits text into lowercase terms for lexical matching.
+//
+// Words joined by "-", "_", "." or "/" form a compound term that is kept
+// whole, so that codes and identifiers such as "ERR-1234", "parse_config"
+// or "v1.2.3" match exactly, and also split into its parts ("err",
+// "1234"). Parts are further split at camelCase and letter/digit
+// boundaries ("parseConfig" gives "parse" and "config", "SKU42" gives
+// "sku" and "42"). Single-character parts and common English and
+// Portuguese stop words are dropped.
+func Tokenize(text string) []string {
+	var terms []string
+	for _, word := range splitWords(text) {
+		parts := wordParts(word)
+		if len(parts) == 1 {
+			term := strings.ToLower(parts[0])
+			if utf8.RuneCountInString(term) > 1 && !stopWords[term] {
+				terms = appendTerm(terms, term)
+			}
+			continue
+		}
+		terms = appendTerm(terms, strings.ToLower(word))
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) > 1 {
+				terms = appendTerm(terms, strings.ToLower(part))
+			}
+		}
+	}
+	return terms
+}
+
+func appendTerm(terms []string, term string) []string {
+	if utf8.RuneCountInString(term) > maxTermLength {
+		return terms
+	}
+	return append(terms, term)
+}
+
+// isConnector reports whether r joins the words of a compound term.
+func isConnector(r rune) bool {
+	return r == '-' || r == '_' || r == '.' || r == '/'
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// splitWords returns the runs of letters and digits of text, including the
+// connectors found between two of them.
+func splitWords(text string) []string {
+	var words []string
+	runes := []rune(text)
+	start := -1
+	for i, r := range runes {
+		switch {
+		case isWordRune(r):
+			if start < 0 {
+				start = i
+			}
+		case start >= 0 && isConnector(r) && i+1 < len(runes) && isWordRune(runes[i+1]):
+			// Part of the compound
+		case start >= 0:
+			words = append(words, string(runes[start:i]))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// wordParts splits a word at connectors, lower to upper case transitions
+// and letter/digit boundaries.
+func wordParts(word string) []string {
+	var parts []string
+	var current []rune
+	var prev rune
+	flush := func() {
+		if len(current) > 0 {
+			parts = append(parts, string(current))
+			current = current[:0]
+		}
+	}
+	for _, r := range word {
+		switch {
+		case isConnector(r):
+			flush()
+			prev = 0
+			continue
+		case len(current) > 0 && unicode.IsLower(prev) && unicode.IsUpper(r),
+			len(current) > 0 && unicode.IsLetter(prev) && unicode.IsDigit(r),
+			len(current) > 0 && unicode.IsDigit(prev) && unicode.IsLetter(r):
+			flush()
+		}
+		current = append(current, r)
+		prev = r
+	}
+	flush()
+	return parts
+}
+
+var stopWords = func() map[string]bool {
+	words := []string{
+		// English
+		"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from",
+		"has", "have", "if", "in", "into", "is", "it", "its", "of", "on", "or",
+		"that", "the", "their", "then", "there", "these", "this", "to", "was",
+		"were", "will", "with",
+		// Portuguese
+		"ao", "aos", "as", "com", "da", "das", "de", "do", "dos", "e", "em",
+		"na", "nas", "no", "nos", "o", "os", "ou", "para", "pela", "pelo",
+		"por", "que", "se", "um", "uma",
+	}
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}()
@@ -0,0 +1,44 @@
+package sparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"lowercase words", "Cats PURR loudly", []string{"cats", "purr", "loudly"}},
+		{"punctuation", "Hello, world! (again)", []string{"hello", "world", "again"}},
+		{"english stop words", "the cat is on the mat", []string{"cat", "mat"}},
+		{"portuguese stop words", "o gato da casa e um cão", []string{"gato", "casa", "cão"}},
+		{"single characters", "x y z ok", []string{"ok"}},
+		{"accents", "Ação rápida", []string{"ação", "rápida"}},
+		{"hyphenated code", "ERR-1234 occurred", []string{"err-1234", "err", "1234", "occurred"}},
+		{"snake case", "parse_config", []string{"parse_config", "parse", "config"}},
+		{"version", "v1.2.3", []string{"v1.2.3"}},
+		{"path", "internal/usecase", []string{"internal/usecase", "internal", "usecase"}},
+		{"camel case", "parseConfig", []string{"parseconfig", "parse", "config"}},
+		{"letters and digits", "SKU42", []string{"sku42", "sku", "42"}},
+		{"trailing connector", "end. next-", []string{"end", "next"}},
+		{"empty", "  ...  ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeDropsLongTerms(t *testing.T) {
+	hash := strings.Repeat("ab", maxTermLength)
+	if got := Tokenize("sha " + hash); !reflect.DeepEqual(got, []string{"sha"}) {
+		t.Errorf("Tokenize kept the %d-character term: %q", len(hash), got)
+	}
+}
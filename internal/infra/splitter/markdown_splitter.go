@@ -0,0 +1,423 @@
+package splitter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/textsplitter"
+)
+
+// MetadataBreadcrumb is the metadata key holding the headings enclosing a
+// chunk, e.g. "Install > Linux > Docker".
+const MetadataBreadcrumb = "breadcrumb"
+
+// breadcrumbSeparator joins the headings of a breadcrumb.
+const breadcrumbSeparator = " > "
+
+// SizedSplitter is a TextSplitter that exposes how it measures chunks, so
+// that other splitters can size chunks in the same unit.
+type SizedSplitter interface {
+	usecase.TextSplitter
+	ChunkSize() int
+	Len(text string) int
+}
+
+// MarkdownSplitter implements the usecase.TextSplitter interface for Markdown
+// and HTML documents, splitting them by heading hierarchy. Each section is
+// chunked on block boundaries (paragraphs, lists, fenced code, tables), so
+// code blocks and tables that fit in a chunk are never cut, and the heading
+// breadcrumb of the section is stored in every chunk's metadata.
+//
+// Chunks are sized like the base splitter (characters or tokens); documents
+// of other formats are split by the base splitter.
+type MarkdownSplitter struct {
+	base    SizedSplitter
+	prefix  bool
+	formats map[string]bool
+}
+
+// MarkdownOption configures a MarkdownSplitter.
+type MarkdownOption func(*MarkdownSplitter)
+
+// WithBreadcrumbPrefix prepends the breadcrumb to the text of each chunk, so
+// that it is part of the embedded text. The prefix counts towards the chunk size.
+func WithBreadcrumbPrefix(enabled bool) MarkdownOption {
+	return func(s *MarkdownSplitter) {
+		s.prefix = enabled
+	}
+}
+
+// NewMarkdownSplitter creates a splitter for documents whose "format"
+// metadata is "markdown" or "html", falling back to base for the others.
+func NewMarkdownSplitter(base SizedSplitter, opts ...MarkdownOption) *MarkdownSplitter {
+	s := &MarkdownSplitter{
+		base:    base,
+		formats: map[string]bool{"markdown": true, "html": true},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SplitDocuments splits Markdown and HTML documents by section and the other
+// documents with the base splitter, keeping the order of the documents.
+func (s *MarkdownSplitter) SplitDocuments(ctx context.Context, docs []schema.Document) ([]schema.Document, error) {
+	var splittedDocs []schema.Document
+	for _, doc := range docs {
+		format, _ := doc.Metadata["format"].(string)
+		if !s.formats[format] {
+			chunks, err := s.base.SplitDocuments(ctx, []schema.Document{doc})
+			if err != nil {
+				return nil, err
+			}
+			splittedDocs = append(splittedDocs, chunks...)
+			continue
+		}
+
+		// Documents already split by section (e.g. parent sections) hold at
+		// most their own heading and keep the breadcrumb they were given
+		inherited, _ := doc.Metadata[MetadataBreadcrumb].(string)
+		for _, section := range parseMarkdownSections(doc.PageContent) {
+			if inherited != "" {
+				section.breadcrumb = strings.Split(inherited, breadcrumbSeparator)
+			}
+			chunks, err := s.splitSection(section)
+			if err != nil {
+				return nil, err
+			}
+			breadcrumb := strings.Join(section.breadcrumb, breadcrumbSeparator)
+			for _, chunk := range chunks {
+				metadata := make(map[string]interface{}, len(doc.Metadata)+1)
+				for k, v := range doc.Metadata {
+					metadata[k] = v
+				}
+				if breadcrumb != "" {
+					metadata[MetadataBreadcrumb] = breadcrumb
+				}
+				splittedDocs = append(splittedDocs, schema.Document{
+					PageContent: chunk,
+					Metadata:    metadata,
+				})
+			}
+		}
+	}
+	return splittedDocs, nil
+}
+
+// splitSection packs the blocks of a section into chunks.
+func (s *MarkdownSplitter) splitSection(section mdSection) ([]string, error) {
+	prefix := ""
+	if s.prefix && len(section.breadcrumb) > 0 {
+		prefix = strings.Join(section.breadcrumb, breadcrumbSeparator) + "\n\n"
+	}
+	budget := s.base.ChunkSize() - s.base.Len(prefix)
+	if budget < s.base.ChunkSize()/2 {
+		// Very deep breadcrumbs are not worth halving the chunks
+		prefix, budget = "", s.base.ChunkSize()
+	}
+
+	var chunks []string
+	current := ""
+	flush := func() {
+		if strings.TrimSpace(current) != "" {
+			chunks = append(chunks, prefix+current)
+		}
+		current = ""
+	}
+
+	for _, block := range section.blocks {
+		if s.base.Len(block.text) > budget {
+			flush()
+			pieces, err := s.splitBlock(block, budget)
+			if err != nil {
+				return nil, err
+			}
+			for _, piece := range pieces {
+				chunks = append(chunks, prefix+piece)
+			}
+			continue
+		}
+		if current == "" {
+			current = block.text
+			continue
+		}
+		if candidate := current + "\n\n" + block.text; s.base.Len(candidate) <= budget {
+			current = candidate
+			continue
+		}
+		flush()
+		current = block.text
+	}
+	flush()
+	return chunks, nil
+}
+
+// splitBlock splits a block larger than a chunk. Code blocks and tables are
+// split between lines, repeating the fence or the header row in every piece;
+// text is split on paragraphs, lines and words.
+func (s *MarkdownSplitter) splitBlock(block mdBlock, budget int) ([]string, error) {
+	lines := strings.Split(block.text, "\n")
+	var head, tail []string
+	switch block.kind {
+	case mdCode:
+		head = lines[:1]
+		if len(lines) > 1 && isClosingFence(lines[len(lines)-1], lines[0]) {
+			tail = lines[len(lines)-1:]
+			lines = lines[1 : len(lines)-1]
+		} else {
+			lines = lines[1:]
+		}
+	case mdTable:
+		if len(lines) > 1 && isTableSeparator(lines[1]) {
+			head, lines = lines[:2], lines[2:]
+		}
+	default:
+		return s.splitText(block.text, budget)
+	}
+
+	wrap := func(body []string) string {
+		parts := append(append(append([]string{}, head...), body...), tail...)
+		return strings.Join(parts, "\n")
+	}
+
+	var pieces []string
+	var body []string
+	for _, line := range lines {
+		if s.base.Len(wrap([]string{line})) > budget {
+			// A single line does not fit: split it as plain text
+			if len(body) > 0 {
+				pieces = append(pieces, wrap(body))
+				body = nil
+			}
+			parts, err := s.splitText(line, budget)
+			if err != nil {
+				return nil, err
+			}
+			pieces = append(pieces, parts...)
+			continue
+		}
+		if s.base.Len(wrap(append(body, line))) > budget && len(body) > 0 {
+			pieces = append(pieces, wrap(body))
+			body = nil
+		}
+		body = append(body, line)
+	}
+	if len(body) > 0 {
+		pieces = append(pieces, wrap(body))
+	}
+	return pieces, nil
+}
+
+// splitText splits text recursively on paragraphs, lines and words into
+// pieces of at most budget, measured like the base splitter.
+func (s *MarkdownSplitter) splitText(text string, budget int) ([]string, error) {
+	rc := textsplitter.NewRecursiveCharacter(
+		textsplitter.WithChunkSize(budget),
+		textsplitter.WithChunkOverlap(budget/10),
+		textsplitter.WithLenFunc(s.base.Len),
+	)
+	pieces, err := rc.SplitText(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split section text: %w", err)
+	}
+	return pieces, nil
+}
+
+type mdBlockKind int
+
+const (
+	mdText mdBlockKind = iota
+	mdHeading
+	mdCode
+	mdTable
+)
+
+// mdBlock is a unit of Markdown that chunks are not split inside of, unless
+// it is larger than a chunk.
+type mdBlock struct {
+	kind mdBlockKind
+	text string
+}
+
+// mdSection holds the blocks under a heading, starting with the heading itself.
+type mdSection struct {
+	breadcrumb []string
+	blocks     []mdBlock
+}
+
+// parseMarkdownSections splits Markdown text into sections at ATX ("## X")
+// and setext headings outside fenced code. Sections holding only their
+// heading are dropped; the heading lives on in the breadcrumb of its
+// subsections.
+func parseMarkdownSections(text string) []mdSection {
+	var sections []mdSection
+	var headings [6]string
+	current := mdSection{}
+
+	var para []string
+	paraKind := mdText
+	flushPara := func() {
+		if len(para) > 0 {
+			current.blocks = append(current.blocks, mdBlock{kind: paraKind, text: strings.Join(para, "\n")})
+		}
+		para, paraKind = nil, mdText
+	}
+	startSection := func(level int, title, line string) {
+		flushPara()
+		if hasContent(current) {
+			sections = append(sections, current)
+		}
+		headings[level-1] = title
+		for i := level; i < len(headings); i++ {
+			headings[i] = ""
+		}
+		var breadcrumb []string
+		for _, h := range headings[:level] {
+			if h != "" {
+				breadcrumb = append(breadcrumb, h)
+			}
+		}
+		current = mdSection{breadcrumb: breadcrumb, blocks: []mdBlock{{kind: mdHeading, text: line}}}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if isOpeningFence(line) {
+			flushPara()
+			end := i + 1
+			for end < len(lines) && !isClosingFence(lines[end], line) {
+				end++
+			}
+			if end == len(lines) {
+				end-- // Unclosed fences run to the end of the text
+			}
+			current.blocks = append(current.blocks, mdBlock{kind: mdCode, text: strings.Join(lines[i:end+1], "\n")})
+			i = end
+			continue
+		}
+		if level, title, ok := atxHeading(line); ok {
+			startSection(level, title, strings.Repeat("#", level)+" "+title)
+			continue
+		}
+		if level := setextLevel(trimmed); level > 0 && paraKind == mdText && len(para) == 1 {
+			title := strings.TrimSpace(para[0])
+			para = nil
+			startSection(level, title, strings.Repeat("#", level)+" "+title)
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushPara()
+		case isTableRow(trimmed, paraKind == mdTable) || (strings.Contains(trimmed, "|") && i+1 < len(lines) && isTableSeparator(lines[i+1])):
+			if paraKind != mdTable {
+				flushPara()
+				paraKind = mdTable
+			}
+			para = append(para, line)
+		default:
+			if paraKind == mdTable {
+				flushPara()
+			}
+			para = append(para, line)
+		}
+	}
+	flushPara()
+	if hasContent(current) {
+		sections = append(sections, current)
+	}
+	return sections
+}
+
+// hasContent reports whether a section has blocks besides its heading.
+func hasContent(section mdSection) bool {
+	for _, b := range section.blocks {
+		if b.kind != mdHeading {
+			return true
+		}
+	}
+	return false
+}
+
+// atxHeading parses "## Title ##" headings.
+func atxHeading(line string) (int, string, bool) {
+	s := strings.TrimLeft(line, " ")
+	if len(line)-len(s) > 3 {
+		return 0, "", false
+	}
+	level := 0
+	for level < len(s) && s[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(s) && s[level] != ' ' && s[level] != '\t') {
+		return 0, "", false
+	}
+	title := strings.TrimSpace(s[level:])
+	// Optional closing sequence
+	if trimmed := strings.TrimRight(title, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		title = strings.TrimSpace(trimmed)
+	}
+	if title == "" {
+		return 0, "", false
+	}
+	return level, title, true
+}
+
+// setextLevel returns 1 for "===" and 2 for "---" underlines, 0 otherwise.
+func setextLevel(trimmed string) int {
+	switch {
+	case trimmed == "":
+		return 0
+	case strings.Trim(trimmed, "=") == "":
+		return 1
+	case strings.Trim(trimmed, "-") == "":
+		return 2
+	}
+	return 0
+}
+
+// isOpeningFence reports whether line opens a ``` or ~~~ code fence.
+func isOpeningFence(line string) bool {
+	s := strings.TrimLeft(line, " ")
+	return len(line)-len(s) <= 3 && (strings.HasPrefix(s, "```") || strings.HasPrefix(s, "~~~"))
+}
+
+// isClosingFence reports whether line closes the fence opened by opening.
+func isClosingFence(line, opening string) bool {
+	open := strings.TrimLeft(opening, " ")
+	fence := open[:1]
+	n := len(open) - len(strings.TrimLeft(open, fence))
+	s := strings.TrimSpace(line)
+	return len(s) >= n && strings.Trim(s, fence) == ""
+}
+
+// isTableRow reports whether a line is a pipe table row. Rows without a
+// leading pipe are only recognized inside a table.
+func isTableRow(trimmed string, inTable bool) bool {
+	return strings.HasPrefix(trimmed, "|") || (inTable && strings.Contains(trimmed, "|"))
+}
+
+// isTableSeparator reports whether line is a table delimiter row ("|---|:-:|").
+func isTableSeparator(line string) bool {
+	s := strings.Trim(strings.TrimSpace(line), "|")
+	if !strings.Contains(s, "-") {
+		return false
+	}
+	for _, cell := range strings.Split(s, "|") {
+		cell = strings.TrimSpace(cell)
+		if cell == "" || strings.Trim(cell, ":-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Ensure MarkdownSplitter implements the interface
+var _ usecase.TextSplitter = (*MarkdownSplitter)(nil)
@@ -0,0 +1,75 @@
+package splitter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// ParentChildSplitter implements the usecase.TextSplitter interface for
+// small-to-big retrieval. Documents are first split into large parent
+// sections, then each parent is split into small child chunks by the child
+// splitter. Only children are returned, hence embedded and searched; each one
+// carries the ID and text of its parent in its metadata (see
+// usecase.MetadataParentID), so that the query use case can answer from the
+// parents.
+//
+// The parent text is stored with every child, which grows the payload by
+// roughly the number of children per parent.
+type ParentChildSplitter struct {
+	parent usecase.TextSplitter
+	child  usecase.TextSplitter
+}
+
+// NewParentChildSplitter creates a splitter producing children with child
+// from the parents produced by parent. A nil parent splitter keeps the
+// documents as loaded (one per PDF page, record or file) as parents.
+func NewParentChildSplitter(parent, child usecase.TextSplitter) *ParentChildSplitter {
+	return &ParentChildSplitter{parent: parent, child: child}
+}
+
+// SplitDocuments splits the documents into parents and returns their
+// children, keeping the order of the documents.
+func (s *ParentChildSplitter) SplitDocuments(ctx context.Context, docs []schema.Document) ([]schema.Document, error) {
+	parents := docs
+	if s.parent != nil {
+		var err error
+		parents, err = s.parent.SplitDocuments(ctx, docs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split parent sections: %w", err)
+		}
+	}
+
+	var children []schema.Document
+	for i, parent := range parents {
+		chunks, err := s.child.SplitDocuments(ctx, []schema.Document{parent})
+		if err != nil {
+			return nil, err
+		}
+		id := parentID(parent.PageContent)
+		for _, chunk := range chunks {
+			if chunk.Metadata == nil {
+				chunk.Metadata = make(map[string]interface{})
+			}
+			chunk.Metadata[usecase.MetadataParentID] = id
+			chunk.Metadata[usecase.MetadataParentIndex] = i
+			chunk.Metadata[usecase.MetadataParentText] = parent.PageContent
+			children = append(children, chunk)
+		}
+	}
+	return children, nil
+}
+
+// parentID identifies a parent by its content, so that children of the same
+// section share it across runs.
+func parentID(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Ensure ParentChildSplitter implements the interface
+var _ usecase.TextSplitter = (*ParentChildSplitter)(nil)
@@ -0,0 +1,47 @@
+package splitter
+
+import "strings"
+
+// ModelPreset holds token-based chunking settings suited to an embedding model.
+type ModelPreset struct {
+	MaxTokens    int // Maximum input accepted by the model
+	ChunkSize    int // Tokens per chunk
+	ChunkOverlap int // Tokens shared by consecutive chunks
+}
+
+// DefaultModelPreset is used for embedding models without a preset. It fits
+// the smallest common context window (512 tokens).
+var DefaultModelPreset = ModelPreset{MaxTokens: 512, ChunkSize: 384, ChunkOverlap: 48}
+
+// modelPresets are keyed by model name without tag. Chunk sizes stay well
+// below MaxTokens because tiktoken only approximates the tokenizer of most
+// Ollama models.
+var modelPresets = map[string]ModelPreset{
+	// Ollama runs nomic-embed-text with a 2048 token context by default
+	"nomic-embed-text":       {MaxTokens: 2048, ChunkSize: 512, ChunkOverlap: 64},
+	"mxbai-embed-large":      {MaxTokens: 512, ChunkSize: 384, ChunkOverlap: 48},
+	"snowflake-arctic-embed": {MaxTokens: 512, ChunkSize: 384, ChunkOverlap: 48},
+	"all-minilm":             {MaxTokens: 256, ChunkSize: 192, ChunkOverlap: 24},
+	"bge-large":              {MaxTokens: 512, ChunkSize: 384, ChunkOverlap: 48},
+	"bge-m3":                 {MaxTokens: 8192, ChunkSize: 1024, ChunkOverlap: 128},
+	"text-embedding-3-small": {MaxTokens: 8191, ChunkSize: 1024, ChunkOverlap: 128},
+	"text-embedding-3-large": {MaxTokens: 8191, ChunkSize: 1024, ChunkOverlap: 128},
+	"text-embedding-ada-002": {MaxTokens: 8191, ChunkSize: 1024, ChunkOverlap: 128},
+}
+
+// PresetFor returns the preset of an embedding model such as
+// "nomic-embed-text:latest", or DefaultModelPreset and false when the model
+// is unknown.
+func PresetFor(model string) (ModelPreset, bool) {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	if p, ok := modelPresets[name]; ok {
+		return p, true
+	}
+	return DefaultModelPreset, false
+}
@@ -3,6 +3,7 @@ package splitter
 import (
 	"context"
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
 	"github.com/tmc/langchaingo/schema"
@@ -11,7 +12,8 @@ import (
 
 // RecursiveCharacterSplitter implements the usecase.TextSplitter interface.
 type RecursiveCharacterSplitter struct {
-	splitter textsplitter.RecursiveCharacter
+	splitter  textsplitter.RecursiveCharacter
+	chunkSize int
 }
 
 // NewRecursiveCharacterSplitter creates a new splitter with default settings.
@@ -21,9 +23,20 @@ func NewRecursiveCharacterSplitter(chunkSize, chunkOverlap int) *RecursiveCharac
 			textsplitter.WithChunkSize(chunkSize),
 			textsplitter.WithChunkOverlap(chunkOverlap),
 		),
+		chunkSize: chunkSize,
 	}
 }
 
+// ChunkSize returns the maximum chunk length in characters.
+func (s *RecursiveCharacterSplitter) ChunkSize() int {
+	return s.chunkSize
+}
+
+// Len returns the length of text in characters.
+func (s *RecursiveCharacterSplitter) Len(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
 // SplitDocuments splits the given documents using the recursive character splitter.
 func (s *RecursiveCharacterSplitter) SplitDocuments(ctx context.Context, docs []schema.Document) ([]schema.Document, error) {
 	splittedDocs, err := textsplitter.SplitDocuments(s.splitter, docs)
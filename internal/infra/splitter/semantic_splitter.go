@@ -0,0 +1,343 @@
+package splitter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// SemanticSplitter implements the usecase.TextSplitter interface by cutting
+// chunks where the topic changes. Text is split into sentences, each
+// sentence is embedded together with its neighbours, and a chunk ends where
+// the cosine similarity between consecutive sentences falls below the given
+// percentile of all similarities in the document.
+//
+// Chunks are kept between minSize and the base splitter's chunk size,
+// measured like the base splitter (characters or tokens). Sentences are not
+// overlapped between chunks. Splitting costs one embedding per sentence.
+type SemanticSplitter struct {
+	base       SizedSplitter
+	embedder   usecase.EmbeddingGenerator
+	percentile float64
+	minSize    int
+	window     int
+	batchSize  int
+}
+
+// SemanticOption configures a SemanticSplitter.
+type SemanticOption func(*SemanticSplitter)
+
+// WithBreakpointPercentile sets the similarity percentile below which a
+// chunk boundary is placed (5 by default): lower values produce fewer, larger chunks.
+func WithBreakpointPercentile(p float64) SemanticOption {
+	return func(s *SemanticSplitter) {
+		s.percentile = p
+	}
+}
+
+// WithMinChunkSize sets the size below which chunks are not cut at topic
+// changes (a quarter of the chunk size by default).
+func WithMinChunkSize(n int) SemanticOption {
+	return func(s *SemanticSplitter) {
+		s.minSize = n
+	}
+}
+
+// WithSentenceWindow sets how many sentences on each side are embedded with
+// a sentence to smooth the similarities (1 by default).
+func WithSentenceWindow(n int) SemanticOption {
+	return func(s *SemanticSplitter) {
+		s.window = n
+	}
+}
+
+// WithEmbedBatchSize sets how many sentences are sent per embedding request.
+func WithEmbedBatchSize(n int) SemanticOption {
+	return func(s *SemanticSplitter) {
+		s.batchSize = n
+	}
+}
+
+// NewSemanticSplitter creates a splitter embedding sentences with embedder,
+// sizing chunks like base.
+func NewSemanticSplitter(base SizedSplitter, embedder usecase.EmbeddingGenerator, opts ...SemanticOption) *SemanticSplitter {
+	s := &SemanticSplitter{
+		base:       base,
+		embedder:   embedder,
+		percentile: 5,
+		minSize:    base.ChunkSize() / 4,
+		window:     1,
+		batchSize:  32,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.minSize > base.ChunkSize() {
+		s.minSize = base.ChunkSize()
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = 32
+	}
+	return s
+}
+
+// ChunkSize returns the maximum chunk size of the base splitter.
+func (s *SemanticSplitter) ChunkSize() int {
+	return s.base.ChunkSize()
+}
+
+// Len measures text like the base splitter.
+func (s *SemanticSplitter) Len(text string) int {
+	return s.base.Len(text)
+}
+
+// SplitDocuments splits every document at topic changes. Chunks inherit the
+// metadata of their document.
+func (s *SemanticSplitter) SplitDocuments(ctx context.Context, docs []schema.Document) ([]schema.Document, error) {
+	var splittedDocs []schema.Document
+	for _, doc := range docs {
+		chunks, err := s.splitText(ctx, doc.PageContent)
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range chunks {
+			metadata := make(map[string]interface{}, len(doc.Metadata))
+			for k, v := range doc.Metadata {
+				metadata[k] = v
+			}
+			splittedDocs = append(splittedDocs, schema.Document{
+				PageContent: chunk,
+				Metadata:    metadata,
+			})
+		}
+	}
+	return splittedDocs, nil
+}
+
+// splitText returns the chunks of one text.
+func (s *SemanticSplitter) splitText(ctx context.Context, text string) ([]string, error) {
+	spans := s.sentences(text)
+	if len(spans) == 0 {
+		return nil, nil
+	}
+	chunkText := func(from, to int) string {
+		return strings.TrimSpace(text[spans[from].start:spans[to].end])
+	}
+	if len(spans) == 1 || s.Len(chunkText(0, len(spans)-1)) <= s.minSize {
+		return []string{chunkText(0, len(spans)-1)}, nil
+	}
+
+	similarities, err := s.similarities(ctx, text, spans)
+	if err != nil {
+		return nil, err
+	}
+	threshold := percentile(similarities, s.percentile)
+
+	// Chunks are ranges of sentences
+	type sentenceRange struct{ from, to int }
+	maxSize := s.ChunkSize()
+	var ranges []sentenceRange
+	first := 0
+	for i := 0; i < len(spans)-1; i++ {
+		size := s.Len(chunkText(first, i))
+		topicChange := similarities[i] < threshold && size >= s.minSize
+		if topicChange || s.Len(chunkText(first, i+1)) > maxSize {
+			ranges = append(ranges, sentenceRange{first, i})
+			first = i + 1
+		}
+	}
+	tail := sentenceRange{first, len(spans) - 1}
+
+	// A short tail joins the previous chunk when both fit
+	if n := len(ranges); n > 0 && s.Len(chunkText(tail.from, tail.to)) < s.minSize && s.Len(chunkText(ranges[n-1].from, tail.to)) <= maxSize {
+		ranges[n-1].to = tail.to
+	} else {
+		ranges = append(ranges, tail)
+	}
+
+	chunks := make([]string, len(ranges))
+	for i, r := range ranges {
+		chunks[i] = chunkText(r.from, r.to)
+	}
+	return chunks, nil
+}
+
+// similarities returns the cosine similarity between each sentence and the
+// next, embedding every sentence with its surrounding window.
+func (s *SemanticSplitter) similarities(ctx context.Context, text string, spans []span) ([]float64, error) {
+	windows := make([]string, len(spans))
+	for i := range spans {
+		from, to := max(0, i-s.window), min(len(spans)-1, i+s.window)
+		windows[i] = strings.TrimSpace(text[spans[from].start:spans[to].end])
+	}
+
+	embeddings := make([][]float32, 0, len(windows))
+	for start := 0; start < len(windows); start += s.batchSize {
+		end := min(start+s.batchSize, len(windows))
+		batch, err := s.embedder.EmbedDocuments(ctx, windows[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed sentences for semantic chunking: %w", err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("failed to embed sentences for semantic chunking: got %d embeddings for %d sentences", len(batch), end-start)
+		}
+		embeddings = append(embeddings, batch...)
+	}
+
+	similarities := make([]float64, len(spans)-1)
+	for i := range similarities {
+		similarities[i] = cosineSimilarity(embeddings[i], embeddings[i+1])
+	}
+	return similarities, nil
+}
+
+// span is a byte range of the text.
+type span struct {
+	start, end int
+}
+
+// sentences splits text into sentence spans. Sentences longer than a chunk
+// are cut between words.
+func (s *SemanticSplitter) sentences(text string) []span {
+	var spans []span
+	for _, sp := range splitSentences(text) {
+		if s.Len(text[sp.start:sp.end]) <= s.ChunkSize() {
+			spans = append(spans, sp)
+			continue
+		}
+		spans = append(spans, s.splitLong(text, sp)...)
+	}
+	return spans
+}
+
+// splitLong cuts a span at whitespace into spans of at most a chunk. Words
+// longer than a chunk are kept whole.
+func (s *SemanticSplitter) splitLong(text string, sp span) []span {
+	var spans []span
+	start, lastFit := sp.start, -1
+	for i := sp.start; i <= sp.end; i++ {
+		if i < sp.end && !isASCIISpace(text[i]) {
+			continue
+		}
+		if i > start && !isASCIISpace(text[i-1]) {
+			// i ends a word
+			if s.Len(text[start:i]) <= s.ChunkSize() || lastFit < 0 {
+				lastFit = i
+			} else {
+				spans = append(spans, span{start: start, end: lastFit})
+				start = lastFit
+				for start < sp.end && isASCIISpace(text[start]) {
+					start++
+				}
+				lastFit = i
+			}
+		}
+	}
+	if lastFit > start {
+		spans = append(spans, span{start: start, end: lastFit})
+	}
+	return spans
+}
+
+func isASCIISpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == '\v'
+}
+
+// abbreviations do not end a sentence.
+var abbreviations = map[string]bool{
+	"e.g.": true, "i.e.": true, "etc.": true, "vs.": true, "cf.": true, "fig.": true,
+	"dr.": true, "mr.": true, "mrs.": true, "ms.": true, "sr.": true, "sra.": true,
+	"no.": true, "p.": true, "pp.": true, "ex.": true,
+}
+
+// splitSentences returns the spans of the sentences of text. Sentences end
+// at ".", "!", "?" or "…" followed by whitespace, and at blank lines.
+func splitSentences(text string) []span {
+	var spans []span
+	start := -1
+	emit := func(end int) {
+		if start >= 0 && strings.TrimSpace(text[start:end]) != "" {
+			spans = append(spans, span{start: start, end: end})
+		}
+		start = -1
+	}
+
+	runes := []rune(text)
+	offsets := make([]int, len(runes)+1)
+	pos := 0
+	for i, r := range runes {
+		offsets[i] = pos
+		pos += len(string(r))
+	}
+	offsets[len(runes)] = pos
+
+	for i, r := range runes {
+		if start < 0 {
+			if unicode.IsSpace(r) {
+				continue
+			}
+			start = offsets[i]
+		}
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		switch {
+		case r == '\n' && next == '\n':
+			emit(offsets[i])
+		case strings.ContainsRune(".!?…", r) && (next == 0 || unicode.IsSpace(next)):
+			word := text[start:offsets[i+1]]
+			if j := strings.LastIndexFunc(word, unicode.IsSpace); j >= 0 {
+				word = word[j+1:]
+			}
+			if r == '.' && abbreviations[strings.ToLower(word)] {
+				continue
+			}
+			emit(offsets[i+1])
+		}
+	}
+	emit(len(text))
+	return spans
+}
+
+// percentile returns the p-th percentile (0-100) of values, interpolating
+// between the closest ranks.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := math.Max(0, math.Min(100, p)) / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// Ensure SemanticSplitter implements the interfaces
+var (
+	_ usecase.TextSplitter = (*SemanticSplitter)(nil)
+	_ SizedSplitter        = (*SemanticSplitter)(nil)
+)
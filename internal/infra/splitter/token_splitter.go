@@ -0,0 +1,116 @@
+package splitter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/textsplitter"
+)
+
+// DefaultTokenEncoding is the tiktoken encoding used to count tokens.
+const DefaultTokenEncoding = "cl100k_base"
+
+// TokenSplitter implements the usecase.TextSplitter interface, measuring chunk
+// size and overlap in tokens instead of characters. Text is split on the same
+// separators as RecursiveCharacterSplitter (paragraphs, lines, words).
+//
+// Tokens are counted locally with a tiktoken encoding, which approximates the
+// tokenizer of models that do not use it. Chunks exceeding maxTokens, the
+// embedding model's input limit, are refused instead of being silently
+// truncated by the model.
+type TokenSplitter struct {
+	splitter  textsplitter.RecursiveCharacter
+	count     func(string) int
+	chunkSize int
+	maxTokens int
+}
+
+// TokenOption configures a TokenSplitter.
+type TokenOption func(*tokenOptions)
+
+type tokenOptions struct {
+	encoding  string
+	maxTokens int
+}
+
+// WithEncoding sets the tiktoken encoding (DefaultTokenEncoding by default).
+func WithEncoding(name string) TokenOption {
+	return func(o *tokenOptions) {
+		o.encoding = name
+	}
+}
+
+// WithMaxTokens sets the largest chunk accepted, usually the embedding
+// model's maximum input. 0 disables the guard.
+func WithMaxTokens(n int) TokenOption {
+	return func(o *tokenOptions) {
+		o.maxTokens = n
+	}
+}
+
+// NewTokenSplitter creates a splitter producing chunks of at most chunkSize
+// tokens, consecutive chunks sharing up to chunkOverlap tokens. The encoding
+// is downloaded on first use and cached in TIKTOKEN_CACHE_DIR (or the system
+// temporary directory); it fails when the encoding cannot be loaded.
+func NewTokenSplitter(chunkSize, chunkOverlap int, opts ...TokenOption) (*TokenSplitter, error) {
+	o := tokenOptions{encoding: DefaultTokenEncoding}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxTokens > 0 && chunkSize > o.maxTokens {
+		return nil, fmt.Errorf("chunk size of %d tokens exceeds the embedding model limit of %d tokens", chunkSize, o.maxTokens)
+	}
+
+	enc, err := tiktoken.GetEncoding(o.encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer encoding '%s': %w", o.encoding, err)
+	}
+	count := func(s string) int {
+		return len(enc.EncodeOrdinary(s))
+	}
+
+	return &TokenSplitter{
+		splitter: textsplitter.NewRecursiveCharacter(
+			textsplitter.WithChunkSize(chunkSize),
+			textsplitter.WithChunkOverlap(chunkOverlap),
+			textsplitter.WithLenFunc(count),
+		),
+		count:     count,
+		chunkSize: chunkSize,
+		maxTokens: o.maxTokens,
+	}, nil
+}
+
+// ChunkSize returns the maximum chunk length in tokens.
+func (s *TokenSplitter) ChunkSize() int {
+	return s.chunkSize
+}
+
+// Len returns the number of tokens in text.
+func (s *TokenSplitter) Len(text string) int {
+	return s.count(text)
+}
+
+// SplitDocuments splits the given documents into token-sized chunks. It fails
+// when a chunk exceeds the embedding model's input limit.
+func (s *TokenSplitter) SplitDocuments(ctx context.Context, docs []schema.Document) ([]schema.Document, error) {
+	splittedDocs, err := textsplitter.SplitDocuments(s.splitter, docs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split documents: %w", err)
+	}
+
+	if s.maxTokens > 0 {
+		for i, doc := range splittedDocs {
+			if n := s.count(doc.PageContent); n > s.maxTokens {
+				return nil, fmt.Errorf("chunk %d has %d tokens, exceeding the embedding model limit of %d tokens", i, n, s.maxTokens)
+			}
+		}
+	}
+	return splittedDocs, nil
+}
+
+// Ensure TokenSplitter implements the interface
+var _ usecase.TextSplitter = (*TokenSplitter)(nil)
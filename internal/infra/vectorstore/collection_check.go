@@ -0,0 +1,161 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/qdrant"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+const (
+	// defaultDistance is the distance of the collections created by the adapters.
+	defaultDistance = "Cosine"
+
+	// metadataEmbeddingModel is the collection metadata key recording the
+	// embedding model whose vectors the collection stores.
+	metadataEmbeddingModel = "embedding_model"
+)
+
+// collectionInfo describes the "default" vector, the sparse vector and the
+// payload indexes of an existing collection.
+type collectionInfo struct {
+	VectorSize     int // 0 when the collection has no vector named "default"
+	Distance       string
+	Sparse         bool   // The collection has a sparse vector named "bm25"
+	EmbeddingModel string // Empty for collections created without metadata
+	PayloadIndexes map[string]PayloadIndex
+}
+
+// compatible reports, wrapping usecase.ErrIncompatibleCollection, why the
+// collection cannot store vectors of vectorSize dimensions produced by model.
+// An empty model, or a collection without a recorded model, skips the model check.
+func (info *collectionInfo) compatible(collectionName string, vectorSize int, model string) error {
+	var problem string
+	switch {
+	case info.VectorSize == 0:
+		problem = "it has no vector named 'default'"
+	case info.VectorSize != vectorSize:
+		problem = fmt.Sprintf("it stores %d-dimensional vectors but the embedding model produces %d-dimensional vectors", info.VectorSize, vectorSize)
+	case info.Distance != defaultDistance:
+		problem = fmt.Sprintf("it uses %s distance instead of %s", info.Distance, defaultDistance)
+	case model != "" && info.EmbeddingModel != "" && info.EmbeddingModel != model:
+		problem = fmt.Sprintf("it was created with embedding model %q, not %q", info.EmbeddingModel, model)
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w: collection '%s': %s; re-ingest it in recreate mode or use another collection", usecase.ErrIncompatibleCollection, collectionName, problem)
+}
+
+// sparseCompatible reports, wrapping usecase.ErrIncompatibleCollection, that
+// the collection cannot store the sparse vectors of hybrid search.
+func (info *collectionInfo) sparseCompatible(collectionName string) error {
+	if info.Sparse {
+		return nil
+	}
+	return fmt.Errorf("%w: collection '%s': it has no sparse vector '%s' for hybrid search; re-ingest it in recreate mode or use another collection", usecase.ErrIncompatibleCollection, collectionName, sparseVectorName)
+}
+
+// collectionChecker verifies that collections match the embedding model
+// before they are written or searched, remembering the compatible ones so
+// each collection is only fetched once.
+type collectionChecker struct {
+	client *qdrant.Client
+	model  string
+
+	mu         sync.Mutex
+	compatible map[string]bool
+	sparse     map[string]bool // Collections with sparse vectors
+}
+
+func newCollectionChecker(client *qdrant.Client, model string) *collectionChecker {
+	return &collectionChecker{client: client, model: model, compatible: make(map[string]bool), sparse: make(map[string]bool)}
+}
+
+// check returns an error when the collection exists and is incompatible
+// with vectors of vectorSize dimensions produced by the checker's model.
+// Missing collections are left to the caller.
+func (c *collectionChecker) check(ctx context.Context, collectionName string, vectorSize int) error {
+	c.mu.Lock()
+	ok := c.compatible[collectionName]
+	c.mu.Unlock()
+	if ok {
+		return nil
+	}
+
+	info, exists, err := c.info(ctx, collectionName)
+	if err != nil {
+		return fmt.Errorf("failed to check collection '%s': %w", collectionName, err)
+	}
+	if !exists {
+		return nil
+	}
+	if err := info.compatible(collectionName, vectorSize, c.model); err != nil {
+		return err
+	}
+	c.remember(collectionName)
+	return nil
+}
+
+// checkSparse returns an error when the collection exists without the
+// sparse vector searched by hybrid search. Missing collections are left to
+// the caller.
+func (c *collectionChecker) checkSparse(ctx context.Context, collectionName string) error {
+	c.mu.Lock()
+	ok := c.sparse[collectionName]
+	c.mu.Unlock()
+	if ok {
+		return nil
+	}
+
+	info, exists, err := c.info(ctx, collectionName)
+	if err != nil {
+		return fmt.Errorf("failed to check collection '%s': %w", collectionName, err)
+	}
+	if !exists {
+		return nil
+	}
+	if err := info.sparseCompatible(collectionName); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.sparse[collectionName] = true
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *collectionChecker) remember(collectionName string) {
+	c.mu.Lock()
+	c.compatible[collectionName] = true
+	c.mu.Unlock()
+}
+
+// forget drops a deleted collection, which may be recreated differently.
+func (c *collectionChecker) forget(collectionName string) {
+	c.mu.Lock()
+	delete(c.compatible, collectionName)
+	delete(c.sparse, collectionName)
+	c.mu.Unlock()
+}
+
+// info fetches the vector parameters and metadata of a collection; exists
+// is false when the collection does not exist.
+func (c *collectionChecker) info(ctx context.Context, collectionName string) (info *collectionInfo, exists bool, err error) {
+	description, exists, err := c.client.GetCollection(ctx, collectionName)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	// The adapters use a single vector named "default"
+	// (collections with a single unnamed vector are reported as incompatible)
+	params := description.NamedVectors()["default"]
+
+	info = &collectionInfo{VectorSize: params.Size, Distance: params.Distance, Sparse: description.HasSparseVector(sparseVectorName)}
+	info.EmbeddingModel, _ = description.Config.Metadata[metadataEmbeddingModel].(string)
+	info.PayloadIndexes = make(map[string]PayloadIndex, len(description.PayloadSchema))
+	for field, schema := range description.PayloadSchema {
+		info.PayloadIndexes[field] = PayloadIndex{Field: field, Type: PayloadIndexType(schema.DataType), Points: schema.Points}
+	}
+	return info, true, nil
+}
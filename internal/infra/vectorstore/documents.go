@@ -0,0 +1,38 @@
+package vectorstore
+
+import "github.com/tmc/langchaingo/schema"
+
+// scoredPayload is a point returned by a search of either backend.
+type scoredPayload struct {
+	id      string
+	score   float64
+	payload map[string]interface{}
+}
+
+// resultDocuments converts search results to documents. The metadata of a
+// document is the payload of its point, without "text", plus its "score"
+// and the "collection" it was found in.
+func resultDocuments(results []scoredPayload, collectionName string) []schema.Document {
+	documents := make([]schema.Document, 0, len(results))
+	for _, res := range results {
+		text, ok := res.payload["text"].(string)
+		if !ok {
+			continue
+		}
+
+		metadata := make(map[string]interface{}, len(res.payload)+1)
+		for k, v := range res.payload {
+			if k != "text" {
+				metadata[k] = v
+			}
+		}
+		metadata["score"] = res.score
+		metadata["collection"] = collectionName
+
+		documents = append(documents, schema.Document{
+			PageContent: text,
+			Metadata:    metadata,
+		})
+	}
+	return documents
+}
@@ -0,0 +1,71 @@
+package vectorstore
+
+import (
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/qdrant"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// qdrantFilter translates f into a Qdrant filter, nil when f is empty.
+func qdrantFilter(f *usecase.Filter) *qdrant.Filter {
+	if f.IsEmpty() {
+		return nil
+	}
+	return &qdrant.Filter{
+		Must:    qdrantConditions(f.Must),
+		Should:  qdrantConditions(f.Should),
+		MustNot: qdrantConditions(f.MustNot),
+	}
+}
+
+func qdrantConditions(conditions []usecase.Condition) []qdrant.FieldCondition {
+	if len(conditions) == 0 {
+		return nil
+	}
+	result := make([]qdrant.FieldCondition, len(conditions))
+	for i, c := range conditions {
+		result[i] = qdrantCondition(c)
+	}
+	return result
+}
+
+func qdrantCondition(c usecase.Condition) qdrant.FieldCondition {
+	switch c.Op {
+	case usecase.FilterMatch:
+		return qdrant.FieldCondition{Key: c.Key, Match: &qdrant.MatchValue{Value: c.Value}}
+	case usecase.FilterIn:
+		return qdrant.FieldCondition{Key: c.Key, Match: &qdrant.MatchValue{Any: c.Values}}
+	case usecase.FilterRange:
+		r := &qdrant.RangeValue{}
+		setBound(&r.Gt, c.Range.Gt)
+		setBound(&r.Gte, c.Range.Gte)
+		setBound(&r.Lt, c.Range.Lt)
+		setBound(&r.Lte, c.Range.Lte)
+		return qdrant.FieldCondition{Key: c.Key, Range: r}
+	case usecase.FilterDateRange:
+		r := &qdrant.RangeValue{}
+		setDateBound(&r.Gt, c.DateRange.Gt)
+		setDateBound(&r.Gte, c.DateRange.Gte)
+		setDateBound(&r.Lt, c.DateRange.Lt)
+		setDateBound(&r.Lte, c.DateRange.Lte)
+		return qdrant.FieldCondition{Key: c.Key, Range: r}
+	default: // usecase.FilterExists
+		// Qdrant has no exists condition: negate is_empty in a nested filter
+		return qdrant.FieldCondition{Filter: &qdrant.Filter{
+			MustNot: []qdrant.FieldCondition{{IsEmpty: &qdrant.PayloadField{Key: c.Key}}},
+		}}
+	}
+}
+
+func setBound(dst *interface{}, v *float64) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func setDateBound(dst *interface{}, t *time.Time) {
+	if t != nil {
+		*dst = t.Format(time.RFC3339Nano)
+	}
+}
@@ -0,0 +1,54 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+func TestQdrantFilterJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{"match", "lang=pt", `{"must":[{"key":"lang","match":{"value":"pt"}}]}`},
+		{"integer match", "page=3", `{"must":[{"key":"page","match":{"value":3}}]}`},
+		{"false match", "draft=false", `{"must":[{"key":"draft","match":{"value":false}}]}`},
+		{"any", "lang=pt|en", `{"must":[{"key":"lang","match":{"any":["pt","en"]}}]}`},
+		{"not equal", "status!=draft", `{"must_not":[{"key":"status","match":{"value":"draft"}}]}`},
+		{"range", "page>=3,page<10", `{"must":[{"key":"page","range":{"gte":3}},{"key":"page","range":{"lt":10}}]}`},
+		{"zero bound", "score>0", `{"must":[{"key":"score","range":{"gt":0}}]}`},
+		{"date range", "created>=2024-01-01,created<=2024-06-30T12:30:00-03:00",
+			`{"must":[{"key":"created","range":{"gte":"2024-01-01T00:00:00Z"}},{"key":"created","range":{"lte":"2024-06-30T12:30:00-03:00"}}]}`},
+		{"exists", "author=*", `{"must":[{"must_not":[{"is_empty":{"key":"author"}}]}]}`},
+		{"does not exist", "author!=*", `{"must_not":[{"must_not":[{"is_empty":{"key":"author"}}]}]}`},
+		{"clauses", "lang=pt,should:tag=a,should:tag=b,must_not:draft=true",
+			`{"must":[{"key":"lang","match":{"value":"pt"}}],"should":[{"key":"tag","match":{"value":"a"}},{"key":"tag","match":{"value":"b"}}],"must_not":[{"key":"draft","match":{"value":true}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := usecase.ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q): %v", tt.expr, err)
+			}
+			data, err := json.Marshal(qdrantFilter(f))
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("filter %q translates to\n%s\nwant\n%s", tt.expr, data, tt.want)
+			}
+		})
+	}
+}
+
+func TestQdrantFilterEmpty(t *testing.T) {
+	if f := qdrantFilter(nil); f != nil {
+		t.Errorf("nil filter translates to %+v", f)
+	}
+	if f := qdrantFilter(&usecase.Filter{}); f != nil {
+		t.Errorf("empty filter translates to %+v", f)
+	}
+}
@@ -0,0 +1,138 @@
+package vectorstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// filterMatches evaluates f on a payload the way Qdrant does for the
+// filters of qdrantFilter: a condition on an array field holds when it
+// holds for any element, and dotted keys ("a.b") reach nested objects.
+func filterMatches(f *usecase.Filter, payload map[string]interface{}) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	for _, c := range f.Must {
+		if !conditionMatches(c, payload) {
+			return false
+		}
+	}
+	for _, c := range f.MustNot {
+		if conditionMatches(c, payload) {
+			return false
+		}
+	}
+	if len(f.Should) == 0 {
+		return true
+	}
+	for _, c := range f.Should {
+		if conditionMatches(c, payload) {
+			return true
+		}
+	}
+	return false
+}
+
+func conditionMatches(c usecase.Condition, payload map[string]interface{}) bool {
+	values := payloadValues(payload, c.Key)
+	if c.Op == usecase.FilterExists {
+		return len(values) > 0
+	}
+	for _, v := range values {
+		if valueMatches(c, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func valueMatches(c usecase.Condition, v interface{}) bool {
+	switch c.Op {
+	case usecase.FilterMatch:
+		return equalValue(c.Value, v)
+	case usecase.FilterIn:
+		for _, want := range c.Values {
+			if equalValue(want, v) {
+				return true
+			}
+		}
+		return false
+	case usecase.FilterRange:
+		n, ok := v.(float64)
+		if !ok {
+			return false
+		}
+		r := c.Range
+		return (r.Gt == nil || n > *r.Gt) && (r.Gte == nil || n >= *r.Gte) &&
+			(r.Lt == nil || n < *r.Lt) && (r.Lte == nil || n <= *r.Lte)
+	case usecase.FilterDateRange:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		t, ok := parsePayloadDate(s)
+		if !ok {
+			return false
+		}
+		r := c.DateRange
+		return (r.Gt == nil || t.After(*r.Gt)) && (r.Gte == nil || !t.Before(*r.Gte)) &&
+			(r.Lt == nil || t.Before(*r.Lt)) && (r.Lte == nil || !t.After(*r.Lte))
+	}
+	return false
+}
+
+// equalValue compares a filter value (string, int64 or bool) with a payload
+// value decoded from JSON, where numbers are float64.
+func equalValue(want, v interface{}) bool {
+	switch want := want.(type) {
+	case int64:
+		n, ok := v.(float64)
+		return ok && n == float64(want)
+	default:
+		return want == v
+	}
+}
+
+// payloadValues returns the non-null values of the field at key, arrays
+// being flattened. A "[]" suffix on a key part is accepted, as in Qdrant.
+func payloadValues(payload map[string]interface{}, key string) []interface{} {
+	current := []interface{}{payload}
+	for _, part := range strings.Split(key, ".") {
+		part = strings.TrimSuffix(part, "[]")
+		var next []interface{}
+		for _, v := range current {
+			if obj, ok := v.(map[string]interface{}); ok {
+				next = appendFlat(next, obj[part])
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+func appendFlat(values []interface{}, v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return values
+	case []interface{}:
+		for _, e := range v {
+			values = appendFlat(values, e)
+		}
+		return values
+	default:
+		return append(values, v)
+	}
+}
+
+// parsePayloadDate parses the date formats accepted by Qdrant datetime
+// ranges: RFC 3339, with or without time zone, or a date alone (UTC).
+func parsePayloadDate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
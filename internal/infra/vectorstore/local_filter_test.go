@@ -0,0 +1,88 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+func TestFilterMatches(t *testing.T) {
+	// Decoded from JSON, as the local store reads its payloads
+	var payload map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"lang": "pt",
+		"page": 3,
+		"score": 0.75,
+		"draft": false,
+		"tags": ["go", "rag"],
+		"author": {"name": "Ana", "roles": ["editor"]},
+		"created": "2024-03-15T10:00:00Z",
+		"published": "2024-03-20",
+		"empty": null,
+		"none": []
+	}`), &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"", true},
+		{"lang=pt", true},
+		{"lang=en", false},
+		{"lang!=en", true},
+		{"page=3", true},
+		{`page="3"`, false},
+		{"page=4", false},
+		{"draft=false", true},
+		{"draft=true", false},
+		{"lang=en|pt", true},
+		{"page=1|2", false},
+		{"tags=rag", true},
+		{"tags=python", false},
+		{"tags!=python|java", true},
+		{"author.name=Ana", true},
+		{"author.roles=editor", true},
+		{"author.roles[]=editor", true},
+		{"author.name=Bia", false},
+		{"page>=3", true},
+		{"page>3", false},
+		{"page<3.5,page>2", true},
+		{"score<=0.75", true},
+		{"score<0.75", false},
+		{"lang>1", false},
+		{"created>=2024-03-01", true},
+		{"created>2024-03-15T10:00:00Z", false},
+		{"created<2024-03-15T12:00:00+03:00", false},
+		{"published<=2024-03-20", true},
+		{"published>2024-03-19T23:00:00Z", true},
+		{"lang>=2024-01-01", false},
+		{"lang=*", true},
+		{"author=*", true},
+		{"missing=*", false},
+		{"empty=*", false},
+		{"none=*", false},
+		{"missing!=*", true},
+		{"missing=x", false},
+		{"missing!=x", true},
+		{"should:lang=en,should:page=3", true},
+		{"should:lang=en,should:page=4", false},
+		{"lang=pt,should:lang=en", false},
+		{"must_not:draft=false", false},
+		{"lang=pt,page>=1,must_not:tags=python,should:tags=go,should:tags=java", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := usecase.ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q): %v", tt.expr, err)
+			}
+			if got := filterMatches(f, payload); got != tt.want {
+				t.Errorf("filter %q matches = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,694 @@
+package vectorstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+	bolt "go.etcd.io/bbolt"
+)
+
+// CollectionRetriever is a retriever that also searches and lists any
+// collection, as needed by the multi-collection queries of
+// usecase.QueryUseCase. QdrantVectorStore and LocalVectorStore implement it.
+type CollectionRetriever interface {
+	usecase.Retriever
+	SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int, opts ...usecase.SearchOption) ([]schema.Document, error)
+	ListCollections(ctx context.Context) ([]string, error)
+}
+
+// LocalVectorStore implements the usecase.VectorStore and usecase.Retriever
+// interfaces, and hybrid search, without a Qdrant server: the collections
+// are kept in memory, searched by brute force and persisted in a bbolt
+// file. It suits small corpora and tests.
+//
+// Scores are cosine similarities, and filters, payloads and point IDs
+// behave as with Qdrant, so both backends return the same documents. Every
+// collection stores sparse vectors and payload indexes are not needed, so
+// WithSparseVectors and WithPayloadIndexes have no effect.
+type LocalVectorStore struct {
+	db             *localDB
+	embedder       usecase.EmbeddingGenerator // Embedder needed for GetRelevantDocuments
+	collectionName string                     // Collection used by GetRelevantDocuments
+	numDocuments   int                        // Number of documents returned by GetRelevantDocuments
+	embeddingModel string                     // Recorded in the collections created by the store
+
+	closeOnce sync.Once
+}
+
+// Buckets of the local store file: one per collection, holding its
+// configuration and a bucket of points keyed by ID.
+var (
+	localConfigKey    = []byte("config")
+	localPointsBucket = []byte("points")
+)
+
+// localDB holds the collections of a store file, shared by the
+// LocalVectorStore instances opened on it.
+type localDB struct {
+	bolt *bolt.DB // nil for in-memory stores
+	path string
+	refs int // Open instances; guarded by localDBs.mu
+
+	mu sync.RWMutex
+	// Collections loaded so far; nil for collections known not to exist
+	collections map[string]*localCollection
+}
+
+// localDBs shares the open store files of the process, as bbolt locks them.
+var localDBs = struct {
+	mu    sync.Mutex
+	byAbs map[string]*localDB
+}{byAbs: make(map[string]*localDB)}
+
+type localCollection struct {
+	config localCollectionConfig
+
+	mu     sync.RWMutex
+	points map[string]*localPoint
+}
+
+type localCollectionConfig struct {
+	VectorSize     int    `json:"vector_size"`
+	Distance       string `json:"distance"`
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+}
+
+type localPoint struct {
+	vector  []float32 // Unit length
+	sparse  usecase.SparseVector
+	payload map[string]interface{} // As decoded from JSON, like Qdrant payloads
+}
+
+// localPointRecord is the gob encoding of a point in the store file.
+type localPointRecord struct {
+	Vector        []float32
+	SparseIndices []uint32
+	SparseValues  []float32
+	Payload       []byte // JSON
+}
+
+// NewLocalVectorStore opens the local store kept in the bbolt file at path,
+// creating the file and its directory if needed; an empty path keeps the
+// collections in memory only. Stores opened on the same path in a process
+// share their collections, but only one process can open the file at a time.
+func NewLocalVectorStore(path string, embedder usecase.EmbeddingGenerator, opts ...Option) (*LocalVectorStore, error) {
+	db, err := openLocalDB(path)
+	if err != nil {
+		return nil, err
+	}
+	o := applyOptions(opts)
+	return &LocalVectorStore{
+		db:             db,
+		embedder:       embedder,
+		collectionName: o.collectionName,
+		numDocuments:   o.numDocuments,
+		embeddingModel: o.embeddingModel,
+	}, nil
+}
+
+func openLocalDB(path string) (*localDB, error) {
+	if path == "" {
+		return &localDB{collections: make(map[string]*localCollection)}, nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local vector store path %s: %w", path, err)
+	}
+
+	localDBs.mu.Lock()
+	defer localDBs.mu.Unlock()
+	if db, ok := localDBs.byAbs[abs]; ok {
+		db.refs++
+		return db, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create local vector store directory: %w", err)
+	}
+	b, err := bolt.Open(abs, 0o600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("local vector store %s is in use by another process", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local vector store %s: %w", path, err)
+	}
+	db := &localDB{bolt: b, path: abs, refs: 1, collections: make(map[string]*localCollection)}
+	localDBs.byAbs[abs] = db
+	return db, nil
+}
+
+// Close releases the store; the file is closed with the last store opened on it.
+func (s *LocalVectorStore) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		db := s.db
+		if db.bolt == nil {
+			return
+		}
+		localDBs.mu.Lock()
+		defer localDBs.mu.Unlock()
+		db.refs--
+		if db.refs > 0 {
+			return
+		}
+		delete(localDBs.byAbs, db.path)
+		if cerr := db.bolt.Close(); cerr != nil {
+			err = fmt.Errorf("failed to close local vector store %s: %w", db.path, cerr)
+		}
+	})
+	return err
+}
+
+// collection returns a collection, loading it from the file on first use;
+// nil when it does not exist.
+func (db *localDB) collection(name string) (*localCollection, error) {
+	db.mu.RLock()
+	c, ok := db.collections[name]
+	db.mu.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if c, ok := db.collections[name]; ok {
+		return c, nil
+	}
+	c, err := db.load(name)
+	if err != nil {
+		return nil, err
+	}
+	db.collections[name] = c
+	return c, nil
+}
+
+// load reads a collection from the file; nil when it does not exist.
+func (db *localDB) load(name string) (*localCollection, error) {
+	if db.bolt == nil {
+		return nil, nil
+	}
+	var c *localCollection
+	err := db.bolt.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(name))
+		if b == nil {
+			return nil
+		}
+		c = &localCollection{points: make(map[string]*localPoint)}
+		if err := json.Unmarshal(b.Get(localConfigKey), &c.config); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+		points := b.Bucket(localPointsBucket)
+		if points == nil {
+			return nil
+		}
+		return points.ForEach(func(id, data []byte) error {
+			p, err := decodeLocalPoint(data)
+			if err != nil {
+				return fmt.Errorf("invalid point %s: %w", id, err)
+			}
+			c.points[string(id)] = p
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collection '%s' from local vector store: %w", name, err)
+	}
+	return c, nil
+}
+
+// existing returns a collection, failing when it does not exist.
+func (db *localDB) existing(name string) (*localCollection, error) {
+	c, err := db.collection(name)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("collection '%s' does not exist", name)
+	}
+	return c, nil
+}
+
+// info describes the collection as collectionInfo, for the compatibility checks.
+func (c *localCollection) info() *collectionInfo {
+	return &collectionInfo{VectorSize: c.config.VectorSize, Distance: c.config.Distance, Sparse: true, EmbeddingModel: c.config.EmbeddingModel}
+}
+
+// EnsureCollection creates the collection if it does not exist. An existing
+// collection must have the same vector size and, when both are known, the
+// same embedding model (see WithEmbeddingModel); otherwise the error wraps
+// usecase.ErrIncompatibleCollection.
+func (s *LocalVectorStore) EnsureCollection(ctx context.Context, collectionName string, vectorSize int) error {
+	if collectionName == "" {
+		return fmt.Errorf("collection name must not be empty")
+	}
+	c, err := s.db.collection(collectionName)
+	if err != nil {
+		return err
+	}
+	if c != nil {
+		return c.info().compatible(collectionName, vectorSize, s.embeddingModel)
+	}
+
+	db := s.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if c := db.collections[collectionName]; c != nil {
+		// Created meanwhile
+		return c.info().compatible(collectionName, vectorSize, s.embeddingModel)
+	}
+
+	c = &localCollection{
+		config: localCollectionConfig{VectorSize: vectorSize, Distance: defaultDistance, EmbeddingModel: s.embeddingModel},
+		points: make(map[string]*localPoint),
+	}
+	if db.bolt != nil {
+		config, err := json.Marshal(c.config)
+		if err != nil {
+			return fmt.Errorf("failed to encode configuration of collection '%s': %w", collectionName, err)
+		}
+		err = db.bolt.Update(func(tx *bolt.Tx) error {
+			b, err := tx.CreateBucket([]byte(collectionName))
+			if err != nil {
+				return err
+			}
+			if _, err := b.CreateBucket(localPointsBucket); err != nil {
+				return err
+			}
+			return b.Put(localConfigKey, config)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create collection '%s': %w", collectionName, err)
+		}
+	}
+	db.collections[collectionName] = c
+
+	fmt.Printf("Collection '%s' created successfully\n", collectionName)
+	return nil
+}
+
+// DeleteCollection deletes a collection. Deleting a missing collection is
+// not an error.
+func (s *LocalVectorStore) DeleteCollection(ctx context.Context, collectionName string) error {
+	c, err := s.db.collection(collectionName)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return nil
+	}
+
+	db := s.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.bolt != nil {
+		err := db.bolt.Update(func(tx *bolt.Tx) error {
+			err := tx.DeleteBucket([]byte(collectionName))
+			if errors.Is(err, bolt.ErrBucketNotFound) {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete collection '%s': %w", collectionName, err)
+		}
+	}
+	db.collections[collectionName] = nil
+
+	fmt.Printf("Collection '%s' deleted successfully\n", collectionName)
+	return nil
+}
+
+// AddDocuments adds documents with pre-generated embeddings to the specified collection.
+func (s *LocalVectorStore) AddDocuments(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32) ([]string, error) {
+	return s.upsert(ctx, collectionName, docs, embeddings, nil)
+}
+
+// AddDocumentsWithSparse adds documents with pre-generated embeddings and
+// sparse vectors to the specified collection.
+func (s *LocalVectorStore) AddDocumentsWithSparse(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32, sparse []usecase.SparseVector) ([]string, error) {
+	return s.upsert(ctx, collectionName, docs, embeddings, sparse)
+}
+
+// upsert stores the documents with their embeddings and, when sparse is not
+// nil, their sparse vectors, replacing the points with the same IDs.
+func (s *LocalVectorStore) upsert(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32, sparse []usecase.SparseVector) ([]string, error) {
+	if len(docs) != len(embeddings) {
+		return nil, fmt.Errorf("number of documents (%d) does not match number of embeddings (%d)", len(docs), len(embeddings))
+	}
+	if sparse != nil && len(sparse) != len(docs) {
+		return nil, fmt.Errorf("number of documents (%d) does not match number of sparse vectors (%d)", len(docs), len(sparse))
+	}
+	c, err := s.db.existing(collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert points to collection '%s': %w", collectionName, err)
+	}
+
+	ids := make([]string, len(docs))
+	points := make([]*localPoint, len(docs))
+	records := make([][]byte, len(docs))
+	for i, doc := range docs {
+		if len(embeddings[i]) != c.config.VectorSize {
+			return nil, fmt.Errorf("failed to upsert points to collection '%s': embedding %d has %d dimensions, the collection stores %d", collectionName, i, len(embeddings[i]), c.config.VectorSize)
+		}
+		ids[i] = pointIDFor(doc, i)
+
+		payload := map[string]interface{}{}
+		for k, v := range doc.Metadata {
+			payload[k] = v
+		}
+		payload["text"] = doc.PageContent
+		payloadJSON, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode payload of document %d: %w", i, err)
+		}
+
+		p := &localPoint{vector: unitVector(embeddings[i])}
+		if err := json.Unmarshal(payloadJSON, &p.payload); err != nil {
+			return nil, fmt.Errorf("failed to decode payload of document %d: %w", i, err)
+		}
+		if sparse != nil {
+			p.sparse = sortedSparse(sparse[i])
+		}
+		points[i] = p
+
+		if s.db.bolt != nil {
+			records[i], err = encodeLocalPoint(p, payloadJSON)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode point of document %d: %w", i, err)
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s.db.bolt != nil {
+		err := s.db.bolt.Update(func(tx *bolt.Tx) error {
+			b, err := pointsBucket(tx, collectionName)
+			if err != nil {
+				return err
+			}
+			for i, id := range ids {
+				if err := b.Put([]byte(id), records[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert points to collection '%s': %w", collectionName, err)
+		}
+	}
+	for i, id := range ids {
+		c.points[id] = points[i]
+	}
+	return ids, nil
+}
+
+// pointsBucket returns the points bucket of a collection in the file.
+func pointsBucket(tx *bolt.Tx, collectionName string) (*bolt.Bucket, error) {
+	b := tx.Bucket([]byte(collectionName))
+	if b == nil {
+		return nil, fmt.Errorf("collection '%s' does not exist", collectionName)
+	}
+	return b.CreateBucketIfNotExists(localPointsBucket)
+}
+
+// ListSourceHashes returns the file hash recorded for each source of the
+// collection, empty for sources stored with several versions (see
+// recordSourceHash). A missing collection yields an empty map.
+func (s *LocalVectorStore) ListSourceHashes(ctx context.Context, collectionName string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	c, err := s.db.collection(collectionName)
+	if err != nil || c == nil {
+		return hashes, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, p := range c.points {
+		source, ok := p.payload[usecase.MetadataSource].(string)
+		if !ok {
+			continue
+		}
+		hash, _ := p.payload[usecase.MetadataFileHash].(string)
+		recordSourceHash(hashes, source, hash)
+	}
+	return hashes, nil
+}
+
+// DeleteBySource removes all points whose "source" payload equals source.
+func (s *LocalVectorStore) DeleteBySource(ctx context.Context, collectionName string, source string) error {
+	filter := &usecase.Filter{Must: []usecase.Condition{usecase.Match(usecase.MetadataSource, source)}}
+	if err := s.DeleteByFilter(ctx, collectionName, filter); err != nil {
+		return fmt.Errorf("failed to delete points of source '%s': %w", source, err)
+	}
+	return nil
+}
+
+// DeleteByFilter removes all points matching filter, which must not be empty.
+func (s *LocalVectorStore) DeleteByFilter(ctx context.Context, collectionName string, filter *usecase.Filter) error {
+	if filter.IsEmpty() {
+		return fmt.Errorf("refusing to delete the points of collection '%s' without a filter", collectionName)
+	}
+	c, err := s.db.existing(collectionName)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ids []string
+	for id, p := range c.points {
+		if filterMatches(filter, p.payload) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if s.db.bolt != nil {
+		err := s.db.bolt.Update(func(tx *bolt.Tx) error {
+			b, err := pointsBucket(tx, collectionName)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := b.Delete([]byte(id)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete points from collection '%s': %w", collectionName, err)
+		}
+	}
+	for _, id := range ids {
+		delete(c.points, id)
+	}
+	return nil
+}
+
+// SimilaritySearch returns the numDocuments points of the collection most
+// similar to the query embedding, among those matching the filter of the
+// options, if any. The documents hold the score and the collection in
+// their metadata.
+func (s *LocalVectorStore) SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	c, err := s.db.existing(collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	if err := c.info().compatible(collectionName, len(queryEmbedding), s.embeddingModel); err != nil {
+		return nil, err
+	}
+
+	query := unitVector(queryEmbedding)
+	results := c.search(usecase.NewSearchOptions(opts...).Filter, numDocuments, func(p *localPoint) (float64, bool) {
+		var dot float64
+		for i, v := range p.vector {
+			dot += float64(v) * float64(query[i])
+		}
+		return dot, true
+	})
+	return resultDocuments(results, collectionName), nil
+}
+
+// SparseSearch searches the sparse vectors of the collection, restricted to
+// the points matching the filter of the options, if any. Only points
+// sharing a term with the vector are returned.
+func (s *LocalVectorStore) SparseSearch(ctx context.Context, collectionName string, vector usecase.SparseVector, numDocuments int, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	c, err := s.db.existing(collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("sparse search failed: %w", err)
+	}
+
+	weights := make(map[uint32]float32, len(vector.Indices))
+	for i, id := range vector.Indices {
+		weights[id] += vector.Values[i]
+	}
+	results := c.search(usecase.NewSearchOptions(opts...).Filter, numDocuments, func(p *localPoint) (float64, bool) {
+		var dot float64
+		found := false
+		for i, id := range p.sparse.Indices {
+			if w, ok := weights[id]; ok {
+				dot += float64(w) * float64(p.sparse.Values[i])
+				found = true
+			}
+		}
+		return dot, found
+	})
+	return resultDocuments(results, collectionName), nil
+}
+
+// search returns the limit best points matching filter, by decreasing
+// score; score reports false for the points not to return.
+func (c *localCollection) search(filter *usecase.Filter, limit int, score func(p *localPoint) (float64, bool)) []scoredPayload {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var results []scoredPayload
+	for id, p := range c.points {
+		if !filterMatches(filter, p.payload) {
+			continue
+		}
+		value, ok := score(p)
+		if !ok {
+			continue
+		}
+		results = append(results, scoredPayload{id: id, score: value, payload: p.payload})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].id < results[j].id
+	})
+	if len(results) > limit {
+		results = results[:max(limit, 0)]
+	}
+	return results
+}
+
+// GetRelevantDocuments implements the usecase.Retriever interface.
+// It embeds the query and then calls SimilaritySearch on the configured
+// collection (see WithCollectionName and WithNumDocuments).
+func (s *LocalVectorStore) GetRelevantDocuments(ctx context.Context, query string, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	queryEmbedding, err := s.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query for retrieval: %w", err)
+	}
+	return s.SimilaritySearch(ctx, s.collectionName, queryEmbedding, s.numDocuments, opts...)
+}
+
+// GetRelevantSparseDocuments calls SparseSearch on the collection of
+// GetRelevantDocuments.
+func (s *LocalVectorStore) GetRelevantSparseDocuments(ctx context.Context, vector usecase.SparseVector, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	return s.SparseSearch(ctx, s.collectionName, vector, s.numDocuments, opts...)
+}
+
+// ListCollections returns the names of the collections, sorted.
+func (s *LocalVectorStore) ListCollections(ctx context.Context) ([]string, error) {
+	db := s.db
+	var names []string
+	if db.bolt == nil {
+		db.mu.RLock()
+		for name, c := range db.collections {
+			if c != nil {
+				names = append(names, name)
+			}
+		}
+		db.mu.RUnlock()
+	} else {
+		err := db.bolt.View(func(tx *bolt.Tx) error {
+			return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+				names = append(names, string(name))
+				return nil
+			})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list collections: %w", err)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// unitVector returns v scaled to unit length, so that dot products are
+// cosine similarities. The zero vector is returned as is.
+func unitVector(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	unit := make([]float32, len(v))
+	if norm == 0 {
+		copy(unit, v)
+		return unit
+	}
+	norm = math.Sqrt(norm)
+	for i, x := range v {
+		unit[i] = float32(float64(x) / norm)
+	}
+	return unit
+}
+
+// sortedSparse returns a copy of v sorted by term id.
+func sortedSparse(v usecase.SparseVector) usecase.SparseVector {
+	order := make([]int, len(v.Indices))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return v.Indices[order[a]] < v.Indices[order[b]] })
+	sorted := usecase.SparseVector{Indices: make([]uint32, len(order)), Values: make([]float32, len(order))}
+	for i, j := range order {
+		sorted.Indices[i] = v.Indices[j]
+		sorted.Values[i] = v.Values[j]
+	}
+	return sorted
+}
+
+func encodeLocalPoint(p *localPoint, payloadJSON []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(localPointRecord{
+		Vector:        p.vector,
+		SparseIndices: p.sparse.Indices,
+		SparseValues:  p.sparse.Values,
+		Payload:       payloadJSON,
+	})
+	return buf.Bytes(), err
+}
+
+func decodeLocalPoint(data []byte) (*localPoint, error) {
+	var record localPointRecord
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&record); err != nil {
+		return nil, err
+	}
+	p := &localPoint{
+		vector: record.Vector,
+		sparse: usecase.SparseVector{Indices: record.SparseIndices, Values: record.SparseValues},
+	}
+	if err := json.Unmarshal(record.Payload, &p.payload); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Ensure LocalVectorStore implements the interfaces
+var _ usecase.VectorStore = (*LocalVectorStore)(nil)
+var _ usecase.Retriever = (*LocalVectorStore)(nil)
+var _ usecase.SparseVectorStore = (*LocalVectorStore)(nil)
+var _ usecase.SparseSearcher = (*LocalVectorStore)(nil)
+var _ CollectionRetriever = (*LocalVectorStore)(nil)
+var _ CollectionRetriever = (*QdrantVectorStore)(nil)
@@ -0,0 +1,391 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// chunkDoc returns chunk index of source, in version hash of the file.
+func chunkDoc(source string, index int, hash, text string) schema.Document {
+	return schema.Document{PageContent: text, Metadata: map[string]interface{}{
+		usecase.MetadataSource:     source,
+		usecase.MetadataChunkIndex: index,
+		usecase.MetadataChunkHash:  text,
+		usecase.MetadataFileHash:   hash,
+	}}
+}
+
+// animals are stored by newAnimalStore, with their embeddings.
+var animals = []struct {
+	doc    schema.Document
+	vector []float32
+}{
+	{chunkDoc("data/cats.txt", 0, "h1", "Cats purr."), []float32{1, 0, 0}},
+	{chunkDoc("data/cats.txt", 1, "h1", "Cats sleep."), []float32{1.6, 1.2, 0}}, // Cosine 0.8 with cats
+	{chunkDoc("data/dogs.txt", 0, "h2", "Dogs bark."), []float32{0, 0, 3}},
+}
+
+func newAnimalStore(t *testing.T, path string, opts ...Option) *LocalVectorStore {
+	t.Helper()
+	ctx := context.Background()
+	s, err := NewLocalVectorStore(path, nil, opts...)
+	if err != nil {
+		t.Fatalf("NewLocalVectorStore: %v", err)
+	}
+	if err := s.EnsureCollection(ctx, "animals", 3); err != nil {
+		t.Fatalf("EnsureCollection: %v", err)
+	}
+	docs := make([]schema.Document, len(animals))
+	vectors := make([][]float32, len(animals))
+	for i, a := range animals {
+		docs[i], vectors[i] = a.doc, a.vector
+	}
+	if _, err := s.AddDocuments(ctx, "animals", docs, vectors); err != nil {
+		t.Fatalf("AddDocuments: %v", err)
+	}
+	return s
+}
+
+func texts(docs []schema.Document) []string {
+	result := make([]string, len(docs))
+	for i, doc := range docs {
+		result[i] = doc.PageContent
+	}
+	return result
+}
+
+func equalTexts(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLocalStoreCosineRanking(t *testing.T) {
+	ctx := context.Background()
+	s := newAnimalStore(t, "")
+
+	// The length of the query does not change cosine scores
+	docs, err := s.SimilaritySearch(ctx, "animals", []float32{5, 0, 0}, 10)
+	if err != nil {
+		t.Fatalf("SimilaritySearch: %v", err)
+	}
+	if got := texts(docs); !equalTexts(got, "Cats purr.", "Cats sleep.", "Dogs bark.") {
+		t.Fatalf("ranking = %q", got)
+	}
+	for i, want := range []float64{1, 0.8, 0} {
+		if score := docs[i].Metadata["score"].(float64); math.Abs(score-want) > 1e-6 {
+			t.Errorf("score of %q = %g, want %g", docs[i].PageContent, score, want)
+		}
+	}
+	if docs[0].Metadata["collection"] != "animals" || docs[0].Metadata[usecase.MetadataSource] != "data/cats.txt" {
+		t.Errorf("metadata = %v, want the collection and the payload", docs[0].Metadata)
+	}
+	if _, ok := docs[0].Metadata["text"]; ok {
+		t.Error("the text is also in the metadata")
+	}
+
+	docs, err = s.SimilaritySearch(ctx, "animals", []float32{0, 0.1, 1}, 1)
+	if err != nil {
+		t.Fatalf("SimilaritySearch: %v", err)
+	}
+	if got := texts(docs); !equalTexts(got, "Dogs bark.") {
+		t.Errorf("best match for dogs = %q", got)
+	}
+
+	if _, err := s.SimilaritySearch(ctx, "animals", []float32{1, 0}, 1); !errors.Is(err, usecase.ErrIncompatibleCollection) {
+		t.Errorf("search with 2 dimensions returned %v, want ErrIncompatibleCollection", err)
+	}
+	if _, err := s.SimilaritySearch(ctx, "missing", []float32{1, 0, 0}, 1); err == nil {
+		t.Error("search of a missing collection succeeded")
+	}
+}
+
+func TestLocalStoreUpsertReplacesPoints(t *testing.T) {
+	ctx := context.Background()
+	s := newAnimalStore(t, "")
+
+	// The same chunk stored again keeps its ID
+	doc := animals[0].doc
+	ids, err := s.AddDocuments(ctx, "animals", []schema.Document{doc}, [][]float32{{0, 1, 0}})
+	if err != nil {
+		t.Fatalf("AddDocuments: %v", err)
+	}
+	docs, _ := s.SimilaritySearch(ctx, "animals", []float32{0, 1, 0}, 10)
+	if len(docs) != len(animals) || docs[0].PageContent != doc.PageContent {
+		t.Errorf("after re-adding %s: %q", ids[0], texts(docs))
+	}
+
+	if _, err := s.AddDocuments(ctx, "animals", []schema.Document{doc}, [][]float32{{1, 0}}); err == nil {
+		t.Error("AddDocuments accepted an embedding of the wrong dimension")
+	}
+	if _, err := s.AddDocuments(ctx, "missing", []schema.Document{doc}, [][]float32{{1, 0, 0}}); err == nil {
+		t.Error("AddDocuments accepted a missing collection")
+	}
+}
+
+func TestLocalStoreReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store", "vectors.db")
+	s := newAnimalStore(t, path, WithEmbeddingModel("hash"))
+	if _, err := s.AddDocumentsWithSparse(ctx, "animals",
+		[]schema.Document{chunkDoc("data/birds.txt", 0, "h3", "Parrots talk.")},
+		[][]float32{{0, 1, 0}},
+		[]usecase.SparseVector{{Indices: []uint32{7}, Values: []float32{2}}}); err != nil {
+		t.Fatalf("AddDocumentsWithSparse: %v", err)
+	}
+	if err := s.EnsureCollection(ctx, "empty", 3); err != nil {
+		t.Fatalf("EnsureCollection: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("the store file was not written: %v", err)
+	}
+
+	reopened, err := NewLocalVectorStore(path, nil, WithEmbeddingModel("hash"))
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	defer reopened.Close()
+
+	names, err := reopened.ListCollections(ctx)
+	if err != nil || !equalTexts(names, "animals", "empty") {
+		t.Errorf("ListCollections = %q, %v", names, err)
+	}
+	docs, err := reopened.SimilaritySearch(ctx, "animals", []float32{1, 0, 0}, 2)
+	if err != nil {
+		t.Fatalf("SimilaritySearch: %v", err)
+	}
+	if got := texts(docs); !equalTexts(got, "Cats purr.", "Cats sleep.") {
+		t.Errorf("ranking after reopening = %q", got)
+	}
+	if docs[0].Metadata[usecase.MetadataChunkIndex] != 0.0 {
+		t.Errorf("chunk_index = %#v, want the JSON number 0", docs[0].Metadata[usecase.MetadataChunkIndex])
+	}
+	sparse, err := reopened.SparseSearch(ctx, "animals", usecase.SparseVector{Indices: []uint32{7}, Values: []float32{1}}, 5)
+	if err != nil || !equalTexts(texts(sparse), "Parrots talk.") {
+		t.Errorf("SparseSearch after reopening = %q, %v", texts(sparse), err)
+	}
+	hashes, err := reopened.ListSourceHashes(ctx, "animals")
+	if err != nil || len(hashes) != 3 || hashes["data/cats.txt"] != "h1" {
+		t.Errorf("ListSourceHashes = %v, %v", hashes, err)
+	}
+
+	// The collection configuration is kept too
+	if err := reopened.EnsureCollection(ctx, "animals", 4); !errors.Is(err, usecase.ErrIncompatibleCollection) {
+		t.Errorf("EnsureCollection with another size returned %v", err)
+	}
+	other, err := NewLocalVectorStore(path, nil, WithEmbeddingModel("other-model"))
+	if err != nil {
+		t.Fatalf("opening with another model: %v", err)
+	}
+	defer other.Close()
+	if err := other.EnsureCollection(ctx, "animals", 3); !errors.Is(err, usecase.ErrIncompatibleCollection) {
+		t.Errorf("EnsureCollection with another model returned %v", err)
+	}
+
+	if err := reopened.DeleteCollection(ctx, "empty"); err != nil {
+		t.Fatalf("DeleteCollection: %v", err)
+	}
+	if names, _ := reopened.ListCollections(ctx); !equalTexts(names, "animals") {
+		t.Errorf("collections after deleting one = %q", names)
+	}
+	if err := reopened.DeleteCollection(ctx, "empty"); err != nil {
+		t.Errorf("deleting a missing collection: %v", err)
+	}
+}
+
+func TestLocalStoreSharedHandle(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vectors.db")
+
+	first := newAnimalStore(t, path)
+	// A relative path to the same file shares the handle
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewLocalVectorStore(rel, nil)
+	if err != nil {
+		t.Fatalf("opening the file a second time: %v", err)
+	}
+	if first.db != second.db || first.db.refs != 2 {
+		t.Fatalf("stores on the same file do not share a handle (refs %d)", first.db.refs)
+	}
+	db := first.db
+
+	// Collections are shared as well
+	if docs, err := second.SimilaritySearch(ctx, "animals", []float32{1, 0, 0}, 1); err != nil || len(docs) != 1 {
+		t.Errorf("search through the second store = %v, %v", docs, err)
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if db.refs != 1 {
+		t.Errorf("refs after closing the first store twice = %d, want 1", db.refs)
+	}
+	if _, err := second.AddDocuments(ctx, "animals", []schema.Document{chunkDoc("data/fish.txt", 0, "h4", "Fish swim.")}, [][]float32{{0, 1, 1}}); err != nil {
+		t.Errorf("the second store no longer works after closing the first: %v", err)
+	}
+
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if db.refs != 0 {
+		t.Errorf("refs after closing both stores = %d", db.refs)
+	}
+	localDBs.mu.Lock()
+	_, open := localDBs.byAbs[db.path]
+	localDBs.mu.Unlock()
+	if open {
+		t.Error("the file is still registered after closing every store")
+	}
+
+	// The file was closed, so it can be opened again, with the new point
+	third, err := NewLocalVectorStore(path, nil)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	defer third.Close()
+	if third.db == db {
+		t.Error("reopening returned the closed handle")
+	}
+	if docs, _ := third.SimilaritySearch(ctx, "animals", []float32{0, 1, 1}, 1); !equalTexts(texts(docs), "Fish swim.") {
+		t.Errorf("best match after reopening = %q, want the point added through the second store", texts(docs))
+	}
+}
+
+func TestLocalStoreFilters(t *testing.T) {
+	ctx := context.Background()
+	s := newAnimalStore(t, "")
+
+	search := func(expr string) []string {
+		t.Helper()
+		f, err := usecase.ParseFilter(expr)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q): %v", expr, err)
+		}
+		docs, err := s.SimilaritySearch(ctx, "animals", []float32{1, 0, 0}, 10, usecase.WithFilter(f))
+		if err != nil {
+			t.Fatalf("SimilaritySearch with %q: %v", expr, err)
+		}
+		return texts(docs)
+	}
+	if got := search("source=data/dogs.txt"); !equalTexts(got, "Dogs bark.") {
+		t.Errorf("dogs only = %q", got)
+	}
+	if got := search("must_not:source=data/dogs.txt"); !equalTexts(got, "Cats purr.", "Cats sleep.") {
+		t.Errorf("all but dogs = %q", got)
+	}
+	if got := search("chunk_index>=1"); !equalTexts(got, "Cats sleep.") {
+		t.Errorf("second chunks = %q", got)
+	}
+	if got := search("should:file_hash=h2,should:chunk_index=1"); !equalTexts(got, "Cats sleep.", "Dogs bark.") {
+		t.Errorf("either condition = %q", got)
+	}
+	if got := search("source=data/birds.txt"); len(got) != 0 {
+		t.Errorf("no match = %q", got)
+	}
+
+	// Deleting by filter removes the matching points only
+	if err := s.DeleteByFilter(ctx, "animals", nil); err == nil {
+		t.Error("DeleteByFilter accepted an empty filter")
+	}
+	filter := &usecase.Filter{
+		Must:    []usecase.Condition{usecase.Match(usecase.MetadataSource, "data/cats.txt")},
+		MustNot: []usecase.Condition{usecase.Match(usecase.MetadataChunkIndex, int64(0))},
+	}
+	if err := s.DeleteByFilter(ctx, "animals", filter); err != nil {
+		t.Fatalf("DeleteByFilter: %v", err)
+	}
+	if got := search("source=*"); !equalTexts(got, "Cats purr.", "Dogs bark.") {
+		t.Errorf("after deleting the second cats chunk = %q", got)
+	}
+	if err := s.DeleteBySource(ctx, "animals", "data/dogs.txt"); err != nil {
+		t.Fatalf("DeleteBySource: %v", err)
+	}
+	if got := search("source=*"); !equalTexts(got, "Cats purr.") {
+		t.Errorf("after deleting dogs = %q", got)
+	}
+}
+
+func TestLocalStoreSparseSearch(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalVectorStore("", nil, WithCollectionName("terms"), WithNumDocuments(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnsureCollection(ctx, "terms", 2); err != nil {
+		t.Fatal(err)
+	}
+	docs := []schema.Document{
+		chunkDoc("a.txt", 0, "h", "ERR-1234 in parser"),
+		chunkDoc("b.txt", 0, "h", "parser overview"),
+		chunkDoc("c.txt", 0, "h", "unrelated"),
+	}
+	// Unsorted indices are accepted
+	sparse := []usecase.SparseVector{
+		{Indices: []uint32{5, 1}, Values: []float32{1, 2}},
+		{Indices: []uint32{5}, Values: []float32{0.5}},
+		{Indices: []uint32{9}, Values: []float32{3}},
+	}
+	vectors := [][]float32{{1, 0}, {1, 0}, {1, 0}}
+	if _, err := s.AddDocumentsWithSparse(ctx, "terms", docs, vectors, sparse); err != nil {
+		t.Fatalf("AddDocumentsWithSparse: %v", err)
+	}
+
+	query := usecase.SparseVector{Indices: []uint32{1, 5}, Values: []float32{3, 2}}
+	found, err := s.SparseSearch(ctx, "terms", query, 10)
+	if err != nil {
+		t.Fatalf("SparseSearch: %v", err)
+	}
+	// Only the points sharing a term are returned, scored by dot product
+	if got := texts(found); !equalTexts(got, "ERR-1234 in parser", "parser overview") {
+		t.Fatalf("SparseSearch = %q", got)
+	}
+	for i, want := range []float64{3*2 + 2*1, 2 * 0.5} {
+		if score := found[i].Metadata["score"].(float64); math.Abs(score-want) > 1e-6 {
+			t.Errorf("score of %q = %g, want %g", found[i].PageContent, score, want)
+		}
+	}
+
+	f := &usecase.Filter{Must: []usecase.Condition{usecase.Match(usecase.MetadataSource, "b.txt")}}
+	found, err = s.GetRelevantSparseDocuments(ctx, query, usecase.WithFilter(f))
+	if err != nil || !equalTexts(texts(found), "parser overview") {
+		t.Errorf("GetRelevantSparseDocuments with a filter = %q, %v", texts(found), err)
+	}
+	if found, _ := s.SparseSearch(ctx, "terms", usecase.SparseVector{}, 10); len(found) != 0 {
+		t.Errorf("an empty query matched %q", texts(found))
+	}
+
+	// Points stored without a sparse vector are never found
+	if _, err := s.AddDocuments(ctx, "terms", []schema.Document{chunkDoc("d.txt", 0, "h", "dense only")}, [][]float32{{0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := s.SparseSearch(ctx, "terms", query, 10); len(found) != 2 {
+		t.Errorf("SparseSearch after adding a dense-only point = %q", texts(found))
+	}
+}
@@ -0,0 +1,94 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/qdrant"
+)
+
+// legacyMetadataKey is the payload field holding the metadata of the points
+// written by the legacy pgk/store adapter, next to "text". The adapters of
+// this package store the metadata as top-level payload fields instead.
+const legacyMetadataKey = "metadata"
+
+// MigrationReport counts the points of a collection examined by
+// MigrateLegacyPayloads.
+type MigrationReport struct {
+	Collection string
+	Scanned    int // Points of the collection
+	Legacy     int // Points in the legacy layout
+	Migrated   int // Legacy points rewritten in the flat layout
+}
+
+// MigrateLegacyPayloads rewrites the points of a collection stored in the
+// legacy layout, {"text": ..., "metadata": {...}}, into the flat layout of
+// the adapters, where metadata fields are top-level payload fields and can
+// be filtered on. A metadata field named like a top-level field is renamed
+// with a "metadata_" prefix. Vectors and point IDs are kept, and points
+// already flat are left untouched, so the migration can be run again. With
+// dryRun, the legacy points are only counted.
+func (s *QdrantVectorStore) MigrateLegacyPayloads(ctx context.Context, collectionName string, dryRun bool) (MigrationReport, error) {
+	report := MigrationReport{Collection: collectionName}
+
+	_, exists, err := s.client.GetCollection(ctx, collectionName)
+	if err != nil {
+		return report, fmt.Errorf("failed to check if collection '%s' exists: %w", collectionName, err)
+	}
+	if !exists {
+		return report, fmt.Errorf("collection '%s' does not exist", collectionName)
+	}
+
+	err = s.scroll(ctx, collectionName, nil, func(points []qdrant.Record) error {
+		report.Scanned += len(points)
+
+		var operations []qdrant.UpdateOperation
+		for _, p := range points {
+			payload, ok := flattenLegacyPayload(p.Payload)
+			if !ok {
+				continue
+			}
+			report.Legacy++
+			operations = append(operations, qdrant.UpdateOperation{
+				OverwritePayload: &qdrant.OverwritePayload{Payload: payload, Points: []interface{}{p.ID}},
+			})
+		}
+		if dryRun || len(operations) == 0 {
+			return nil
+		}
+
+		if err := s.client.UpdateBatch(ctx, collectionName, operations); err != nil {
+			return fmt.Errorf("failed to migrate payloads: %w", err)
+		}
+		report.Migrated += len(operations)
+		return nil
+	})
+	return report, err
+}
+
+// flattenLegacyPayload returns the flat layout of a payload in the legacy
+// layout; ok is false for any other payload.
+func flattenLegacyPayload(payload map[string]interface{}) (flat map[string]interface{}, ok bool) {
+	if _, isText := payload["text"].(string); !isText {
+		return nil, false
+	}
+	value, present := payload[legacyMetadataKey]
+	metadata, isMap := value.(map[string]interface{})
+	if !present || (!isMap && value != nil) {
+		return nil, false
+	}
+
+	flat = make(map[string]interface{}, len(payload)+len(metadata))
+	for k, v := range payload {
+		if k != legacyMetadataKey {
+			flat[k] = v
+		}
+	}
+	for k, v := range metadata {
+		if _, taken := flat[k]; taken {
+			k = legacyMetadataKey + "_" + k
+		}
+		flat[k] = v
+	}
+	return flat, true
+}
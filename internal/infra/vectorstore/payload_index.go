@@ -0,0 +1,130 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/qdrant"
+)
+
+// PayloadIndexType is the type of a Qdrant payload index.
+type PayloadIndexType string
+
+const (
+	IndexKeyword  PayloadIndexType = "keyword"  // Exact match on strings
+	IndexInteger  PayloadIndexType = "integer"  // Match and range on integers
+	IndexFloat    PayloadIndexType = "float"    // Range on numbers
+	IndexBool     PayloadIndexType = "bool"     // Match on booleans
+	IndexDatetime PayloadIndexType = "datetime" // Range on RFC 3339 dates
+	IndexText     PayloadIndexType = "text"     // Full-text match on words
+)
+
+// Valid reports whether t is a known index type.
+func (t PayloadIndexType) Valid() bool {
+	switch t {
+	case IndexKeyword, IndexInteger, IndexFloat, IndexBool, IndexDatetime, IndexText:
+		return true
+	}
+	return false
+}
+
+// PayloadIndex declares an index on a payload field. Filtering on indexed
+// fields avoids scanning every point of the collection.
+type PayloadIndex struct {
+	Field  string
+	Type   PayloadIndexType
+	Points int // Indexed points, as reported by ListPayloadIndexes
+}
+
+// String formats the index as "field:type".
+func (i PayloadIndex) String() string {
+	return i.Field + ":" + string(i.Type)
+}
+
+// ParsePayloadIndex parses a "field:type" index declaration, e.g.
+// "source:keyword" or "page:integer".
+func ParsePayloadIndex(s string) (PayloadIndex, error) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return PayloadIndex{}, fmt.Errorf("invalid payload index %q: expected field:type", s)
+	}
+	index := PayloadIndex{
+		Field: strings.TrimSpace(s[:i]),
+		Type:  PayloadIndexType(strings.ToLower(strings.TrimSpace(s[i+1:]))),
+	}
+	if index.Field == "" {
+		return PayloadIndex{}, fmt.Errorf("invalid payload index %q: missing field", s)
+	}
+	if !index.Type.Valid() {
+		return PayloadIndex{}, fmt.Errorf("invalid payload index %q: type must be keyword, integer, float, bool, datetime or text", s)
+	}
+	return index, nil
+}
+
+// WithPayloadIndexes declares the payload indexes of the collections:
+// EnsureCollection creates the missing ones, on new and existing
+// collections. An existing index of another type is kept, with a warning.
+func WithPayloadIndexes(indexes ...PayloadIndex) Option {
+	return func(o *options) {
+		o.payloadIndexes = indexes
+	}
+}
+
+// fieldSchema returns the Qdrant field schema of the index: full-text
+// indexes split words and lowercase them.
+func (i PayloadIndex) fieldSchema() interface{} {
+	if i.Type == IndexText {
+		return map[string]interface{}{"type": "text", "tokenizer": "word", "lowercase": true}
+	}
+	return string(i.Type)
+}
+
+// ensurePayloadIndexes creates the declared indexes missing from existing.
+func (s *QdrantVectorStore) ensurePayloadIndexes(ctx context.Context, collectionName string, existing map[string]PayloadIndex) error {
+	for _, index := range s.payloadIndexes {
+		if current, ok := existing[index.Field]; ok {
+			if current.Type != index.Type {
+				log.Printf("Warning: payload field '%s' of collection '%s' is indexed as %s, not %s; drop the index to recreate it", index.Field, collectionName, current.Type, index.Type)
+			}
+			continue
+		}
+		if err := s.CreatePayloadIndex(ctx, collectionName, index); err != nil {
+			return err
+		}
+		log.Printf("Created %s payload index on '%s' in collection '%s'", index.Type, index.Field, collectionName)
+	}
+	return nil
+}
+
+// ListPayloadIndexes returns the payload indexes of a collection, sorted by field.
+func (s *QdrantVectorStore) ListPayloadIndexes(ctx context.Context, collectionName string) ([]PayloadIndex, error) {
+	info, exists, err := s.checker.info(ctx, collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection '%s': %w", collectionName, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("collection '%s' does not exist", collectionName)
+	}
+
+	indexes := make([]PayloadIndex, 0, len(info.PayloadIndexes))
+	for _, index := range info.PayloadIndexes {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Field < indexes[j].Field })
+	return indexes, nil
+}
+
+// CreatePayloadIndex indexes a payload field of a collection, waiting for
+// the existing points to be indexed.
+func (s *QdrantVectorStore) CreatePayloadIndex(ctx context.Context, collectionName string, index PayloadIndex) error {
+	return s.client.CreatePayloadIndex(ctx, collectionName, qdrant.CreateIndexRequest{FieldName: index.Field, FieldSchema: index.fieldSchema()})
+}
+
+// DeletePayloadIndex drops the index of a payload field. Deleting a missing
+// index is not an error.
+func (s *QdrantVectorStore) DeletePayloadIndex(ctx context.Context, collectionName string, field string) error {
+	return s.client.DeletePayloadIndex(ctx, collectionName, field)
+}
@@ -1,124 +1,140 @@
 package vectorstore
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/qdrant"
 	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
 	"github.com/google/uuid"
 	"github.com/tmc/langchaingo/schema"
 )
 
-// QdrantVectorStore implements the usecase.VectorStore and usecase.Retriever interfaces using Qdrant.
+// QdrantVectorStore implements the usecase.VectorStore and usecase.Retriever
+// interfaces, hybrid search and the administration of the collections
+// (payload indexes, payload migration) using Qdrant.
 type QdrantVectorStore struct {
-	baseURL  string
-	embedder usecase.EmbeddingGenerator // Embedder needed for GetRelevantDocuments
-	client   *http.Client
+	client         *qdrant.Client
+	embedder       usecase.EmbeddingGenerator // Embedder needed for GetRelevantDocuments
+	collectionName string                     // Collection used by GetRelevantDocuments
+	numDocuments   int                        // Number of documents returned by GetRelevantDocuments
+	embeddingModel string                     // Recorded in the metadata of created collections
+	payloadIndexes []PayloadIndex
+	sparse         bool // Collections are created with sparse vectors
+	checker        *collectionChecker
 }
 
-// --- Qdrant API Structures ---
+// Option configures the vector stores.
+type Option func(*options)
 
-type CreateCollectionRequest struct {
-	Vectors map[string]VectorParams `json:"vectors"`
+type options struct {
+	collectionName string
+	numDocuments   int
+	embeddingModel string
+	payloadIndexes []PayloadIndex
+	sparse         bool
 }
 
-type VectorParams struct {
-	Size     int    `json:"size"`
-	Distance string `json:"distance"` // e.g., "Cosine", "Euclid", "Dot"
-}
-
-type UpsertPointsRequest struct {
-	Points []Point `json:"points"`
-	Wait   bool    `json:"wait"`
-}
-
-type Point struct {
-	ID      string                 `json:"id"`
-	Vector  map[string][]float32   `json:"vector"` // Assuming single vector named "default"
-	Payload map[string]interface{} `json:"payload"`
+func defaultOptions() options {
+	return options{
+		collectionName: "my_collection",
+		numDocuments:   4,
+	}
 }
 
-type SearchRequest struct {
-	Vector      NamedVector `json:"vector"`
-	Limit       int         `json:"limit"`
-	WithPayload bool        `json:"with_payload"`
-	WithVector  bool        `json:"with_vector"`
+// WithCollectionName sets the collection searched by GetRelevantDocuments.
+func WithCollectionName(name string) Option {
+	return func(o *options) {
+		o.collectionName = name
+	}
 }
 
-type NamedVector struct {
-	Name   string    `json:"name"`
-	Vector []float32 `json:"vector"`
+// WithNumDocuments sets how many documents GetRelevantDocuments returns.
+func WithNumDocuments(n int) Option {
+	return func(o *options) {
+		o.numDocuments = n
+	}
 }
 
-type SearchResponse struct {
-	Result []SearchResult `json:"result"`
-	Status string         `json:"status"`
-	Time   float64        `json:"time"`
+// WithEmbeddingModel sets the name of the embedding model producing the
+// vectors. It is recorded in the metadata of the collections created by the
+// adapter, and writing to or searching a collection recorded with another
+// model fails with usecase.ErrIncompatibleCollection.
+func WithEmbeddingModel(model string) Option {
+	return func(o *options) {
+		o.embeddingModel = model
+	}
 }
 
-type SearchResult struct {
-	ID      string                 `json:"id"`
-	Version int                    `json:"version"`
-	Score   float64                `json:"score"`
-	Payload map[string]interface{} `json:"payload"`
-	Vector  interface{}            `json:"vector"` // Can be map[string][]float32 or []float32 depending on request
+func applyOptions(opts []Option) options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
 }
 
 // --- Adapter Implementation ---
 
 // NewQdrantVectorStore creates a new QdrantVectorStore adapter.
-func NewQdrantVectorStore(baseURL string, embedder usecase.EmbeddingGenerator) (*QdrantVectorStore, error) {
-	// Validate URL
-	_, err := url.Parse(baseURL)
+func NewQdrantVectorStore(baseURL string, embedder usecase.EmbeddingGenerator, opts ...Option) (*QdrantVectorStore, error) {
+	client, err := qdrant.NewClient(baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("invalid Qdrant base URL: %w", err)
+		return nil, err
 	}
+	o := applyOptions(opts)
 	return &QdrantVectorStore{
-		baseURL:  baseURL,
-		embedder: embedder,
-		client:   &http.Client{},
+		client:         client,
+		embedder:       embedder,
+		collectionName: o.collectionName,
+		numDocuments:   o.numDocuments,
+		embeddingModel: o.embeddingModel,
+		payloadIndexes: o.payloadIndexes,
+		sparse:         o.sparse,
+		checker:        newCollectionChecker(client, o.embeddingModel),
 	}, nil
 }
 
-// EnsureCollection checks if a collection exists and creates it if not.
+// EnsureCollection checks if a collection exists and creates it if not. An
+// existing collection must have the same vector size, the Cosine distance
+// and, when both are known, the same embedding model (see WithEmbeddingModel);
+// otherwise the error wraps usecase.ErrIncompatibleCollection, as it does
+// when the collection lacks the sparse vector of WithSparseVectors. The
+// payload indexes declared with WithPayloadIndexes are then created if missing.
 func (s *QdrantVectorStore) EnsureCollection(ctx context.Context, collectionName string, vectorSize int) error {
-	exists, err := s.collectionExists(ctx, collectionName)
+	info, exists, err := s.checker.info(ctx, collectionName)
 	if err != nil {
 		return fmt.Errorf("failed to check if collection '%s' exists: %w", collectionName, err)
 	}
 	if !exists {
-		return s.createCollection(ctx, collectionName, vectorSize)
+		if err := s.createCollection(ctx, collectionName, vectorSize); err != nil {
+			return err
+		}
+		s.checker.remember(collectionName)
+		return s.ensurePayloadIndexes(ctx, collectionName, nil)
 	}
-	return nil
+	if err := info.compatible(collectionName, vectorSize, s.embeddingModel); err != nil {
+		return err
+	}
+	if s.sparse {
+		if err := info.sparseCompatible(collectionName); err != nil {
+			return err
+		}
+	}
+	s.checker.remember(collectionName)
+	return s.ensurePayloadIndexes(ctx, collectionName, info.PayloadIndexes)
 }
 
-// DeleteCollection deletes a Qdrant collection.
+// DeleteCollection deletes a Qdrant collection. Deleting a missing
+// collection is not an error.
 func (s *QdrantVectorStore) DeleteCollection(ctx context.Context, collectionName string) error {
-	url := fmt.Sprintf("%s/collections/%s", s.baseURL, collectionName)
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create delete request for collection '%s': %w", collectionName, err)
-	}
-
-	resp, err := s.client.Do(req)
+	existed, err := s.client.DeleteCollection(ctx, collectionName)
+	s.checker.forget(collectionName)
 	if err != nil {
-		return fmt.Errorf("failed to execute delete request for collection '%s': %w", collectionName, err)
+		return err
 	}
-	defer resp.Body.Close()
-
-	// 404 is acceptable (means it didn't exist)
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete collection '%s', status: %d, response: %s", collectionName, resp.StatusCode, string(bodyBytes))
-	}
-
-	if resp.StatusCode == http.StatusOK {
+	if existed {
 		fmt.Printf("Collection '%s' deleted successfully\n", collectionName)
 	}
 	return nil
@@ -126,381 +142,219 @@ func (s *QdrantVectorStore) DeleteCollection(ctx context.Context, collectionName
 
 // AddDocuments adds documents with pre-generated embeddings to the specified collection.
 func (s *QdrantVectorStore) AddDocuments(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32) ([]string, error) {
+	return s.upsert(ctx, collectionName, docs, embeddings, nil)
+}
+
+// upsert stores the documents with their embeddings and, when sparse is not
+// nil, their sparse vectors. The payload of a point is the metadata of its
+// document plus "text", the layout read back by resultDocuments.
+func (s *QdrantVectorStore) upsert(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32, sparse []usecase.SparseVector) ([]string, error) {
 	if len(docs) != len(embeddings) {
 		return nil, fmt.Errorf("number of documents (%d) does not match number of embeddings (%d)", len(docs), len(embeddings))
 	}
+	if sparse != nil && len(sparse) != len(docs) {
+		return nil, fmt.Errorf("number of documents (%d) does not match number of sparse vectors (%d)", len(docs), len(sparse))
+	}
 
 	ids := make([]string, len(docs))
-	points := make([]Point, len(docs))
+	points := make([]qdrant.Point, len(docs))
 
 	for i, doc := range docs {
-		pointID := uuid.New().String()
+		pointID := pointIDFor(doc, i)
 		ids[i] = pointID
 
-		payload := map[string]interface{}{}
-		// Preserve existing metadata and add text
-		if doc.Metadata != nil {
-			for k, v := range doc.Metadata {
-				payload[k] = v
-			}
+		payload := make(map[string]interface{}, len(doc.Metadata)+1)
+		for k, v := range doc.Metadata {
+			payload[k] = v
 		}
-		payload["text"] = doc.PageContent // Ensure text is in payload
+		payload["text"] = doc.PageContent
 
-		points[i] = Point{
+		points[i] = qdrant.Point{
 			ID: pointID,
-			Vector: map[string][]float32{
-				"default": embeddings[i], // Assuming the vector name is "default"
+			Vector: map[string]interface{}{
+				"default": embeddings[i],
 			},
 			Payload: payload,
 		}
+		if sparse != nil && !sparse[i].IsEmpty() {
+			points[i].Vector[sparseVectorName] = qdrant.SparseVector{Indices: sparse[i].Indices, Values: sparse[i].Values}
+		}
 	}
 
-	upsertReq := UpsertPointsRequest{
-		Points: points,
-		Wait:   true, // Wait for operation to complete
-	}
-
-	jsonData, err := json.Marshal(upsertReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal upsert request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/collections/%s/points", s.baseURL, collectionName)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create upsert request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute upsert request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to upsert points to collection '%s', status: %d, response: %s", collectionName, resp.StatusCode, string(bodyBytes))
+	if err := s.client.Upsert(ctx, collectionName, points); err != nil {
+		return nil, err
 	}
-
 	return ids, nil
 }
 
-// SimilaritySearch performs a search using a pre-generated query embedding.
-func (s *QdrantVectorStore) SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int) ([]schema.Document, error) {
-	searchReq := SearchRequest{
-		Vector: NamedVector{
-			Name:   "default", // Assuming the vector name is "default"
-			Vector: queryEmbedding,
-		},
-		Limit:       numDocuments,
-		WithPayload: true,
-		WithVector:  false, // Usually not needed for RAG
-	}
-
-	jsonData, err := json.Marshal(searchReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal search request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/collections/%s/points/search", s.baseURL, collectionName)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create search request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute search request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("search request failed for collection '%s', status: %d, response: %s", collectionName, resp.StatusCode, string(bodyBytes))
-	}
+// pointNamespace scopes the deterministic point IDs generated by pointIDFor.
+var pointNamespace = uuid.MustParse("8b5e1f3a-6a4c-4f0e-9d2b-3c7a1e5f9b10")
+
+// pointIDFor derives a stable point ID from the chunk's source, index,
+// content hash and file hash, so that re-ingesting the same chunk overwrites
+// the existing point instead of duplicating it, while the chunks of a new
+// version of the file never overwrite those of the previous one, which stays
+// searchable until the new version is fully stored. Documents without chunk
+// metadata fall back to their content and position in the batch.
+func pointIDFor(doc schema.Document, position int) string {
+	source, _ := doc.Metadata[usecase.MetadataSource].(string)
+	index, hasIndex := doc.Metadata[usecase.MetadataChunkIndex]
+	hash, hasHash := doc.Metadata[usecase.MetadataChunkHash]
+	if !hasIndex || !hasHash {
+		index = position
+		hash = doc.PageContent
+	}
+	fileHash, _ := doc.Metadata[usecase.MetadataFileHash].(string)
+	name := fmt.Sprintf("%s\x00%v\x00%v\x00%s", source, index, hash, fileHash)
+	return uuid.NewSHA1(pointNamespace, []byte(name)).String()
+}
 
-	var searchResp SearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return nil, fmt.Errorf("failed to decode search response: %w", err)
+// recordSourceHash records the file hash of a point of source. A source
+// whose points carry different hashes, left by an interrupted replacement
+// of its previous version, is recorded with an empty hash so that
+// incremental ingestion processes it again.
+func recordSourceHash(hashes map[string]string, source, hash string) {
+	if previous, ok := hashes[source]; ok && previous != hash {
+		hash = ""
 	}
+	hashes[source] = hash
+}
 
-	// Convert search results to schema.Document
-	documents := make([]schema.Document, 0, len(searchResp.Result))
-	for _, res := range searchResp.Result {
-		text, ok := res.Payload["text"].(string)
-		if !ok {
-			// If text is not directly in payload, skip or handle differently
-			continue
+// scroll calls visit with every point of an existing collection, page by
+// page. Only the payload fields in include are returned, or the whole
+// payload when include is empty.
+func (s *QdrantVectorStore) scroll(ctx context.Context, collectionName string, include []string, visit func(points []qdrant.Record) error) error {
+	var withPayload interface{} = true
+	if len(include) > 0 {
+		withPayload = map[string][]string{"include": include}
+	}
+
+	var offset interface{}
+	for {
+		page, err := s.client.Scroll(ctx, collectionName, qdrant.ScrollRequest{
+			Limit:       256,
+			Offset:      offset,
+			WithPayload: withPayload,
+		})
+		if err != nil {
+			return err
 		}
-
-		metadata := make(map[string]interface{})
-		for k, v := range res.Payload {
-			if k != "text" { // Avoid duplicating text in metadata
-				metadata[k] = v
-			}
+		if err := visit(page.Points); err != nil {
+			return err
 		}
-		metadata["score"] = res.Score // Add similarity score to metadata
-
-		documents = append(documents, schema.Document{
-			PageContent: text,
-			Metadata:    metadata,
-		})
+		if page.NextPageOffset == nil {
+			return nil
+		}
+		offset = page.NextPageOffset
 	}
-
-	return documents, nil
 }
 
-// GetRelevantDocuments implements the usecase.Retriever interface.
-// It embeds the query and then calls SimilaritySearch.
-// Assumes the collection name is implicitly known or needs to be configured.
-// For simplicity, using a hardcoded collection name here, but should be configurable.
-func (s *QdrantVectorStore) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
-	collectionName := "my_collection" // TODO: Make this configurable
-	numDocuments := 4                 // TODO: Make this configurable
+// ListSourceHashes scrolls through the collection and returns the file hash
+// recorded for each source, empty for sources stored with several versions
+// (see recordSourceHash). A missing collection yields an empty map.
+func (s *QdrantVectorStore) ListSourceHashes(ctx context.Context, collectionName string) (map[string]string, error) {
+	hashes := make(map[string]string)
 
-	queryEmbedding, err := s.embedder.EmbedQuery(ctx, query)
+	_, exists, err := s.client.GetCollection(ctx, collectionName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to embed query for retrieval: %w", err)
+		return nil, fmt.Errorf("failed to check if collection '%s' exists: %w", collectionName, err)
 	}
-
-	return s.SimilaritySearch(ctx, collectionName, queryEmbedding, numDocuments)
-}
-
-// --- Helper Methods ---
-
-func (s *QdrantVectorStore) collectionExists(ctx context.Context, collectionName string) (bool, error) {
-	url := fmt.Sprintf("%s/collections/%s", s.baseURL, collectionName)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return false, err
+	if !exists {
+		return hashes, nil
 	}
 
-	resp, err := s.client.Do(req)
+	err = s.scroll(ctx, collectionName, []string{usecase.MetadataSource, usecase.MetadataFileHash}, func(points []qdrant.Record) error {
+		for _, p := range points {
+			source, ok := p.Payload[usecase.MetadataSource].(string)
+			if !ok {
+				continue
+			}
+			hash, _ := p.Payload[usecase.MetadataFileHash].(string)
+			recordSourceHash(hashes, source, hash)
+		}
+		return nil
+	})
 	if err != nil {
-		return false, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		return true, nil
+		return nil, err
 	}
-	if resp.StatusCode == http.StatusNotFound {
-		return false, nil
-	}
-
-	return false, fmt.Errorf("unexpected status code %d when checking collection '%s'", resp.StatusCode, collectionName)
+	return hashes, nil
 }
 
-func (s *QdrantVectorStore) createCollection(ctx context.Context, collectionName string, vectorSize int) error {
-	url := fmt.Sprintf("%s/collections/%s", s.baseURL, collectionName)
-
-	createReq := CreateCollectionRequest{
-		Vectors: map[string]VectorParams{
-			"default": { // Assuming the vector name is "default"
-				Size:     vectorSize,
-				Distance: "Cosine", // Default distance metric
-			},
-		},
+// DeleteBySource removes all points whose "source" payload equals source.
+func (s *QdrantVectorStore) DeleteBySource(ctx context.Context, collectionName string, source string) error {
+	if err := s.client.DeletePoints(ctx, collectionName, qdrant.MatchKey(usecase.MetadataSource, source)); err != nil {
+		return fmt.Errorf("failed to delete points of source '%s': %w", source, err)
 	}
-
-	jsonData, err := json.Marshal(createReq)
-	if err != nil {
-		return fmt.Errorf("failed to marshal create collection request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request for creating collection '%s': %w", collectionName, err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to execute request for creating collection '%s': %w", collectionName, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to create collection '%s', status: %d, response: %s", collectionName, resp.StatusCode, string(bodyBytes))
-	}
-
-	fmt.Printf("Collection '%s' created successfully\n", collectionName)
 	return nil
 }
 
-// Ensure QdrantVectorStore implements the interfaces
-var _ usecase.VectorStore = (*QdrantVectorStore)(nil)
-var _ usecase.Retriever = (*QdrantVectorStore)(nil)
-
-// QdrantRetriever implementa a interface usecase.Retriever com suporte a múltiplas coleções.
-type QdrantRetriever struct {
-	baseURL  string
-	embedder usecase.EmbeddingGenerator
-	client   *http.Client
-}
-
-// NewQdrantRetriever cria um novo QdrantRetriever.
-func NewQdrantRetriever(baseURL string, embedder usecase.EmbeddingGenerator) (*QdrantRetriever, error) {
-	_, err := url.Parse(baseURL)
-	if err != nil {
-		return nil, fmt.Errorf("invalid Qdrant base URL: %w", err)
+// DeleteByFilter removes all points matching filter, which must not be empty.
+func (s *QdrantVectorStore) DeleteByFilter(ctx context.Context, collectionName string, filter *usecase.Filter) error {
+	if filter.IsEmpty() {
+		return fmt.Errorf("refusing to delete the points of collection '%s' without a filter", collectionName)
 	}
-	return &QdrantRetriever{
-		baseURL:  baseURL,
-		embedder: embedder,
-		client:   &http.Client{},
-	}, nil
+	return s.client.DeletePoints(ctx, collectionName, *qdrantFilter(filter))
 }
 
-// GetRelevantDocuments implementa a interface usecase.Retriever.
-// Esta implementação usa collectionName configurável, se não fornecido, usa "my_collection"
-func (r *QdrantRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
-	// Se nenhuma coleção for especificada, usa a padrão
-	collectionName := "my_collection"
-	numDocuments := 4
-
-	// Converter query para embedding
-	queryEmbedding, err := r.embedder.EmbedQuery(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to embed query for retrieval: %w", err)
+// SimilaritySearch performs a search using a pre-generated query embedding,
+// restricted to the points matching the filter of the options, if any.
+// It fails early when the collection does not match the query embedding.
+func (s *QdrantVectorStore) SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	if err := s.checker.check(ctx, collectionName, len(queryEmbedding)); err != nil {
+		return nil, err
 	}
-
-	// Buscar documentos usando a função de similaridade
-	return r.SimilaritySearch(ctx, collectionName, queryEmbedding, numDocuments)
+	return s.search(ctx, collectionName, qdrant.NamedVector{Name: "default", Vector: queryEmbedding}, numDocuments, opts)
 }
 
-// SimilaritySearch busca documentos similares em uma coleção específica do Qdrant.
-func (r *QdrantRetriever) SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int) ([]schema.Document, error) {
-	searchReq := SearchRequest{
-		Vector: NamedVector{
-			Name:   "default",
-			Vector: queryEmbedding,
-		},
+// search runs a search on the dense (qdrant.NamedVector) or sparse
+// (qdrant.NamedSparseVector) vectors of a collection.
+func (s *QdrantVectorStore) search(ctx context.Context, collectionName string, vector interface{}, numDocuments int, opts []usecase.SearchOption) ([]schema.Document, error) {
+	points, err := s.client.Search(ctx, collectionName, qdrant.SearchRequest{
+		Vector:      vector,
+		Filter:      qdrantFilter(usecase.NewSearchOptions(opts...).Filter),
 		Limit:       numDocuments,
 		WithPayload: true,
-		WithVector:  false,
-	}
-
-	jsonData, err := json.Marshal(searchReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal search request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/collections/%s/points/search", r.baseURL, collectionName)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create search request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := r.client.Do(req)
+		WithVector:  false, // Usually not needed for RAG
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute search request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("search request failed for collection '%s', status: %d, response: %s", collectionName, resp.StatusCode, string(bodyBytes))
+		return nil, err
 	}
 
-	var searchResp SearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return nil, fmt.Errorf("failed to decode search response: %w", err)
-	}
-
-	// Converter resultados para schema.Document
-	documents := make([]schema.Document, 0, len(searchResp.Result))
-	for _, res := range searchResp.Result {
-		text, ok := res.Payload["text"].(string)
-		if !ok {
-			continue
-		}
-
-		metadata := make(map[string]interface{})
-		for k, v := range res.Payload {
-			if k != "text" {
-				metadata[k] = v
-			}
-		}
-		metadata["score"] = res.Score           // Adicionar score aos metadados
-		metadata["collection"] = collectionName // Adicionar nome da coleção aos metadados
-
-		documents = append(documents, schema.Document{
-			PageContent: text,
-			Metadata:    metadata,
-		})
+	results := make([]scoredPayload, len(points))
+	for i, p := range points {
+		results[i] = scoredPayload{id: fmt.Sprint(p.ID), score: p.Score, payload: p.Payload}
 	}
-
-	return documents, nil
+	return resultDocuments(results, collectionName), nil
 }
 
-// ListCollections lista todas as coleções disponíveis no Qdrant
-func (r *QdrantRetriever) ListCollections(ctx context.Context) ([]string, error) {
-	url := fmt.Sprintf("%s/collections", r.baseURL)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create list collections request: %w", err)
-	}
-
-	resp, err := r.client.Do(req)
+// GetRelevantDocuments implements the usecase.Retriever interface.
+// It embeds the query and then calls SimilaritySearch on the configured
+// collection (see WithCollectionName and WithNumDocuments).
+func (s *QdrantVectorStore) GetRelevantDocuments(ctx context.Context, query string, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	queryEmbedding, err := s.embedder.EmbedQuery(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute list collections request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to list collections, status: %d, response: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	// Decodificar resposta JSON considerando o formato retornado pelo Qdrant
-	// A estrutura esperada é algo como:
-	// {
-	//   "result": {
-	//     "collections": [
-	//       { "name": "collection1", ... },
-	//       { "name": "collection2", ... }
-	//     ]
-	//   },
-	//   "status": "ok",
-	//   "time": 0.0001
-	// }
-	var result struct {
-		Result struct {
-			Collections []struct {
-				Name string `json:"name"`
-			} `json:"collections"`
-		} `json:"result"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode list collections response: %w", err)
+		return nil, fmt.Errorf("failed to embed query for retrieval: %w", err)
 	}
 
-	// Extrair nomes das coleções
-	collections := make([]string, 0, len(result.Result.Collections))
-	for _, coll := range result.Result.Collections {
-		collections = append(collections, coll.Name)
-	}
+	return s.SimilaritySearch(ctx, s.collectionName, queryEmbedding, s.numDocuments, opts...)
+}
 
-	return collections, nil
+// ListCollections returns the names of the collections of the server.
+func (s *QdrantVectorStore) ListCollections(ctx context.Context) ([]string, error) {
+	return s.client.ListCollections(ctx)
 }
 
-// SearchAllCollections busca documentos em todas as coleções existentes
-func (r *QdrantRetriever) SearchAllCollections(ctx context.Context, queryEmbedding []float32, numDocsPerCollection int) ([]schema.Document, error) {
-	collections, err := r.ListCollections(ctx)
+// SearchAllCollections calls SimilaritySearch on every collection, skipping
+// with a warning those that cannot be searched.
+func (s *QdrantVectorStore) SearchAllCollections(ctx context.Context, queryEmbedding []float32, numDocsPerCollection int, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	collections, err := s.ListCollections(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list collections: %w", err)
 	}
 
 	var allDocs []schema.Document
 	for _, collection := range collections {
-		docs, err := r.SimilaritySearch(ctx, collection, queryEmbedding, numDocsPerCollection)
+		docs, err := s.SimilaritySearch(ctx, collection, queryEmbedding, numDocsPerCollection, opts...)
 		if err != nil {
 			log.Printf("Warning: Failed to search collection %s: %v", collection, err)
 			continue
@@ -511,5 +365,41 @@ func (r *QdrantRetriever) SearchAllCollections(ctx context.Context, queryEmbeddi
 	return allDocs, nil
 }
 
-// Ensure QdrantRetriever implements the interface
-var _ usecase.Retriever = (*QdrantRetriever)(nil)
+// --- Helper Methods ---
+
+func (s *QdrantVectorStore) createCollection(ctx context.Context, collectionName string, vectorSize int) error {
+	createReq := qdrant.CreateCollectionRequest{
+		Vectors: map[string]qdrant.VectorParams{
+			"default": {
+				Size:     vectorSize,
+				Distance: defaultDistance,
+			},
+		},
+	}
+	if s.sparse {
+		createReq.SparseVectors = map[string]qdrant.SparseVectorParams{sparseVectorName: {}}
+	}
+	if s.embeddingModel != "" {
+		supported, err := s.client.SupportsCollectionMetadata(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create collection '%s': %w", collectionName, err)
+		}
+		if supported {
+			createReq.Metadata = map[string]interface{}{metadataEmbeddingModel: s.embeddingModel}
+		} else {
+			version, _ := s.client.ServerVersion(ctx)
+			log.Printf("Warning: Qdrant %s does not support collection metadata, creating '%s' without recording the embedding model", version, collectionName)
+		}
+	}
+
+	if err := s.client.CreateCollection(ctx, collectionName, createReq); err != nil {
+		return err
+	}
+
+	fmt.Printf("Collection '%s' created successfully\n", collectionName)
+	return nil
+}
+
+// Ensure QdrantVectorStore implements the interfaces
+var _ usecase.VectorStore = (*QdrantVectorStore)(nil)
+var _ usecase.Retriever = (*QdrantVectorStore)(nil)
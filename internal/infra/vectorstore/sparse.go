@@ -0,0 +1,51 @@
+package vectorstore
+
+import (
+	"context"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/qdrant"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// sparseVectorName is the sparse vector stored next to the "default" dense
+// vector for hybrid search.
+const sparseVectorName = "bm25"
+
+// WithSparseVectors makes the adapter create collections with a sparse
+// vector for hybrid search (see usecase.SparseVectorStore). EnsureCollection
+// then rejects existing collections without it.
+func WithSparseVectors(enabled bool) Option {
+	return func(o *options) {
+		o.sparse = enabled
+	}
+}
+
+// AddDocumentsWithSparse adds documents with pre-generated embeddings and
+// sparse vectors to the specified collection.
+func (s *QdrantVectorStore) AddDocumentsWithSparse(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32, sparse []usecase.SparseVector) ([]string, error) {
+	return s.upsert(ctx, collectionName, docs, embeddings, sparse)
+}
+
+// SparseSearch searches the sparse vectors of the collection, restricted to
+// the points matching the filter of the options, if any. It fails early
+// when the collection has no sparse vectors.
+func (s *QdrantVectorStore) SparseSearch(ctx context.Context, collectionName string, vector usecase.SparseVector, numDocuments int, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	if err := s.checker.checkSparse(ctx, collectionName); err != nil {
+		return nil, err
+	}
+	return s.search(ctx, collectionName, qdrant.NamedSparseVector{
+		Name:   sparseVectorName,
+		Vector: qdrant.SparseVector{Indices: vector.Indices, Values: vector.Values},
+	}, numDocuments, opts)
+}
+
+// GetRelevantSparseDocuments calls SparseSearch on the collection of
+// GetRelevantDocuments.
+func (s *QdrantVectorStore) GetRelevantSparseDocuments(ctx context.Context, vector usecase.SparseVector, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	return s.SparseSearch(ctx, s.collectionName, vector, s.numDocuments, opts...)
+}
+
+// Ensure the adapter implements the hybrid search interfaces
+var _ usecase.SparseVectorStore = (*QdrantVectorStore)(nil)
+var _ usecase.SparseSearcher = (*QdrantVectorStore)(nil)
@@ -0,0 +1,113 @@
+// Package jobs runs ingestion work in the background and keeps a record of
+// every job, so that progress can be polled or streamed and recent history
+// survives a server restart.
+package jobs
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// Status is the lifecycle state of a job.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Done reports whether the job reached a final state.
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCancelled
+}
+
+// StageQueued marks a file that was not picked up by the pipeline yet.
+const StageQueued usecase.IngestionStage = "queued"
+
+var (
+	// ErrNotFound is returned for unknown job IDs.
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when cancelling a job that already finished.
+	ErrFinished = errors.New("job already finished")
+)
+
+// FileProgress tracks one file of a job.
+type FileProgress struct {
+	Name       string                 `json:"name"` // Name of the uploaded file
+	Path       string                 `json:"path"` // Path the file was saved to
+	Collection string                 `json:"collection,omitempty"`
+	Stage      usecase.IngestionStage `json:"stage"`
+	Chunks     int                    `json:"chunks"` // Chunks produced by the splitter
+	Stored     int                    `json:"stored"` // Chunks stored so far
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Job is the persisted record of an ingestion job.
+type Job struct {
+	ID         string                     `json:"id"`
+	Status     Status                     `json:"status"`
+	PerPDF     bool                       `json:"per_pdf"`
+	CreatedAt  time.Time                  `json:"created_at"`
+	StartedAt  *time.Time                 `json:"started_at,omitempty"`
+	FinishedAt *time.Time                 `json:"finished_at,omitempty"`
+	Files      []FileProgress             `json:"files"`
+	Reports    []*usecase.IngestionReport `json:"reports,omitempty"`
+	Message    string                     `json:"message,omitempty"`
+	Error      string                     `json:"error,omitempty"`
+}
+
+// FilesDone returns how many files reached a final stage.
+func (j *Job) FilesDone() int {
+	done := 0
+	for _, f := range j.Files {
+		switch f.Stage {
+		case usecase.StageCompleted, usecase.StageUnchanged, usecase.StageFailed:
+			done++
+		}
+	}
+	return done
+}
+
+// clone returns a copy that can be handed out while the job keeps running.
+func (j *Job) clone() *Job {
+	c := *j
+	c.Files = append([]FileProgress(nil), j.Files...)
+	c.Reports = append([]*usecase.IngestionReport(nil), j.Reports...)
+	return &c
+}
+
+// apply records an ingestion event on the matching file.
+func (j *Job) apply(ev usecase.IngestionEvent) {
+	if ev.File == "" {
+		return
+	}
+	for i := range j.Files {
+		f := &j.Files[i]
+		if f.Path != ev.File {
+			continue
+		}
+		if ev.Collection != "" {
+			f.Collection = ev.Collection
+		}
+		switch ev.Stage {
+		case usecase.StageSplit:
+			f.Chunks = ev.Chunks
+		case usecase.StageUpserted:
+			f.Stored += ev.Chunks
+		case usecase.StageFailed:
+			f.Error = ev.Error
+		}
+		// Keep the final stage once reached; late batches must not hide it
+		switch f.Stage {
+		case usecase.StageCompleted, usecase.StageUnchanged, usecase.StageFailed:
+		default:
+			f.Stage = ev.Stage
+		}
+		return
+	}
+}
@@ -0,0 +1,338 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+// RunFunc performs the work of a job. It must stop when ctx is cancelled and
+// report progress through observer.
+type RunFunc func(ctx context.Context, observer usecase.IngestionObserver) ([]*usecase.IngestionReport, error)
+
+// Event types delivered to subscribers.
+const (
+	EventStatus   = "status"   // The job status changed
+	EventProgress = "progress" // A file of the job made progress
+)
+
+// Event is a change of a job delivered to subscribers.
+type Event struct {
+	Type      string                  `json:"type"`
+	Ingestion *usecase.IngestionEvent `json:"ingestion,omitempty"` // Set on EventProgress
+	Job       *Job                    `json:"job"`                 // Snapshot after the change
+}
+
+// subscriberBuffer is the number of events buffered per subscriber. Slow
+// subscribers miss intermediate events but always see the final state.
+const subscriberBuffer = 64
+
+// Manager queues jobs, runs a bounded number of them concurrently and keeps
+// their records in a FileStore.
+type Manager struct {
+	store   *FileStore
+	history int
+	slots   chan struct{}
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	cancels map[string]context.CancelFunc
+	subs    map[string]map[chan Event]struct{}
+}
+
+// NewManager loads the job history from store. Jobs that were still queued
+// or running when the server stopped are marked as failed. At most workers
+// jobs run at the same time and the newest history finished jobs are kept.
+func NewManager(store *FileStore, workers, history int) (*Manager, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	stored, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		store:   store,
+		history: history,
+		slots:   make(chan struct{}, workers),
+		jobs:    make(map[string]*Job, len(stored)),
+		cancels: make(map[string]context.CancelFunc),
+		subs:    make(map[string]map[chan Event]struct{}),
+	}
+
+	for _, job := range stored {
+		if !job.Status.Done() {
+			m.finish(job, StatusFailed, "interrupted by server restart")
+			m.save(job)
+		}
+		m.jobs[job.ID] = job
+	}
+	m.prune()
+
+	return m, nil
+}
+
+// Submit records the job and queues it. ID, status and timestamps are set by
+// the manager; Files should list the files the job will process.
+func (m *Manager) Submit(job *Job, run RunFunc) *Job {
+	job.ID = uuid.NewString()
+	job.Status = StatusQueued
+	job.CreatedAt = time.Now()
+	for i := range job.Files {
+		if job.Files[i].Stage == "" {
+			job.Files[i].Stage = StageQueued
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.cancels[job.ID] = cancel
+	m.save(job)
+	snapshot := job.clone()
+	m.prune()
+	m.mu.Unlock()
+
+	go m.run(ctx, job.ID, run)
+	return snapshot
+}
+
+// Get returns a snapshot of the job.
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return job.clone(), nil
+}
+
+// List returns snapshots of up to limit jobs, newest first. A non-positive
+// limit returns every job.
+func (m *Manager) List(limit int) []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		list = append(list, job.clone())
+	}
+	sort.Slice(list, func(i, k int) bool {
+		return list[i].CreatedAt.After(list[k].CreatedAt)
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+// Cancel stops a queued or running job.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if job.Status.Done() {
+		return ErrFinished
+	}
+	m.cancels[id]()
+	return nil
+}
+
+// Subscribe returns a channel receiving the job events. The channel is
+// closed when the job finishes; it is returned already closed for finished
+// jobs. The returned function releases the subscription.
+func (m *Manager) Subscribe(id string) (<-chan Event, func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	if job.Status.Done() {
+		close(ch)
+		return ch, func() {}, nil
+	}
+
+	if m.subs[id] == nil {
+		m.subs[id] = make(map[chan Event]struct{})
+	}
+	m.subs[id][ch] = struct{}{}
+
+	unsubscribe := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.subs[id][ch]; ok {
+			delete(m.subs[id], ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe, nil
+}
+
+// run waits for a free slot, then runs the job and records its outcome.
+func (m *Manager) run(ctx context.Context, id string, run RunFunc) {
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		m.complete(id, nil, ctx.Err(), ctx.Err())
+		return
+	}
+
+	m.mu.Lock()
+	job := m.jobs[id]
+	now := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &now
+	m.save(job)
+	m.publish(id, Event{Type: EventStatus, Job: job.clone()})
+	m.mu.Unlock()
+
+	observer := usecase.IngestionObserverFunc(func(ev usecase.IngestionEvent) {
+		m.progress(id, ev)
+	})
+	reports, err := run(ctx, observer)
+	m.complete(id, reports, err, ctx.Err())
+}
+
+// progress applies an ingestion event to the job and notifies subscribers.
+// The record is saved whenever a file reaches a final stage.
+func (m *Manager) progress(id string, ev usecase.IngestionEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.jobs[id]
+	job.apply(ev)
+	switch ev.Stage {
+	case usecase.StageCompleted, usecase.StageUnchanged, usecase.StageFailed:
+		m.save(job)
+	}
+	m.publish(id, Event{Type: EventProgress, Ingestion: &ev, Job: job.clone()})
+}
+
+// complete records the outcome of a job and closes its subscriptions.
+func (m *Manager) complete(id string, reports []*usecase.IngestionReport, err, ctxErr error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.jobs[id]
+	job.Reports = reports
+	switch {
+	case ctxErr != nil:
+		m.finish(job, StatusCancelled, "cancelled")
+	case err != nil:
+		m.finish(job, StatusFailed, err.Error())
+	default:
+		m.finish(job, StatusSucceeded, "")
+	}
+	m.save(job)
+
+	m.publish(id, Event{Type: EventStatus, Job: job.clone()})
+	for ch := range m.subs[id] {
+		close(ch)
+	}
+	delete(m.subs, id)
+	if cancel, ok := m.cancels[id]; ok {
+		cancel()
+		delete(m.cancels, id)
+	}
+}
+
+// finish sets the final status of a job. Files that did not reach a final
+// stage are marked as failed with reason.
+func (m *Manager) finish(job *Job, status Status, reason string) {
+	now := time.Now()
+	job.Status = status
+	job.FinishedAt = &now
+	if status != StatusSucceeded {
+		job.Error = reason
+	}
+
+	fileReason := reason
+	if fileReason == "" {
+		fileReason = "not processed"
+	}
+
+	failed := 0
+	for i := range job.Files {
+		f := &job.Files[i]
+		switch f.Stage {
+		case usecase.StageCompleted, usecase.StageUnchanged:
+			continue
+		case usecase.StageFailed:
+		default:
+			f.Stage = usecase.StageFailed
+			if f.Error == "" {
+				f.Error = fileReason
+			}
+		}
+		failed++
+	}
+	job.Message = fmt.Sprintf("%d files processed, %d failed", len(job.Files)-failed, failed)
+}
+
+// publish sends ev to the subscribers of the job without blocking.
+// Callers must hold m.mu.
+func (m *Manager) publish(id string, ev Event) {
+	for ch := range m.subs[id] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// save persists the job record, logging failures. Callers must hold m.mu
+// for jobs known to the manager.
+func (m *Manager) save(job *Job) {
+	if err := m.store.Save(job); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// prune drops the oldest finished jobs beyond the history limit.
+// Callers must hold m.mu.
+func (m *Manager) prune() {
+	if m.history <= 0 {
+		return
+	}
+
+	var finished []*Job
+	for _, job := range m.jobs {
+		if job.Status.Done() {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= m.history {
+		return
+	}
+
+	sort.Slice(finished, func(i, k int) bool {
+		return finished[i].CreatedAt.After(finished[k].CreatedAt)
+	})
+	for _, job := range finished[m.history:] {
+		delete(m.jobs, job.ID)
+		if err := m.store.Delete(job.ID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
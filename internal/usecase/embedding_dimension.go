@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrIncompatibleCollection is returned (wrapped) by vector stores when a
+// collection was created for vectors of another dimension, distance or
+// embedding model than the ones in use.
+var ErrIncompatibleCollection = errors.New("collection is incompatible with the embedding model")
+
+// dimensionProbeText is embedded to measure embedders that do not
+// implement DimensionReporter.
+const dimensionProbeText = "dimension probe"
+
+// EmbeddingDimension returns the dimension of the vectors produced by
+// embedder, asking it when it is a DimensionReporter and embedding a short
+// probe text otherwise.
+func EmbeddingDimension(ctx context.Context, embedder EmbeddingGenerator) (int, error) {
+	if reporter, ok := embedder.(DimensionReporter); ok {
+		return reporter.Dimension(ctx)
+	}
+	return ProbeDimension(ctx, embedder)
+}
+
+// ProbeDimension returns the dimension of the vectors produced by embedder
+// by embedding a short probe text. DimensionReporter implementations can
+// use it, and cache its result, to measure their model.
+func ProbeDimension(ctx context.Context, embedder EmbeddingGenerator) (int, error) {
+	vector, err := embedder.EmbedQuery(ctx, dimensionProbeText)
+	if err != nil {
+		return 0, fmt.Errorf("failed to probe embedding dimension: %w", err)
+	}
+	if len(vector) == 0 {
+		return 0, errors.New("failed to probe embedding dimension: embedder returned an empty vector")
+	}
+	return len(vector), nil
+}
+
+// ResolveVectorSize returns the vector size of the collections: the
+// dimension of embedder, which configured (when positive) must match.
+func ResolveVectorSize(ctx context.Context, embedder EmbeddingGenerator, configured int) (int, error) {
+	dimension, err := EmbeddingDimension(ctx, embedder)
+	if err != nil {
+		return 0, err
+	}
+	if configured > 0 && configured != dimension {
+		return 0, fmt.Errorf("configured vector size %d does not match the embedding model, which produces %d-dimensional vectors", configured, dimension)
+	}
+	return dimension, nil
+}
@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MetadataRelativePath is the metadata key holding the path of the source
+// relative to the ingestion directory, with forward slashes.
+const MetadataRelativePath = "relative_path"
+
+// FileSelection controls how the ingestion directory is walked.
+type FileSelection struct {
+	MaxFileSize    int64 // Bytes; larger files are skipped. 0 disables the limit
+	FollowSymlinks bool  // Follow symbolic links to files and directories
+	IncludeHidden  bool  // Ingest files and directories whose name starts with "."
+}
+
+// DefaultFileSelection follows symbolic links, like filepath.Glob did, and
+// skips hidden entries.
+func DefaultFileSelection() FileSelection {
+	return FileSelection{FollowSymlinks: true}
+}
+
+// WithFileSelection sets how the ingestion directory is walked.
+func WithFileSelection(s FileSelection) IngestionOption {
+	return func(uc *IngestionUseCase) {
+		uc.selection = s
+	}
+}
+
+// filePatterns holds the include and exclude globs of a file pattern list.
+//
+// A pattern list holds comma-separated globs matched against the path of
+// each file relative to the ingestion directory, with forward slashes. "**"
+// matches any number of directories and patterns starting with "!" exclude
+// files, e.g. "**/*.pdf,**/*.md,!**/drafts/**". Without include patterns,
+// every file is included.
+type filePatterns struct {
+	include []string
+	exclude []string
+}
+
+// parseFilePatterns parses and validates a pattern list.
+func parseFilePatterns(list string) (filePatterns, error) {
+	var p filePatterns
+	for _, pattern := range splitPatternList(list) {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "./")
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return filePatterns{}, fmt.Errorf("invalid file pattern '%s': %w", pattern, err)
+			}
+		}
+		if exclude {
+			p.exclude = append(p.exclude, pattern)
+		} else {
+			p.include = append(p.include, pattern)
+		}
+	}
+	if len(p.include) == 0 {
+		p.include = []string{"**"}
+	}
+	return p, nil
+}
+
+// splitPatternList splits a pattern list on commas not escaped with a backslash.
+func splitPatternList(list string) []string {
+	var patterns []string
+	var current strings.Builder
+	flush := func() {
+		if pattern := strings.TrimSpace(current.String()); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+		current.Reset()
+	}
+	for i := 0; i < len(list); i++ {
+		switch {
+		case list[i] == '\\' && i+1 < len(list) && list[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case list[i] == ',':
+			flush()
+		default:
+			current.WriteByte(list[i])
+		}
+	}
+	flush()
+	return patterns
+}
+
+// QuoteFilePattern returns a pattern list matching exactly the file name,
+// escaping glob metacharacters, commas and a leading "!".
+func QuoteFilePattern(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch r {
+		case '\\', '*', '?', '[', ',':
+			b.WriteByte('\\')
+		case '!':
+			if i == 0 {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// matches reports whether the relative file path is selected.
+func (p filePatterns) matches(rel string) bool {
+	return matchAny(p.include, rel) && !matchAny(p.exclude, rel)
+}
+
+// excludesDir reports whether a directory is excluded as a whole, so that it
+// is not walked.
+func (p filePatterns) excludesDir(rel string) bool {
+	return matchAny(p.exclude, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	name := strings.Split(rel, "/")
+	for _, pattern := range patterns {
+		if matchSegments(strings.Split(pattern, "/"), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against pattern segments, where "**"
+// matches zero or more segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// discoveredFile is a file selected for ingestion.
+type discoveredFile struct {
+	path string // dirPath joined with rel, as stored in the "source" metadata
+	rel  string // Relative to dirPath, with forward slashes
+}
+
+// discoverFiles walks dirPath recursively and returns the files selected by
+// the patterns and the file selection, sorted by relative path.
+func (uc *IngestionUseCase) discoverFiles(dirPath string, patterns filePatterns) ([]discoveredFile, error) {
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in '%s': %w", dirPath, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("failed to list files in '%s': not a directory", dirPath)
+	}
+
+	// Directories reached through symbolic links are walked once, which
+	// also breaks link cycles
+	visited := make(map[string]bool)
+	if real, err := filepath.EvalSymlinks(dirPath); err == nil {
+		visited[real] = true
+	}
+
+	var files []discoveredFile
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("failed to list files in '%s': %w", dir, err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if strings.HasPrefix(name, ".") && !uc.selection.IncludeHidden {
+				continue
+			}
+			entryPath := filepath.Join(dir, name)
+			entryRel := path.Join(rel, name)
+
+			info, err := entry.Info()
+			if err != nil {
+				log.Printf("Warning: Skipping %s: %v", entryPath, err)
+				continue
+			}
+			linked := info.Mode()&os.ModeSymlink != 0
+			if linked {
+				if !uc.selection.FollowSymlinks {
+					log.Printf("Skipping symbolic link: %s", entryPath)
+					continue
+				}
+				if info, err = os.Stat(entryPath); err != nil {
+					log.Printf("Warning: Skipping broken symbolic link %s: %v", entryPath, err)
+					continue
+				}
+			}
+
+			switch {
+			case info.IsDir():
+				if patterns.excludesDir(entryRel) {
+					continue
+				}
+				if linked {
+					real, err := filepath.EvalSymlinks(entryPath)
+					if err != nil || visited[real] {
+						continue
+					}
+					visited[real] = true
+				}
+				if err := walk(entryPath, entryRel); err != nil {
+					return err
+				}
+			case info.Mode().IsRegular():
+				if !patterns.matches(entryRel) {
+					continue
+				}
+				if max := uc.selection.MaxFileSize; max > 0 && info.Size() > max {
+					log.Printf("Warning: Skipping %s: size %d bytes exceeds the limit of %d bytes", entryPath, info.Size(), max)
+					continue
+				}
+				files = append(files, discoveredFile{path: entryPath, rel: entryRel})
+			}
+		}
+		return nil
+	}
+	if err := walk(dirPath, ""); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+	return files, nil
+}
@@ -0,0 +1,491 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter restricts a similarity search to the documents whose metadata
+// satisfies every Must condition, at least one Should condition (when there
+// are any) and no MustNot condition. A nil Filter matches every document.
+type Filter struct {
+	Must    []Condition
+	Should  []Condition
+	MustNot []Condition
+}
+
+// FilterOp is the kind of test a Condition applies to a metadata field.
+type FilterOp string
+
+const (
+	// FilterMatch tests that the field equals Value.
+	FilterMatch FilterOp = "match"
+	// FilterIn tests that the field equals one of Values.
+	FilterIn FilterOp = "in"
+	// FilterRange tests that the numeric field is within Range.
+	FilterRange FilterOp = "range"
+	// FilterDateRange tests that the date field (RFC 3339) is within DateRange.
+	FilterDateRange FilterOp = "date_range"
+	// FilterExists tests that the field is set and not empty.
+	FilterExists FilterOp = "exists"
+)
+
+// Condition tests one metadata field. Values are strings, integers (int64)
+// or, for FilterMatch only, booleans.
+type Condition struct {
+	Key       string
+	Op        FilterOp
+	Value     interface{}   // FilterMatch
+	Values    []interface{} // FilterIn
+	Range     Range         // FilterRange
+	DateRange DateRange     // FilterDateRange
+}
+
+// Range bounds a numeric field; nil bounds are open.
+type Range struct {
+	Gt, Gte, Lt, Lte *float64
+}
+
+// DateRange bounds a date field; nil bounds are open.
+type DateRange struct {
+	Gt, Gte, Lt, Lte *time.Time
+}
+
+// Match returns a condition testing that key equals value.
+func Match(key string, value interface{}) Condition {
+	return Condition{Key: key, Op: FilterMatch, Value: value}
+}
+
+// MatchAny returns a condition testing that key equals one of values.
+func MatchAny(key string, values ...interface{}) Condition {
+	return Condition{Key: key, Op: FilterIn, Values: values}
+}
+
+// InRange returns a condition testing that the numeric key is within r.
+func InRange(key string, r Range) Condition {
+	return Condition{Key: key, Op: FilterRange, Range: r}
+}
+
+// InDateRange returns a condition testing that the date key is within r.
+func InDateRange(key string, r DateRange) Condition {
+	return Condition{Key: key, Op: FilterDateRange, DateRange: r}
+}
+
+// Exists returns a condition testing that key is set and not empty.
+func Exists(key string) Condition {
+	return Condition{Key: key, Op: FilterExists}
+}
+
+// IsEmpty reports whether f has no condition.
+func (f *Filter) IsEmpty() bool {
+	return f == nil || len(f.Must)+len(f.Should)+len(f.MustNot) == 0
+}
+
+// Validate reports the invalid conditions, all together.
+func (f *Filter) Validate() error {
+	if f == nil {
+		return nil
+	}
+	var errs []error
+	for _, clause := range f.clauses() {
+		for _, c := range clause.conditions {
+			if err := c.validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%s condition on %s: %w", clause.name, c.Key, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c Condition) validate() error {
+	if c.Key == "" {
+		return fmt.Errorf("missing field name")
+	}
+	switch c.Op {
+	case FilterMatch:
+		switch c.Value.(type) {
+		case string, int64, bool:
+		default:
+			return fmt.Errorf("match value must be a string, an integer or a boolean, got %T", c.Value)
+		}
+	case FilterIn:
+		if len(c.Values) == 0 {
+			return fmt.Errorf("no value to match")
+		}
+		for _, v := range c.Values {
+			switch v.(type) {
+			case string, int64:
+			default:
+				return fmt.Errorf("values must be strings or integers, got %T", v)
+			}
+			if fmt.Sprintf("%T", v) != fmt.Sprintf("%T", c.Values[0]) {
+				return fmt.Errorf("values must be all strings or all integers")
+			}
+		}
+	case FilterRange:
+		r := c.Range
+		if r.Gt == nil && r.Gte == nil && r.Lt == nil && r.Lte == nil {
+			return fmt.Errorf("range without bounds")
+		}
+	case FilterDateRange:
+		r := c.DateRange
+		if r.Gt == nil && r.Gte == nil && r.Lt == nil && r.Lte == nil {
+			return fmt.Errorf("date range without bounds")
+		}
+	case FilterExists:
+	default:
+		return fmt.Errorf("unknown operator %q", c.Op)
+	}
+	return nil
+}
+
+type filterClause struct {
+	name       string
+	conditions []Condition
+}
+
+func (f *Filter) clauses() []filterClause {
+	return []filterClause{{"must", f.Must}, {"should", f.Should}, {"must_not", f.MustNot}}
+}
+
+// String formats f in the syntax accepted by ParseFilter, for logging.
+// Ranges bounded on both sides take two conditions.
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	var parts []string
+	for _, clause := range f.clauses() {
+		for _, c := range clause.conditions {
+			prefix := ""
+			if clause.name != "must" {
+				prefix = clause.name + ":"
+			}
+			for _, expr := range c.expressions() {
+				parts = append(parts, prefix+expr)
+			}
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// expressions formats c in the syntax of ParseFilter, one expression per
+// range bound.
+func (c Condition) expressions() []string {
+	switch c.Op {
+	case FilterMatch:
+		return []string{c.Key + "=" + formatFilterValue(c.Value)}
+	case FilterIn:
+		values := make([]string, len(c.Values))
+		for i, v := range c.Values {
+			values[i] = formatFilterValue(v)
+		}
+		return []string{c.Key + "=" + strings.Join(values, "|")}
+	case FilterExists:
+		return []string{c.Key + "=*"}
+	}
+
+	var exprs []string
+	bound := func(op string, value string) {
+		exprs = append(exprs, c.Key+op+value)
+	}
+	if c.Op == FilterRange {
+		for _, b := range []struct {
+			op string
+			v  *float64
+		}{{">", c.Range.Gt}, {">=", c.Range.Gte}, {"<", c.Range.Lt}, {"<=", c.Range.Lte}} {
+			if b.v != nil {
+				bound(b.op, strconv.FormatFloat(*b.v, 'g', -1, 64))
+			}
+		}
+	} else {
+		for _, b := range []struct {
+			op string
+			v  *time.Time
+		}{{">", c.DateRange.Gt}, {">=", c.DateRange.Gte}, {"<", c.DateRange.Lt}, {"<=", c.DateRange.Lte}} {
+			if b.v != nil {
+				bound(b.op, b.v.Format(time.RFC3339))
+			}
+		}
+	}
+	return exprs
+}
+
+// formatFilterValue quotes the strings that would otherwise be read as
+// another type or split.
+func formatFilterValue(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	if s == "" || s == "*" || strings.ContainsAny(s, `,|"`) || s != strings.TrimSpace(s) {
+		return strconv.Quote(s)
+	}
+	if _, isString := scalarFilterValue(s).(string); !isString {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// ParseFilter parses filter expressions holding comma-separated conditions:
+//
+//	key=value         the field equals value
+//	key=a|b|c         the field equals one of the values
+//	key=*             the field is set and not empty
+//	key>n, key>=n,    the field is a number or a date (RFC 3339 or
+//	key<n, key<=n     2006-01-02) within the bound
+//
+// "!=" negates "=" (key!=value, key!=a|b, key!=*). A "should:" prefix makes
+// the condition optional, at least one of them having to hold, and a
+// "must_not:" prefix excludes the documents satisfying it. Unquoted values
+// that are integers or true/false are matched as such; double-quoted
+// values are strings. Conditions on MetadataSource must name the path of
+// the file as ingested: a bare file name such as source=foo.pdf is rejected
+// rather than silently matching nothing. It returns nil when there is no
+// condition.
+func ParseFilter(exprs ...string) (*Filter, error) {
+	var f Filter
+	for _, expr := range exprs {
+		for _, part := range splitUnquoted(expr, ',') {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			if err := f.parseCondition(part); err != nil {
+				return nil, fmt.Errorf("invalid filter condition %q: %w", part, err)
+			}
+		}
+	}
+	if f.IsEmpty() {
+		return nil, nil
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (f *Filter) parseCondition(expr string) error {
+	clause := &f.Must
+	for _, p := range []struct {
+		prefix string
+		clause *[]Condition
+	}{{"must:", &f.Must}, {"should:", &f.Should}, {"must_not:", &f.MustNot}} {
+		if strings.HasPrefix(expr, p.prefix) {
+			clause = p.clause
+			expr = strings.TrimSpace(strings.TrimPrefix(expr, p.prefix))
+			break
+		}
+	}
+
+	i := strings.IndexAny(expr, "=!<>")
+	if i < 0 {
+		return fmt.Errorf("missing operator (=, !=, >, >=, < or <=)")
+	}
+	key := strings.TrimSpace(expr[:i])
+	if key == "" {
+		return fmt.Errorf("missing field name")
+	}
+	op := expr[i : i+1]
+	if i+1 < len(expr) && expr[i+1] == '=' {
+		op += "="
+	}
+	if op == "!" {
+		return fmt.Errorf("missing operator (=, !=, >, >=, < or <=)")
+	}
+	value := strings.TrimSpace(expr[i+len(op):])
+	if value == "" {
+		return fmt.Errorf("missing value")
+	}
+
+	switch op {
+	case "=", "!=":
+		c, err := parseMatch(key, value)
+		if err != nil {
+			return err
+		}
+		if err := checkSourcePaths(c); err != nil {
+			return err
+		}
+		if op == "!=" {
+			switch clause {
+			case &f.Must:
+				clause = &f.MustNot
+			case &f.MustNot:
+				clause = &f.Must
+			default:
+				return fmt.Errorf("!= cannot be used in a should condition")
+			}
+		}
+		*clause = append(*clause, c)
+	default:
+		c, err := parseBound(key, op, value)
+		if err != nil {
+			return err
+		}
+		*clause = append(*clause, c)
+	}
+	return nil
+}
+
+// parseMatch parses the value of an "=" condition.
+func parseMatch(key, value string) (Condition, error) {
+	if value == "*" {
+		return Exists(key), nil
+	}
+	alternatives := splitUnquoted(value, '|')
+	if len(alternatives) == 1 {
+		v, err := parseFilterValue(value)
+		if err != nil {
+			return Condition{}, err
+		}
+		return Match(key, v), nil
+	}
+	values := make([]interface{}, len(alternatives))
+	for i, a := range alternatives {
+		v, err := parseFilterValue(strings.TrimSpace(a))
+		if err != nil {
+			return Condition{}, err
+		}
+		values[i] = v
+	}
+	return MatchAny(key, values...), nil
+}
+
+// checkSourcePaths rejects a match on MetadataSource naming a bare file,
+// without a directory. Sources hold the path given to the ingestion, so
+// such a value would only match files ingested from the working directory.
+func checkSourcePaths(c Condition) error {
+	if c.Key != MetadataSource || (c.Op != FilterMatch && c.Op != FilterIn) {
+		return nil
+	}
+	values := c.Values
+	if c.Op == FilterMatch {
+		values = []interface{}{c.Value}
+	}
+	for _, v := range values {
+		name, ok := v.(string)
+		if !ok || strings.ContainsAny(name, `/\`) {
+			continue
+		}
+		return fmt.Errorf("%s holds the path of the file as ingested (e.g. data/pdfs/%s); use %s=%s to match the file within the ingestion directory",
+			MetadataSource, name, MetadataRelativePath, formatFilterValue(name))
+	}
+	return nil
+}
+
+// parseBound parses a range condition, numeric or date.
+func parseBound(key, op, value string) (Condition, error) {
+	if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+		var r Range
+		switch op {
+		case ">":
+			r.Gt = &n
+		case ">=":
+			r.Gte = &n
+		case "<":
+			r.Lt = &n
+		case "<=":
+			r.Lte = &n
+		}
+		return InRange(key, r), nil
+	}
+	t, err := parseFilterDate(strings.Trim(value, `"`))
+	if err != nil {
+		return Condition{}, fmt.Errorf("%q is neither a number nor a date (RFC 3339 or 2006-01-02)", value)
+	}
+	var r DateRange
+	switch op {
+	case ">":
+		r.Gt = &t
+	case ">=":
+		r.Gte = &t
+	case "<":
+		r.Lt = &t
+	case "<=":
+		r.Lte = &t
+	}
+	return InDateRange(key, r), nil
+}
+
+func parseFilterDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// parseFilterValue parses a quoted string or an unquoted scalar.
+func parseFilterValue(s string) (interface{}, error) {
+	if strings.HasPrefix(s, `"`) {
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted value %s", s)
+		}
+		return v, nil
+	}
+	if s == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+	return scalarFilterValue(s), nil
+}
+
+// scalarFilterValue reads an unquoted value as an integer, a boolean or a string.
+func scalarFilterValue(s string) interface{} {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+		return b
+	}
+	return s
+}
+
+// splitUnquoted splits s at each sep outside double quotes.
+func splitUnquoted(s string, sep byte) []string {
+	var parts []string
+	inQuotes, escaped := false, false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\' && inQuotes:
+			escaped = true
+		case s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// SearchOptions tune a similarity search.
+type SearchOptions struct {
+	Filter *Filter // Restricts the documents searched; nil searches them all
+}
+
+// SearchOption adjusts the SearchOptions of a similarity search.
+type SearchOption func(*SearchOptions)
+
+// WithFilter restricts the search to the documents matching f.
+func WithFilter(f *Filter) SearchOption {
+	return func(o *SearchOptions) {
+		o.Filter = f
+	}
+}
+
+// NewSearchOptions applies opts, in order, to empty SearchOptions.
+func NewSearchOptions(opts ...SearchOption) SearchOptions {
+	var o SearchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,147 @@
+package usecase_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+)
+
+func float(v float64) *float64 { return &v }
+
+func date(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		exprs []string
+		want  *usecase.Filter
+	}{
+		{"no condition", []string{"", " , "}, nil},
+		{"string match", []string{"lang=pt"}, &usecase.Filter{Must: []usecase.Condition{usecase.Match("lang", "pt")}}},
+		{"integer match", []string{"page=3"}, &usecase.Filter{Must: []usecase.Condition{usecase.Match("page", int64(3))}}},
+		{"boolean match", []string{"draft=false"}, &usecase.Filter{Must: []usecase.Condition{usecase.Match("draft", false)}}},
+		{"quoted values are strings", []string{`code="42",title="a, b|c"`}, &usecase.Filter{Must: []usecase.Condition{
+			usecase.Match("code", "42"),
+			usecase.Match("title", "a, b|c"),
+		}}},
+		{"spaces around the operator", []string{" lang = pt "}, &usecase.Filter{Must: []usecase.Condition{usecase.Match("lang", "pt")}}},
+		{"not equal", []string{"status!=draft"}, &usecase.Filter{MustNot: []usecase.Condition{usecase.Match("status", "draft")}}},
+		{"alternatives", []string{"lang=pt|en"}, &usecase.Filter{Must: []usecase.Condition{usecase.MatchAny("lang", "pt", "en")}}},
+		{"integer alternatives", []string{"page=1|2"}, &usecase.Filter{Must: []usecase.Condition{usecase.MatchAny("page", int64(1), int64(2))}}},
+		{"not one of", []string{"lang!=pt|en"}, &usecase.Filter{MustNot: []usecase.Condition{usecase.MatchAny("lang", "pt", "en")}}},
+		{"exists", []string{"author=*"}, &usecase.Filter{Must: []usecase.Condition{usecase.Exists("author")}}},
+		{"does not exist", []string{"author!=*"}, &usecase.Filter{MustNot: []usecase.Condition{usecase.Exists("author")}}},
+		{"numeric range", []string{"page>=3,page<10.5"}, &usecase.Filter{Must: []usecase.Condition{
+			usecase.InRange("page", usecase.Range{Gte: float(3)}),
+			usecase.InRange("page", usecase.Range{Lt: float(10.5)}),
+		}}},
+		{"strict numeric bounds", []string{"score>0.5", "score<=1"}, &usecase.Filter{Must: []usecase.Condition{
+			usecase.InRange("score", usecase.Range{Gt: float(0.5)}),
+			usecase.InRange("score", usecase.Range{Lte: float(1)}),
+		}}},
+		{"date range", []string{"created>=2024-01-01,created<2024-06-30T12:00:00Z"}, &usecase.Filter{Must: []usecase.Condition{
+			usecase.InDateRange("created", usecase.DateRange{Gte: date("2024-01-01T00:00:00Z")}),
+			usecase.InDateRange("created", usecase.DateRange{Lt: date("2024-06-30T12:00:00Z")}),
+		}}},
+		{"should", []string{"should:lang=pt,should:lang=en"}, &usecase.Filter{Should: []usecase.Condition{
+			usecase.Match("lang", "pt"),
+			usecase.Match("lang", "en"),
+		}}},
+		{"should range", []string{"should:page>5"}, &usecase.Filter{Should: []usecase.Condition{
+			usecase.InRange("page", usecase.Range{Gt: float(5)}),
+		}}},
+		{"must_not", []string{"must_not:status=draft"}, &usecase.Filter{MustNot: []usecase.Condition{usecase.Match("status", "draft")}}},
+		{"must_not with not equal", []string{"must_not:status!=final"}, &usecase.Filter{Must: []usecase.Condition{usecase.Match("status", "final")}}},
+		{"explicit must", []string{"must:lang=pt"}, &usecase.Filter{Must: []usecase.Condition{usecase.Match("lang", "pt")}}},
+		{"every clause", []string{"lang=pt", "should:tag=a|b,must_not:draft=true"}, &usecase.Filter{
+			Must:    []usecase.Condition{usecase.Match("lang", "pt")},
+			Should:  []usecase.Condition{usecase.MatchAny("tag", "a", "b")},
+			MustNot: []usecase.Condition{usecase.Match("draft", true)},
+		}},
+		{"source path", []string{"source=data/pdfs/artigo.pdf"}, &usecase.Filter{Must: []usecase.Condition{
+			usecase.Match(usecase.MetadataSource, "data/pdfs/artigo.pdf"),
+		}}},
+		{"source paths", []string{`source!=a/x.pdf|C:\docs\y.pdf`}, &usecase.Filter{MustNot: []usecase.Condition{
+			usecase.MatchAny(usecase.MetadataSource, "a/x.pdf", `C:\docs\y.pdf`),
+		}}},
+		{"source exists", []string{"source=*"}, &usecase.Filter{Must: []usecase.Condition{usecase.Exists(usecase.MetadataSource)}}},
+		{"relative path", []string{"relative_path=artigo.pdf"}, &usecase.Filter{Must: []usecase.Condition{
+			usecase.Match(usecase.MetadataRelativePath, "artigo.pdf"),
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := usecase.ParseFilter(tt.exprs...)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q): %v", tt.exprs, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilter(%q) = %+v, want %+v", tt.exprs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"lang", "missing operator"},
+		{"lang!pt", "missing operator"},
+		{"=pt", "missing field name"},
+		{"lang=", "missing value"},
+		{`lang="pt`, "invalid quoted value"},
+		{"page>three", "neither a number nor a date"},
+		{"created>2024-13-01", "neither a number nor a date"},
+		{"should:lang!=pt", "cannot be used in a should condition"},
+		{"tag=a|1", "all strings or all integers"},
+		{"source=artigo.pdf", "use relative_path=artigo.pdf"},
+		{"source!=artigo.pdf", "use relative_path=artigo.pdf"},
+		{"should:source=data/a.pdf|b.pdf", "use relative_path=b.pdf"},
+		{`source="notes, v2.pdf"`, `use relative_path="notes, v2.pdf"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := usecase.ParseFilter(tt.expr)
+			if err == nil {
+				t.Fatalf("ParseFilter(%q) = %+v, want an error", tt.expr, f)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseFilter(%q) error = %v, want it to mention %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterStringRoundTrip(t *testing.T) {
+	for _, expr := range []string{
+		"lang=pt",
+		`code="42",title="a, b"`,
+		"should:lang=pt|en,must_not:status=draft",
+		"page>=3,page<10.5",
+		"author=*,must_not:draft=true",
+		"created>=2024-01-01T00:00:00Z",
+	} {
+		f, err := usecase.ParseFilter(expr)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q): %v", expr, err)
+		}
+		again, err := usecase.ParseFilter(f.String())
+		if err != nil {
+			t.Fatalf("ParseFilter(%q) of the String of %q: %v", f.String(), expr, err)
+		}
+		if !reflect.DeepEqual(f, again) {
+			t.Errorf("%q formatted as %q parses to %+v, want %+v", expr, f.String(), again, f)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/tmc/langchaingo/schema"
+)
+
+// SparseVector is a sparse lexical vector: the weights (Values) of the
+// terms with the ids in Indices.
+type SparseVector struct {
+	Indices []uint32
+	Values  []float32
+}
+
+// IsEmpty reports whether the vector has no terms.
+func (v SparseVector) IsEmpty() bool {
+	return len(v.Indices) == 0
+}
+
+// SparseEncoder turns texts into sparse lexical vectors (e.g. BM25) using a
+// vocabulary built from the ingested documents. EncodeDocuments adds the
+// documents to the vocabulary; RemoveSource and DeleteCollection take them
+// out again, and Save persists the changes.
+type SparseEncoder interface {
+	EncodeDocuments(ctx context.Context, collectionName string, docs []schema.Document) ([]SparseVector, error)
+	EncodeQuery(ctx context.Context, query string) (SparseVector, error)
+	// RemoveSource removes the documents whose "source" metadata equals
+	// source. Unknown sources are ignored.
+	RemoveSource(ctx context.Context, collectionName string, source string) error
+	DeleteCollection(ctx context.Context, collectionName string) error
+	Save(ctx context.Context) error
+}
+
+// WithSparseEncoder makes ingestion store the sparse vectors of the chunks,
+// encoded with encoder, next to their embeddings, for hybrid search (see
+// WithHybridSearch). The vector store must be a SparseVectorStore.
+func WithSparseEncoder(encoder SparseEncoder) IngestionOption {
+	return func(uc *IngestionUseCase) {
+		uc.sparse = encoder
+	}
+}
+
+// SparseVectorStore is implemented by vector stores that store a sparse
+// vector next to the embedding of each document, for hybrid search.
+type SparseVectorStore interface {
+	AddDocumentsWithSparse(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32, sparse []SparseVector) ([]string, error)
+}
+
+// SparseSearcher is implemented by retrievers that search the sparse vectors
+// stored by a SparseVectorStore. Scores are the dot products of the vectors.
+type SparseSearcher interface {
+	SparseSearch(ctx context.Context, collectionName string, vector SparseVector, numDocuments int, opts ...SearchOption) ([]schema.Document, error)
+	// GetRelevantSparseDocuments searches the collection and number of
+	// documents of GetRelevantDocuments.
+	GetRelevantSparseDocuments(ctx context.Context, vector SparseVector, opts ...SearchOption) ([]schema.Document, error)
+}
+
+// FusionMethod selects how hybrid search combines dense and sparse results.
+type FusionMethod string
+
+const (
+	// FusionRRF ranks documents by reciprocal rank fusion: the sum over the
+	// result lists of 1/(k + rank).
+	FusionRRF FusionMethod = "rrf"
+	// FusionWeighted ranks documents by the weighted sum of their scores,
+	// normalized to 0-1 within each result list.
+	FusionWeighted FusionMethod = "weighted"
+)
+
+// Valid reports whether m is a known method.
+func (m FusionMethod) Valid() bool {
+	return m == FusionRRF || m == FusionWeighted
+}
+
+// Metadata keys of the documents returned by hybrid search. "score" holds
+// the fused score; these keep the scores of each search, when found by it.
+const (
+	MetadataDenseScore  = "dense_score"
+	MetadataSparseScore = "sparse_score"
+)
+
+// HybridOptions tunes the fusion of dense and sparse results.
+type HybridOptions struct {
+	Fusion FusionMethod
+	// RRFK is the rank constant of FusionRRF; larger values flatten the
+	// advantage of top-ranked documents.
+	RRFK int
+	// DenseWeight is the weight (0-1) of the dense score with
+	// FusionWeighted; the sparse score gets the rest.
+	DenseWeight float64
+}
+
+// DefaultHybridOptions returns reciprocal rank fusion with the usual k of 60.
+func DefaultHybridOptions() HybridOptions {
+	return HybridOptions{Fusion: FusionRRF, RRFK: 60, DenseWeight: 0.5}
+}
+
+// Validate reports the out-of-range options.
+func (o HybridOptions) Validate() error {
+	if !o.Fusion.Valid() {
+		return fmt.Errorf("fusion: must be rrf or weighted, got %q", o.Fusion)
+	}
+	if o.RRFK <= 0 {
+		return fmt.Errorf("rrf_k: must be positive, got %d", o.RRFK)
+	}
+	if o.DenseWeight < 0 || o.DenseWeight > 1 {
+		return fmt.Errorf("dense_weight: must be between 0 and 1, got %g", o.DenseWeight)
+	}
+	return nil
+}
+
+type fusedDocument struct {
+	doc    schema.Document
+	score  float64
+	dense  *float64
+	sparse *float64
+}
+
+// FuseResults merges the results of a dense and a sparse search, each sorted
+// by decreasing score, into at most limit documents (all when limit <= 0)
+// sorted by fused score. A document found by both searches is returned once.
+func FuseResults(dense, sparse []schema.Document, opts HybridOptions, limit int) []schema.Document {
+	var fused []*fusedDocument
+	byKey := make(map[string]*fusedDocument)
+	add := func(docs []schema.Document, isDense bool, weight float64) {
+		normalized := normalizedScores(docs)
+		for rank, doc := range docs {
+			key := fusionKey(doc)
+			f, ok := byKey[key]
+			if !ok {
+				f = &fusedDocument{doc: doc}
+				byKey[key] = f
+				fused = append(fused, f)
+			}
+			if score, ok := doc.Metadata["score"].(float64); ok {
+				if isDense {
+					f.dense = &score
+				} else {
+					f.sparse = &score
+				}
+			}
+			if opts.Fusion == FusionWeighted {
+				f.score += weight * normalized[rank]
+			} else {
+				f.score += 1 / float64(opts.RRFK+rank+1)
+			}
+		}
+	}
+	add(dense, true, opts.DenseWeight)
+	add(sparse, false, 1-opts.DenseWeight)
+
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].score > fused[j].score })
+	if limit > 0 && len(fused) > limit {
+		fused = fused[:limit]
+	}
+
+	result := make([]schema.Document, len(fused))
+	for i, f := range fused {
+		metadata := make(map[string]interface{}, len(f.doc.Metadata)+2)
+		for k, v := range f.doc.Metadata {
+			metadata[k] = v
+		}
+		metadata["score"] = f.score
+		if f.dense != nil {
+			metadata[MetadataDenseScore] = *f.dense
+		}
+		if f.sparse != nil {
+			metadata[MetadataSparseScore] = *f.sparse
+		}
+		result[i] = schema.Document{PageContent: f.doc.PageContent, Metadata: metadata}
+	}
+	return result
+}
+
+// normalizedScores maps the scores of docs linearly to 0-1, the best one
+// to 1. Documents without a score get 0; equal scores all get 1.
+func normalizedScores(docs []schema.Document) []float64 {
+	normalized := make([]float64, len(docs))
+	lo, hi, found := 0.0, 0.0, false
+	for _, doc := range docs {
+		score, ok := doc.Metadata["score"].(float64)
+		if !ok {
+			continue
+		}
+		if !found || score < lo {
+			lo = score
+		}
+		if !found || score > hi {
+			hi = score
+		}
+		found = true
+	}
+	for i, doc := range docs {
+		score, ok := doc.Metadata["score"].(float64)
+		switch {
+		case !ok:
+		case hi == lo:
+			normalized[i] = 1
+		default:
+			normalized[i] = (score - lo) / (hi - lo)
+		}
+	}
+	return normalized
+}
+
+// fusionKey identifies a chunk across result lists by its collection,
+// source, index and content hash, or by its content when it has no chunk
+// metadata.
+func fusionKey(doc schema.Document) string {
+	collection, _ := doc.Metadata["collection"].(string)
+	source, _ := doc.Metadata[MetadataSource].(string)
+	index, hasIndex := doc.Metadata[MetadataChunkIndex]
+	hash, hasHash := doc.Metadata[MetadataChunkHash]
+	if !hasIndex || !hasHash {
+		return fmt.Sprintf("%s\x00%s\x00%s", collection, source, doc.PageContent)
+	}
+	return fmt.Sprintf("%s\x00%s\x00%v\x00%v", collection, source, index, hash)
+}
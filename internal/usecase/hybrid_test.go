@@ -0,0 +1,196 @@
+package usecase_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// chunk returns the first chunk of the source name in collection, found
+// with score.
+func chunk(collection, name string, score float64) schema.Document {
+	return schema.Document{
+		PageContent: "text of " + name,
+		Metadata: map[string]interface{}{
+			"score":                    score,
+			"collection":               collection,
+			usecase.MetadataSource:     name,
+			usecase.MetadataChunkIndex: 0,
+			usecase.MetadataChunkHash:  "hash of " + name,
+		},
+	}
+}
+
+func sources(docs []schema.Document) []string {
+	names := make([]string, len(docs))
+	for i, doc := range docs {
+		names[i], _ = doc.Metadata[usecase.MetadataSource].(string)
+	}
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestFuseResultsRRF(t *testing.T) {
+	dense := []schema.Document{chunk("docs", "a", 0.9), chunk("docs", "b", 0.8)}
+	sparse := []schema.Document{chunk("docs", "b", 5), chunk("docs", "c", 3)}
+
+	fused := usecase.FuseResults(dense, sparse, usecase.DefaultHybridOptions(), 0)
+	if got, want := sources(fused), []string{"b", "a", "c"}; !equalStrings(got, want) {
+		t.Fatalf("fused order = %v, want %v", got, want)
+	}
+
+	wantScores := []float64{1.0/61 + 1.0/62, 1.0 / 61, 1.0 / 62}
+	for i, doc := range fused {
+		if score := doc.Metadata["score"].(float64); !closeTo(score, wantScores[i]) {
+			t.Errorf("score of %s = %g, want %g", sources(fused)[i], score, wantScores[i])
+		}
+	}
+
+	b := fused[0].Metadata
+	if b[usecase.MetadataDenseScore] != 0.8 || b[usecase.MetadataSparseScore] != 5.0 {
+		t.Errorf("b found by both searches has dense_score %v and sparse_score %v, want 0.8 and 5", b[usecase.MetadataDenseScore], b[usecase.MetadataSparseScore])
+	}
+	if _, ok := fused[1].Metadata[usecase.MetadataSparseScore]; ok {
+		t.Errorf("a found by the dense search only has a sparse_score: %v", fused[1].Metadata)
+	}
+	if _, ok := fused[2].Metadata[usecase.MetadataDenseScore]; ok {
+		t.Errorf("c found by the sparse search only has a dense_score: %v", fused[2].Metadata)
+	}
+	if dense[1].Metadata["score"] != 0.8 {
+		t.Errorf("FuseResults modified the metadata of its input: %v", dense[1].Metadata)
+	}
+}
+
+func TestFuseResultsRRFRankConstant(t *testing.T) {
+	// With a small k the top dense document outweighs one ranked fourth by
+	// both searches; with a large k ranks matter less than agreement.
+	dense := []schema.Document{chunk("docs", "a", 0.9), chunk("docs", "d", 0.8), chunk("docs", "e", 0.7), chunk("docs", "b", 0.6)}
+	sparse := []schema.Document{chunk("docs", "c", 5), chunk("docs", "f", 4), chunk("docs", "g", 3), chunk("docs", "b", 2)}
+
+	opts := usecase.DefaultHybridOptions()
+	opts.RRFK = 1
+	if got := sources(usecase.FuseResults(dense, sparse, opts, 1)); !equalStrings(got, []string{"a"}) {
+		t.Errorf("best with k=1 = %v, want [a]", got)
+	}
+	opts.RRFK = 60
+	if got := sources(usecase.FuseResults(dense, sparse, opts, 1)); !equalStrings(got, []string{"b"}) {
+		t.Errorf("best with k=60 = %v, want [b]", got)
+	}
+}
+
+func TestFuseResultsWeighted(t *testing.T) {
+	dense := []schema.Document{chunk("docs", "a", 0.9), chunk("docs", "b", 0.5)}
+	sparse := []schema.Document{chunk("docs", "c", 10), chunk("docs", "b", 2)}
+
+	tests := []struct {
+		name        string
+		denseWeight float64
+		want        []string
+		wantScores  []float64
+	}{
+		{"dense favored", 0.8, []string{"a", "c", "b"}, []float64{0.8, 0.2, 0}},
+		{"sparse favored", 0.3, []string{"c", "a", "b"}, []float64{0.7, 0.3, 0}},
+		{"sparse only", 0, []string{"c", "a", "b"}, []float64{1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := usecase.HybridOptions{Fusion: usecase.FusionWeighted, RRFK: 60, DenseWeight: tt.denseWeight}
+			fused := usecase.FuseResults(dense, sparse, opts, 0)
+			if got := sources(fused); !equalStrings(got, tt.want) {
+				t.Fatalf("fused order = %v, want %v", got, tt.want)
+			}
+			for i, doc := range fused {
+				if score := doc.Metadata["score"].(float64); !closeTo(score, tt.wantScores[i]) {
+					t.Errorf("score of %s = %g, want %g", tt.want[i], score, tt.wantScores[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFuseResultsWeightedEqualScores(t *testing.T) {
+	// Equal scores normalize to 1 rather than dividing by zero
+	dense := []schema.Document{chunk("docs", "a", 0.7), chunk("docs", "b", 0.7)}
+	opts := usecase.HybridOptions{Fusion: usecase.FusionWeighted, RRFK: 60, DenseWeight: 0.5}
+	for _, doc := range usecase.FuseResults(dense, nil, opts, 0) {
+		if score := doc.Metadata["score"].(float64); !closeTo(score, 0.5) {
+			t.Errorf("score of %v = %g, want 0.5", doc.Metadata[usecase.MetadataSource], score)
+		}
+	}
+}
+
+func TestFuseResultsLimit(t *testing.T) {
+	dense := []schema.Document{chunk("docs", "a", 0.9), chunk("docs", "b", 0.8)}
+	sparse := []schema.Document{chunk("docs", "c", 5), chunk("docs", "d", 3)}
+	opts := usecase.DefaultHybridOptions()
+
+	if got := usecase.FuseResults(dense, sparse, opts, 3); len(got) != 3 {
+		t.Errorf("limit 3 returned %d documents", len(got))
+	}
+	if got := usecase.FuseResults(dense, sparse, opts, 0); len(got) != 4 {
+		t.Errorf("no limit returned %d documents, want 4", len(got))
+	}
+	if got := usecase.FuseResults(nil, nil, opts, 5); len(got) != 0 {
+		t.Errorf("no results fused into %d documents", len(got))
+	}
+}
+
+func TestFuseResultsKeepsCollectionsApart(t *testing.T) {
+	// The same chunk stored in two collections is two results
+	dense := []schema.Document{chunk("a", "manual.pdf", 0.9)}
+	sparse := []schema.Document{chunk("b", "manual.pdf", 4)}
+
+	fused := usecase.FuseResults(dense, sparse, usecase.DefaultHybridOptions(), 0)
+	if len(fused) != 2 {
+		t.Fatalf("fused %d documents, want one per collection", len(fused))
+	}
+	if fused[0].Metadata["collection"] != "a" || fused[1].Metadata["collection"] != "b" {
+		t.Errorf("collections = %v, %v, want a then b", fused[0].Metadata["collection"], fused[1].Metadata["collection"])
+	}
+}
+
+func TestFuseResultsWithoutChunkMetadata(t *testing.T) {
+	// Documents lacking chunk metadata are matched by their content
+	doc := func(text string, score float64) schema.Document {
+		return schema.Document{PageContent: text, Metadata: map[string]interface{}{"score": score}}
+	}
+	dense := []schema.Document{doc("first", 0.9), doc("second", 0.8)}
+	sparse := []schema.Document{doc("second", 2)}
+
+	fused := usecase.FuseResults(dense, sparse, usecase.DefaultHybridOptions(), 0)
+	if len(fused) != 2 || fused[0].PageContent != "second" {
+		t.Errorf("fused %v, want second ahead of first", fused)
+	}
+}
+
+func TestHybridOptionsValidate(t *testing.T) {
+	if err := usecase.DefaultHybridOptions().Validate(); err != nil {
+		t.Errorf("the default options are invalid: %v", err)
+	}
+	for _, opts := range []usecase.HybridOptions{
+		{Fusion: "max", RRFK: 60, DenseWeight: 0.5},
+		{Fusion: usecase.FusionRRF, RRFK: 0, DenseWeight: 0.5},
+		{Fusion: usecase.FusionWeighted, RRFK: 60, DenseWeight: 1.5},
+	} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Validate accepted %+v", opts)
+		}
+	}
+}
@@ -0,0 +1,505 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/schema"
+)
+
+// PipelineOptions tunes the streaming ingestion pipeline.
+type PipelineOptions struct {
+	LoadWorkers      int // Files loaded and split concurrently
+	EmbedBatchSize   int // Chunks sent per EmbedDocuments call
+	EmbedConcurrency int // Concurrent EmbedDocuments calls
+	UpsertBatchSize  int // Points sent per AddDocuments call
+}
+
+// DefaultPipelineOptions returns conservative defaults suited to a local Ollama instance.
+func DefaultPipelineOptions() PipelineOptions {
+	return PipelineOptions{
+		LoadWorkers:      4,
+		EmbedBatchSize:   32,
+		EmbedConcurrency: 2,
+		UpsertBatchSize:  256,
+	}
+}
+
+// WithPipelineOptions overrides the pipeline sizing. Non-positive values keep their defaults.
+func WithPipelineOptions(p PipelineOptions) IngestionOption {
+	return func(uc *IngestionUseCase) {
+		d := DefaultPipelineOptions()
+		if p.LoadWorkers <= 0 {
+			p.LoadWorkers = d.LoadWorkers
+		}
+		if p.EmbedBatchSize <= 0 {
+			p.EmbedBatchSize = d.EmbedBatchSize
+		}
+		if p.EmbedConcurrency <= 0 {
+			p.EmbedConcurrency = d.EmbedConcurrency
+		}
+		if p.UpsertBatchSize <= 0 {
+			p.UpsertBatchSize = d.UpsertBatchSize
+		}
+		uc.pipeline = p
+	}
+}
+
+// ingestTask is one file to be ingested into a collection.
+type ingestTask struct {
+	filePath   string
+	relPath    string // Relative to the ingestion directory
+	collection string
+	// perFile makes the load stage ensure (and in recreate mode, reset) the
+	// collection and look up its hashes itself, as done by ExecutePerPDF.
+	perFile bool
+}
+
+// fileState tracks a file as its chunks move through the pipeline.
+type fileState struct {
+	path       string
+	collection string
+	unchanged  bool
+	hash       string // Of the file being ingested
+	replacing  bool   // A previous version of the file is stored
+	chunks     int    // Chunks produced by the splitter
+	stored     int    // Chunks upserted into the vector store
+	err        error
+	started    time.Time
+	finished   time.Time
+}
+
+type pipelineChunk struct {
+	file *fileState
+	doc  schema.Document
+}
+
+type pipelineBatch struct {
+	chunks     []pipelineChunk
+	embeddings [][]float32
+}
+
+// pipeline streams files through load → split → embed → upsert stages
+// connected by bounded channels, so memory use is bounded by the batch sizes
+// rather than by the corpus size and slow stages apply backpressure upstream.
+type pipeline struct {
+	uc         *IngestionUseCase
+	opts       PipelineOptions
+	vectorSize int
+	existing   map[string]string // Hashes for single-collection runs
+
+	mu sync.Mutex // Guards fileState.stored, fileState.err and fileState.finished
+}
+
+// runPipeline ingests the tasks and returns the final state of every file.
+// Per-file failures are recorded in the states; only cancellation of ctx is
+// returned as an error.
+func (uc *IngestionUseCase) runPipeline(ctx context.Context, tasks []ingestTask, existing map[string]string, vectorSize int) ([]*fileState, error) {
+	p := &pipeline{uc: uc, opts: uc.pipeline, vectorSize: vectorSize, existing: existing}
+	if uc.sparse != nil {
+		// The vocabulary is saved even when the run is cancelled, as it
+		// already counts the chunks stored so far
+		defer func() {
+			if err := uc.sparse.Save(context.WithoutCancel(ctx)); err != nil {
+				log.Printf("Warning: Failed to save the sparse vocabulary: %v", err)
+			}
+		}()
+	}
+
+	states := make([]*fileState, len(tasks))
+	for i, t := range tasks {
+		states[i] = &fileState{path: t.filePath, collection: t.collection}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	taskCh := make(chan int)
+	chunkCh := make(chan pipelineChunk, p.opts.EmbedBatchSize)
+	batchCh := make(chan *pipelineBatch, p.opts.EmbedConcurrency)
+	embeddedCh := make(chan *pipelineBatch, p.opts.EmbedConcurrency)
+
+	// Stage 1: feed files to the loaders
+	go func() {
+		defer close(taskCh)
+		for i := range tasks {
+			select {
+			case taskCh <- i:
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+
+	// Stage 2: load and split files with a bounded worker pool
+	var loadWG sync.WaitGroup
+	for w := 0; w < p.opts.LoadWorkers; w++ {
+		loadWG.Add(1)
+		go func() {
+			defer loadWG.Done()
+			for i := range taskCh {
+				p.load(runCtx, tasks[i], states[i], chunkCh)
+			}
+		}()
+	}
+	go func() {
+		loadWG.Wait()
+		close(chunkCh)
+	}()
+
+	// Stage 3: group chunks into embedding batches
+	go func() {
+		defer close(batchCh)
+		batch := &pipelineBatch{}
+		for c := range chunkCh {
+			batch.chunks = append(batch.chunks, c)
+			if len(batch.chunks) < p.opts.EmbedBatchSize {
+				continue
+			}
+			select {
+			case batchCh <- batch:
+			case <-runCtx.Done():
+				return
+			}
+			batch = &pipelineBatch{}
+		}
+		if len(batch.chunks) > 0 {
+			select {
+			case batchCh <- batch:
+			case <-runCtx.Done():
+			}
+		}
+	}()
+
+	// Stage 4: embed batches concurrently
+	var embedWG sync.WaitGroup
+	for w := 0; w < p.opts.EmbedConcurrency; w++ {
+		embedWG.Add(1)
+		go func() {
+			defer embedWG.Done()
+			for b := range batchCh {
+				if !p.embed(runCtx, b) {
+					continue
+				}
+				select {
+				case embeddedCh <- b:
+				case <-runCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		embedWG.Wait()
+		close(embeddedCh)
+	}()
+
+	// Stage 5: upsert in batches per collection
+	p.upsert(runCtx, embeddedCh)
+
+	// On cancellation the loaders may still be unwinding
+	cancel()
+	loadWG.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return states, err
+	}
+
+	p.removePrevious(ctx, states)
+	p.cleanupFailed(ctx, states)
+	return states, nil
+}
+
+// load hashes, loads and splits one file, emitting its chunks downstream.
+func (p *pipeline) load(ctx context.Context, task ingestTask, st *fileState, out chan<- pipelineChunk) {
+	uc := p.uc
+	existing := p.existing
+	st.started = time.Now()
+
+	if task.perFile {
+		log.Printf("Ensuring collection '%s' exists with vector size %d...", task.collection, p.vectorSize)
+		if uc.mode == IngestionModeRecreate {
+			uc.deleteCollection(ctx, task.collection)
+		}
+		if err := uc.store.EnsureCollection(ctx, task.collection, p.vectorSize); err != nil {
+			p.fail(st, fmt.Errorf("failed to ensure collection '%s': %w", task.collection, err))
+			return
+		}
+		hashes, err := uc.existingHashes(ctx, task.collection)
+		if err != nil {
+			p.fail(st, err)
+			return
+		}
+		existing = hashes
+	}
+
+	log.Printf("Processing file: %s -> collection: %s", task.filePath, task.collection)
+
+	fileHash, err := hashFile(task.filePath)
+	if err != nil {
+		p.fail(st, fmt.Errorf("failed to hash file %s: %w", task.filePath, err))
+		return
+	}
+	previousHash, known := existing[task.filePath]
+	if known && previousHash == fileHash {
+		log.Printf("File %s is unchanged. Skipping.", task.filePath)
+		st.unchanged = true
+		st.finished = time.Now()
+		uc.notify(IngestionEvent{Stage: StageUnchanged, File: st.path, Collection: st.collection})
+		return
+	}
+
+	loaded, err := uc.loader.Load(ctx, task.filePath)
+	if err != nil {
+		p.fail(st, fmt.Errorf("failed to load file %s: %w", task.filePath, err))
+		return
+	}
+	uc.notify(IngestionEvent{Stage: StageLoaded, File: st.path, Collection: st.collection})
+
+	docs, err := uc.split(ctx, loaded, task.filePath, task.relPath, fileHash)
+	if err != nil {
+		p.fail(st, err)
+		return
+	}
+	if len(docs) == 0 {
+		p.fail(st, fmt.Errorf("no documents were split from file %s", task.filePath))
+		return
+	}
+
+	// The chunks of the previous version stay stored until the new version
+	// is (see removePrevious), so a failure does not lose the file. The
+	// sparse vocabulary counts a single version: the new one from now on.
+	st.hash = fileHash
+	st.replacing = known
+	if known && uc.sparse != nil {
+		if err := uc.sparse.RemoveSource(ctx, task.collection, task.filePath); err != nil {
+			p.fail(st, fmt.Errorf("failed to remove previous chunks of %s from the sparse vocabulary: %w", task.filePath, err))
+			return
+		}
+	}
+
+	st.chunks = len(docs)
+	log.Printf("Loaded and split %d documents from %s", len(docs), task.filePath)
+	uc.notify(IngestionEvent{Stage: StageSplit, File: st.path, Collection: st.collection, Chunks: len(docs)})
+
+	for _, doc := range docs {
+		select {
+		case out <- pipelineChunk{file: st, doc: doc}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// embed generates the embeddings of a batch. It reports false when the batch
+// failed, in which case every file with chunks in it is marked as failed.
+func (p *pipeline) embed(ctx context.Context, b *pipelineBatch) bool {
+	texts := make([]string, len(b.chunks))
+	for i, c := range b.chunks {
+		texts[i] = c.doc.PageContent
+	}
+
+	embeddings, err := p.uc.embedder.EmbedDocuments(ctx, texts)
+	if err == nil && len(embeddings) != len(texts) {
+		err = fmt.Errorf("embedder returned %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Warning: Failed to generate embeddings for a batch of %d chunks: %v", len(texts), err)
+		}
+		for _, c := range b.chunks {
+			p.fail(c.file, fmt.Errorf("failed to generate embeddings: %w", err))
+		}
+		return false
+	}
+
+	b.embeddings = embeddings
+	for _, f := range countByFile(b.chunks) {
+		p.uc.notify(IngestionEvent{Stage: StageEmbedded, File: f.file.path, Collection: f.file.collection, Chunks: f.count})
+	}
+	return true
+}
+
+// upsert buffers embedded chunks per collection and flushes them to the
+// vector store every UpsertBatchSize points.
+func (p *pipeline) upsert(ctx context.Context, in <-chan *pipelineBatch) {
+	pending := make(map[string]*pipelineBatch)
+
+	for b := range in {
+		for i, c := range b.chunks {
+			buf, ok := pending[c.file.collection]
+			if !ok {
+				buf = &pipelineBatch{}
+				pending[c.file.collection] = buf
+			}
+			buf.chunks = append(buf.chunks, c)
+			buf.embeddings = append(buf.embeddings, b.embeddings[i])
+			if len(buf.chunks) >= p.opts.UpsertBatchSize {
+				p.flush(ctx, c.file.collection, buf)
+				delete(pending, c.file.collection)
+			}
+		}
+	}
+
+	for collection, buf := range pending {
+		p.flush(ctx, collection, buf)
+	}
+}
+
+// flush writes one batch to the vector store, skipping chunks of files that
+// already failed in an earlier stage.
+func (p *pipeline) flush(ctx context.Context, collection string, b *pipelineBatch) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	var docs []schema.Document
+	var embeddings [][]float32
+	var files []*fileState
+	p.mu.Lock()
+	for i, c := range b.chunks {
+		if c.file.err != nil {
+			continue
+		}
+		docs = append(docs, c.doc)
+		embeddings = append(embeddings, b.embeddings[i])
+		files = append(files, c.file)
+	}
+	p.mu.Unlock()
+	if len(docs) == 0 {
+		return
+	}
+
+	log.Printf("Adding %d documents with embeddings to collection '%s'...", len(docs), collection)
+	if _, err := p.add(ctx, collection, docs, embeddings); err != nil {
+		log.Printf("Warning: Failed to add documents to collection '%s': %v", collection, err)
+		for _, f := range files {
+			p.fail(f, fmt.Errorf("failed to add documents to vector store: %w", err))
+		}
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, f := range files {
+		f.stored++
+	}
+	for _, f := range countByFile(b.chunks) {
+		if f.file.err != nil {
+			continue
+		}
+		p.uc.notify(IngestionEvent{Stage: StageUpserted, File: f.file.path, Collection: f.file.collection, Chunks: f.count})
+		if f.file.stored == f.file.chunks {
+			f.file.finished = time.Now()
+			p.uc.notify(IngestionEvent{Stage: StageCompleted, File: f.file.path, Collection: f.file.collection, Chunks: f.file.stored})
+		}
+	}
+}
+
+// add stores the documents with their embeddings and, for hybrid search,
+// their sparse vectors.
+func (p *pipeline) add(ctx context.Context, collection string, docs []schema.Document, embeddings [][]float32) ([]string, error) {
+	if p.uc.sparse == nil {
+		return p.uc.store.AddDocuments(ctx, collection, docs, embeddings)
+	}
+	sparse, err := p.uc.sparse.EncodeDocuments(ctx, collection, docs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sparse vectors: %w", err)
+	}
+	return p.uc.store.(SparseVectorStore).AddDocumentsWithSparse(ctx, collection, docs, embeddings, sparse)
+}
+
+type fileCount struct {
+	file  *fileState
+	count int
+}
+
+// countByFile counts the chunks of each file in a batch, in order of first appearance.
+func countByFile(chunks []pipelineChunk) []fileCount {
+	var counts []fileCount
+	index := make(map[*fileState]int)
+	for _, c := range chunks {
+		i, ok := index[c.file]
+		if !ok {
+			i = len(counts)
+			index[c.file] = i
+			counts = append(counts, fileCount{file: c.file})
+		}
+		counts[i].count++
+	}
+	return counts
+}
+
+// removePrevious removes the chunks of the previous version of the files
+// fully stored in a new version, including trailing chunks that the new
+// version no longer produces. A failure leaves both versions stored, which
+// the next incremental run detects (see VectorStore.ListSourceHashes).
+func (p *pipeline) removePrevious(ctx context.Context, states []*fileState) {
+	for _, st := range states {
+		if !st.replacing || st.err != nil || st.stored < st.chunks {
+			continue
+		}
+		filter := &Filter{
+			Must:    []Condition{Match(MetadataSource, st.path)},
+			MustNot: []Condition{Match(MetadataFileHash, st.hash)},
+		}
+		if err := p.uc.store.DeleteByFilter(ctx, st.collection, filter); err != nil {
+			log.Printf("Warning: Failed to remove previous chunks of %s: %v", st.path, err)
+		}
+	}
+}
+
+// cleanupFailed removes the partially stored chunks of failed files so that
+// the next incremental run does not mistake them for fully ingested; the
+// previous version of a replaced file is kept. The sparse vocabulary may
+// count chunks that failed to be stored, so it is cleaned up for every
+// failed file.
+func (p *pipeline) cleanupFailed(ctx context.Context, states []*fileState) {
+	for _, st := range states {
+		if st.err == nil {
+			continue
+		}
+		if st.stored == 0 {
+			if p.uc.sparse != nil {
+				if err := p.uc.sparse.RemoveSource(ctx, st.collection, st.path); err != nil {
+					log.Printf("Warning: Failed to remove %s from the sparse vocabulary: %v", st.path, err)
+				}
+			}
+			continue
+		}
+		if err := p.uc.deleteVersion(ctx, st); err != nil {
+			log.Printf("Warning: Failed to remove partial chunks of %s: %v", st.path, err)
+		}
+	}
+}
+
+// deleteVersion removes the chunks of the version of the file being
+// ingested from the collection and the file from the sparse vocabulary.
+func (uc *IngestionUseCase) deleteVersion(ctx context.Context, st *fileState) error {
+	if !st.replacing {
+		return uc.deleteSource(ctx, st.collection, st.path)
+	}
+	filter := &Filter{Must: []Condition{Match(MetadataSource, st.path), Match(MetadataFileHash, st.hash)}}
+	if err := uc.store.DeleteByFilter(ctx, st.collection, filter); err != nil {
+		return err
+	}
+	if uc.sparse != nil {
+		return uc.sparse.RemoveSource(ctx, st.collection, st.path)
+	}
+	return nil
+}
+
+// fail records the first error of a file.
+func (p *pipeline) fail(st *fileState, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if st.err == nil {
+		st.err = err
+		st.finished = time.Now()
+		log.Printf("Warning: %v. Skipping %s.", err, st.path)
+		p.uc.notify(IngestionEvent{Stage: StageFailed, File: st.path, Collection: st.collection, Error: err.Error()})
+	}
+}
@@ -0,0 +1,258 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/llm"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/loader"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/splitter"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// Paragraphs are split into one chunk each
+const (
+	notesV1 = "Version one of the notes.\n\nThe first release shipped.\n\nIt had a few bugs."
+	notesV2 = "Version two of the notes.\n\nThe second release shipped.\n\nIt fixed the bugs."
+	todo    = "Write the changelog."
+)
+
+// failingStore is a memoryStore whose AddDocuments fails once fail returns
+// an error for the documents of a call.
+type failingStore struct {
+	*memoryStore
+	mu   sync.Mutex
+	adds int // Calls of AddDocuments, including failed ones
+	fail func(call int, docs []schema.Document) error
+}
+
+func (s *failingStore) AddDocuments(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32) ([]string, error) {
+	s.mu.Lock()
+	s.adds++
+	call, fail := s.adds, s.fail
+	s.mu.Unlock()
+	if fail != nil {
+		if err := fail(call, docs); err != nil {
+			return nil, err
+		}
+	}
+	return s.memoryStore.AddDocuments(ctx, collectionName, docs, embeddings)
+}
+
+// failVersion2 fails the calls adding chunks of notesV2 after the first
+// allowed ones.
+func failVersion2(allowed int) func(int, []schema.Document) error {
+	var mu sync.Mutex
+	return func(call int, docs []schema.Document) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, doc := range docs {
+			if strings.Contains(notesV2, doc.PageContent) {
+				if allowed > 0 {
+					allowed--
+					return nil
+				}
+				return errors.New("disk full")
+			}
+		}
+		return nil
+	}
+}
+
+// countingEmbedder counts the texts it embeds for ingestion.
+type countingEmbedder struct {
+	usecase.EmbeddingGenerator
+	mu    sync.Mutex
+	texts int
+}
+
+func (e *countingEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.texts += len(texts)
+	e.mu.Unlock()
+	return e.EmbeddingGenerator.EmbedDocuments(ctx, texts)
+}
+
+func (e *countingEmbedder) embedded() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.texts
+}
+
+func writeFile(t *testing.T, path, text string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newIncremental returns an incremental ingestion into store that flushes
+// every chunk on its own, so that a file can be partially stored.
+func newIncremental(embedder usecase.EmbeddingGenerator, store usecase.VectorStore) *usecase.IngestionUseCase {
+	return usecase.NewIngestionUseCase(loader.NewTextLoader(), splitter.NewRecursiveCharacterSplitter(40, 0), embedder, store,
+		usecase.WithIngestionMode(usecase.IngestionModeIncremental),
+		usecase.WithPipelineOptions(usecase.PipelineOptions{LoadWorkers: 1, EmbedBatchSize: 1, EmbedConcurrency: 1, UpsertBatchSize: 1}),
+	)
+}
+
+// storedChunks returns the sorted texts of the chunks of relPath in the docs collection.
+func storedChunks(s *memoryStore, relPath string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var texts []string
+	for _, p := range s.collections["docs"] {
+		if p.doc.Metadata[usecase.MetadataRelativePath] == relPath {
+			texts = append(texts, p.doc.PageContent)
+		}
+	}
+	sort.Strings(texts)
+	return texts
+}
+
+func chunksOf(text string) []string {
+	chunks := strings.Split(text, "\n\n")
+	sort.Strings(chunks)
+	return chunks
+}
+
+func ingest(t *testing.T, uc *usecase.IngestionUseCase, dir string) *usecase.IngestionReport {
+	t.Helper()
+	report, err := uc.Execute(context.Background(), dir, "*.txt", "docs", 768)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	return report
+}
+
+func TestIncrementalSkipsUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "notes.txt"), notesV1)
+	writeFile(t, filepath.Join(dir, "todo.txt"), todo)
+
+	embedder := &countingEmbedder{EmbeddingGenerator: llm.NewHashEmbedder(768)}
+	store := newMemoryStore(embedder, "docs", 4)
+	uc := newIncremental(embedder, store)
+
+	if report := ingest(t, uc, dir); report.FilesIngested != 2 || report.ChunksStored != 4 {
+		t.Fatalf("first run ingested %d files and %d chunks, want 2 and 4", report.FilesIngested, report.ChunksStored)
+	}
+	embedded := embedder.embedded()
+
+	report := ingest(t, uc, dir)
+	if report.FilesUnchanged != 2 || report.FilesIngested != 0 {
+		t.Errorf("second run: %d unchanged and %d ingested files, want 2 and 0", report.FilesUnchanged, report.FilesIngested)
+	}
+	if embedder.embedded() != embedded {
+		t.Errorf("unchanged files were embedded again: %d texts, then %d", embedded, embedder.embedded())
+	}
+	if got := storedChunks(store, "notes.txt"); !equalStrings(got, chunksOf(notesV1)) {
+		t.Errorf("notes.txt chunks = %q, want the first version", got)
+	}
+}
+
+func TestIncrementalReplacesChangedFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "notes.txt"), notesV1)
+	writeFile(t, filepath.Join(dir, "todo.txt"), todo)
+
+	embedder := llm.NewHashEmbedder(768)
+	store := newMemoryStore(embedder, "docs", 4)
+	uc := newIncremental(embedder, store)
+	ingest(t, uc, dir)
+
+	writeFile(t, filepath.Join(dir, "notes.txt"), notesV2)
+	report := ingest(t, uc, dir)
+	if report.FilesIngested != 1 || report.FilesUnchanged != 1 || report.ChunksStored != 3 {
+		t.Errorf("report = %d ingested, %d unchanged, %d chunks, want 1, 1 and 3", report.FilesIngested, report.FilesUnchanged, report.ChunksStored)
+	}
+	if got := storedChunks(store, "notes.txt"); !equalStrings(got, chunksOf(notesV2)) {
+		t.Errorf("notes.txt chunks = %q, want only the second version", got)
+	}
+	if got := storedChunks(store, "todo.txt"); !equalStrings(got, []string{todo}) {
+		t.Errorf("todo.txt chunks = %q, want it untouched", got)
+	}
+}
+
+func TestIncrementalKeepsPreviousVersionUntilStored(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed int // Chunks of the new version stored before the failure
+	}{
+		{"nothing stored", 0},
+		{"partially stored", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "notes.txt"), notesV1)
+			writeFile(t, filepath.Join(dir, "todo.txt"), todo)
+
+			embedder := llm.NewHashEmbedder(768)
+			store := &failingStore{memoryStore: newMemoryStore(embedder, "docs", 4)}
+			uc := newIncremental(embedder, store)
+			ingest(t, uc, dir)
+
+			writeFile(t, filepath.Join(dir, "notes.txt"), notesV2)
+			store.fail = failVersion2(tt.allowed)
+			report := ingest(t, uc, dir)
+			if report.FilesFailed != 1 {
+				t.Fatalf("%d files failed, want notes.txt", report.FilesFailed)
+			}
+			// The chunks of the new version stored before the failure are
+			// removed, and the previous version is kept
+			if got := storedChunks(store.memoryStore, "notes.txt"); !equalStrings(got, chunksOf(notesV1)) {
+				t.Errorf("notes.txt chunks after the failure = %q, want the first version", got)
+			}
+
+			// The next run retries the file, as its stored hash is the old one
+			store.fail = nil
+			report = ingest(t, uc, dir)
+			if report.FilesIngested != 1 {
+				t.Errorf("the retry ingested %d files, want notes.txt", report.FilesIngested)
+			}
+			if got := storedChunks(store.memoryStore, "notes.txt"); !equalStrings(got, chunksOf(notesV2)) {
+				t.Errorf("notes.txt chunks after the retry = %q, want the second version", got)
+			}
+		})
+	}
+}
+
+func TestFailedNewFileIsCleanedUp(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "todo.txt"), todo)
+	writeFile(t, filepath.Join(dir, "notes.txt"), notesV2)
+
+	embedder := llm.NewHashEmbedder(768)
+	store := &failingStore{memoryStore: newMemoryStore(embedder, "docs", 4), fail: failVersion2(1)}
+	uc := newIncremental(embedder, store)
+
+	report := ingest(t, uc, dir)
+	if report.FilesFailed != 1 || report.FilesIngested != 1 {
+		t.Fatalf("report = %d failed and %d ingested files, want 1 and 1", report.FilesFailed, report.FilesIngested)
+	}
+	for _, f := range report.Failed() {
+		if f.Stored != 1 || !strings.Contains(f.Error, "disk full") {
+			t.Errorf("failed file report = %+v, want 1 chunk stored and the store error", f)
+		}
+	}
+	// The partially stored chunks are removed, so that the next run does
+	// not take the file for ingested
+	if got := storedChunks(store.memoryStore, "notes.txt"); len(got) != 0 {
+		t.Errorf("notes.txt chunks after the failure = %q, want none", got)
+	}
+	if got := storedChunks(store.memoryStore, "todo.txt"); !equalStrings(got, []string{todo}) {
+		t.Errorf("todo.txt chunks = %q, want it stored", got)
+	}
+
+	store.fail = nil
+	if report := ingest(t, uc, dir); report.FilesIngested != 1 || report.FilesUnchanged != 1 {
+		t.Errorf("the retry ingested %d files with %d unchanged, want 1 and 1", report.FilesIngested, report.FilesUnchanged)
+	}
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"time"
+)
+
+// IngestionStage identifies a step reported to an IngestionObserver.
+type IngestionStage string
+
+const (
+	StageDiscovered IngestionStage = "discovered" // Files matching the pattern were found
+	StageLoaded     IngestionStage = "loaded"     // A file was read by the loader
+	StageSplit      IngestionStage = "split"      // A file was split into chunks
+	StageEmbedded   IngestionStage = "embedded"   // Chunks of a file were embedded
+	StageUpserted   IngestionStage = "upserted"   // Chunks of a file were stored
+	StageCompleted  IngestionStage = "completed"  // Every chunk of a file was stored
+	StageUnchanged  IngestionStage = "unchanged"  // A file was skipped because its hash did not change
+	StageRemoved    IngestionStage = "removed"    // The chunks of a deleted file were removed
+	StageFailed     IngestionStage = "failed"     // A file could not be ingested
+)
+
+// IngestionEvent describes the progress of an ingestion run.
+type IngestionEvent struct {
+	Stage      IngestionStage `json:"stage"`
+	File       string         `json:"file,omitempty"`
+	Collection string         `json:"collection,omitempty"`
+	Chunks     int            `json:"chunks,omitempty"`      // Chunks affected by this event
+	TotalFiles int            `json:"total_files,omitempty"` // Set on StageDiscovered
+	Error      string         `json:"error,omitempty"`       // Failure reason on StageFailed
+	Time       time.Time      `json:"time"`
+}
+
+// IngestionObserver receives progress events. Events are delivered one at a
+// time, in the order they happen, even though the pipeline is concurrent.
+type IngestionObserver interface {
+	OnIngestionEvent(ev IngestionEvent)
+}
+
+// IngestionObserverFunc adapts a function to the IngestionObserver interface.
+type IngestionObserverFunc func(ev IngestionEvent)
+
+// OnIngestionEvent calls f(ev).
+func (f IngestionObserverFunc) OnIngestionEvent(ev IngestionEvent) {
+	f(ev)
+}
+
+// WithIngestionObserver registers an observer notified of ingestion progress.
+func WithIngestionObserver(o IngestionObserver) IngestionOption {
+	return func(uc *IngestionUseCase) {
+		uc.observer = o
+	}
+}
+
+// FileStatus is the outcome of ingesting one file.
+type FileStatus string
+
+const (
+	FileIngested  FileStatus = "ingested"
+	FileUnchanged FileStatus = "unchanged"
+	FileFailed    FileStatus = "failed"
+)
+
+// FileReport summarizes the ingestion of one file.
+type FileReport struct {
+	Path       string     `json:"path"`
+	Collection string     `json:"collection"`
+	Status     FileStatus `json:"status"`
+	Chunks     int        `json:"chunks"` // Chunks produced by the splitter
+	Stored     int        `json:"stored"` // Chunks stored in the vector store
+	DurationMS int64      `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// IngestionReport summarizes an ingestion run.
+type IngestionReport struct {
+	Mode           IngestionMode `json:"mode"`
+	StartedAt      time.Time     `json:"started_at"`
+	FinishedAt     time.Time     `json:"finished_at"`
+	DurationMS     int64         `json:"duration_ms"`
+	FilesFound     int           `json:"files_found"`
+	FilesIngested  int           `json:"files_ingested"`
+	FilesUnchanged int           `json:"files_unchanged"`
+	FilesFailed    int           `json:"files_failed"`
+	ChunksStored   int           `json:"chunks_stored"`
+	RemovedSources []string      `json:"removed_sources,omitempty"`
+	Files          []FileReport  `json:"files"`
+}
+
+// Failed returns the reports of the files that could not be ingested.
+func (r *IngestionReport) Failed() []FileReport {
+	var failed []FileReport
+	for _, f := range r.Files {
+		if f.Status == FileFailed {
+			failed = append(failed, f)
+		}
+	}
+	return failed
+}
+
+// newIngestionReport starts a report for a run in the given mode.
+func newIngestionReport(mode IngestionMode) *IngestionReport {
+	return &IngestionReport{Mode: mode, StartedAt: time.Now()}
+}
+
+// finish fills the per-file entries and totals from the pipeline states.
+func (r *IngestionReport) finish(states []*fileState) {
+	for _, st := range states {
+		fr := FileReport{
+			Path:       st.path,
+			Collection: st.collection,
+			Chunks:     st.chunks,
+			Stored:     st.stored,
+		}
+		if !st.started.IsZero() {
+			fr.DurationMS = st.finished.Sub(st.started).Milliseconds()
+		}
+
+		switch {
+		case st.err != nil:
+			fr.Status = FileFailed
+			fr.Error = st.err.Error()
+			r.FilesFailed++
+		case st.unchanged:
+			fr.Status = FileUnchanged
+			r.FilesUnchanged++
+		default:
+			fr.Status = FileIngested
+			r.FilesIngested++
+			r.ChunksStored += st.stored
+		}
+		r.Files = append(r.Files, fr)
+	}
+
+	r.FinishedAt = time.Now()
+	r.DurationMS = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+}
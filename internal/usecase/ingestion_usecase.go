@@ -2,176 +2,387 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/tmc/langchaingo/schema"
 )
 
+// Metadata keys written by the ingestion pipeline and stored in the vector store payload.
+const (
+	MetadataSource     = "source"
+	MetadataFileHash   = "file_hash"
+	MetadataChunkIndex = "chunk_index"
+	MetadataChunkHash  = "chunk_hash"
+
+	// Parent-child retrieval: chunks produced from a larger parent section
+	// record its ID, position in the file and text. The query use case sends
+	// the parents of the retrieved chunks to the LLM instead of the chunks.
+	MetadataParentID    = "parent_id"
+	MetadataParentIndex = "parent_index"
+	MetadataParentText  = "parent_text"
+)
+
+// IngestionMode controls what happens to documents already stored in a collection.
+type IngestionMode string
+
+const (
+	// IngestionModeIncremental keeps the collection, skips unchanged files,
+	// replaces the chunks of changed files and removes the chunks of deleted files.
+	IngestionModeIncremental IngestionMode = "incremental"
+	// IngestionModeRecreate deletes and recreates the collection before ingesting.
+	IngestionModeRecreate IngestionMode = "recreate"
+)
+
 type IngestionUseCase struct {
-	loader   DocumentLoader
-	splitter TextSplitter
-	embedder EmbeddingGenerator
-	store    VectorStore
+	loader    DocumentLoader
+	splitter  TextSplitter
+	embedder  EmbeddingGenerator
+	store     VectorStore
+	mode      IngestionMode
+	pipeline  PipelineOptions
+	selection FileSelection
+	observer  IngestionObserver
+	sparse    SparseEncoder // Hybrid search only
+	notifyMu  sync.Mutex
 }
 
-func NewIngestionUseCase(l DocumentLoader, s TextSplitter, e EmbeddingGenerator, vs VectorStore) *IngestionUseCase {
-	return &IngestionUseCase{
-		loader:   l,
-		splitter: s,
-		embedder: e,
-		store:    vs,
+// IngestionOption configures an IngestionUseCase.
+type IngestionOption func(*IngestionUseCase)
+
+// WithIngestionMode sets the ingestion mode. Without it the use case runs
+// IngestionModeRecreate; the applications always pass ingestion.mode from
+// the configuration (see config.Config.IngestionOptions), which defaults to
+// IngestionModeIncremental.
+func WithIngestionMode(mode IngestionMode) IngestionOption {
+	return func(uc *IngestionUseCase) {
+		uc.mode = mode
 	}
 }
 
-func (uc *IngestionUseCase) Execute(ctx context.Context, dirPath, filePattern, collectionName string, vectorSize int) error {
-	log.Printf("Starting ingestion process for directory: %s, pattern: %s", dirPath, filePattern)
+func NewIngestionUseCase(l DocumentLoader, s TextSplitter, e EmbeddingGenerator, vs VectorStore, opts ...IngestionOption) *IngestionUseCase {
+	uc := &IngestionUseCase{
+		loader:    l,
+		splitter:  s,
+		embedder:  e,
+		store:     vs,
+		mode:      IngestionModeRecreate,
+		pipeline:  DefaultPipelineOptions(),
+		selection: DefaultFileSelection(),
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// Execute ingests every file of dirPath, including subdirectories, matching
+// the filePattern list (see filePatterns) into collectionName. The returned report describes the outcome of each file; it is returned
+// together with the error when the run fails after files were discovered.
+// A vectorSize of 0 uses the dimension of the embedder (see ResolveVectorSize).
+func (uc *IngestionUseCase) Execute(ctx context.Context, dirPath, filePattern, collectionName string, vectorSize int) (*IngestionReport, error) {
+	log.Printf("Starting %s ingestion process for directory: %s, pattern: %s", uc.mode, dirPath, filePattern)
+	report := newIngestionReport(uc.mode)
+
+	patterns, err := parseFilePatterns(filePattern)
+	if err != nil {
+		return nil, err
+	}
+	vectorSize, err = ResolveVectorSize(ctx, uc.embedder, vectorSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkSparse(); err != nil {
+		return nil, err
+	}
 
 	log.Printf("Ensuring collection '%s' exists with vector size %d...", collectionName, vectorSize)
-	if err := uc.store.DeleteCollection(ctx, collectionName); err != nil {
-		log.Printf("Warning: Failed to delete collection '%s': %v", collectionName, err)
+	if uc.mode == IngestionModeRecreate {
+		uc.deleteCollection(ctx, collectionName)
 	}
 	if err := uc.store.EnsureCollection(ctx, collectionName, vectorSize); err != nil {
-		return fmt.Errorf("failed to ensure collection '%s': %w", collectionName, err)
+		return nil, fmt.Errorf("failed to ensure collection '%s': %w", collectionName, err)
 	}
 	log.Printf("Collection '%s' ensured.", collectionName)
 
-	files, err := filepath.Glob(filepath.Join(dirPath, filePattern))
+	existing, err := uc.existingHashes(ctx, collectionName)
 	if err != nil {
-		return fmt.Errorf("failed to list files in '%s' with pattern '%s': %w", dirPath, filePattern, err)
+		return nil, err
 	}
-	if len(files) == 0 {
-		return fmt.Errorf("no files found matching pattern '%s' in directory '%s'", filePattern, dirPath)
+
+	files, err := uc.listFiles(dirPath, filePattern, patterns)
+	if err != nil {
+		return nil, err
 	}
 	log.Printf("Found %d files to process.", len(files))
+	report.FilesFound = len(files)
+	uc.notify(IngestionEvent{Stage: StageDiscovered, Collection: collectionName, TotalFiles: len(files)})
 
-	var allDocs []schema.Document
-	var allTexts []string
-	for _, filePath := range files {
-		log.Printf("Processing file: %s", filePath)
-
-		docs, err := uc.loader.Load(ctx, filePath)
-		if err != nil {
-			log.Printf("Warning: Failed to load file %s: %v. Skipping.", filePath, err)
-			continue
-		}
-
-		splittedDocs, err := uc.splitter.SplitDocuments(ctx, docs)
-		if err != nil {
-			log.Printf("Warning: Failed to split documents from file %s: %v. Skipping.", filePath, err)
-			continue
-		}
-
-		for _, doc := range splittedDocs {
-			allDocs = append(allDocs, doc)
-			allTexts = append(allTexts, doc.PageContent)
-		}
-		log.Printf("Loaded and split %d documents from %s", len(splittedDocs), filePath)
+	if uc.mode == IngestionModeIncremental {
+		report.RemovedSources = uc.removeStaleSources(ctx, collectionName, dirPath, patterns, files, existing)
 	}
 
-	if len(allDocs) == 0 {
-		return fmt.Errorf("no documents were successfully loaded and split from any files")
+	tasks := make([]ingestTask, len(files))
+	for i, file := range files {
+		tasks[i] = ingestTask{filePath: file.path, relPath: file.rel, collection: collectionName}
 	}
-	log.Printf("Total documents to embed and add: %d", len(allDocs))
 
-	log.Println("Generating embeddings for all documents...")
-	embeddings, err := uc.embedder.EmbedDocuments(ctx, allTexts)
+	states, err := uc.runPipeline(ctx, tasks, existing, vectorSize)
+	report.finish(states)
 	if err != nil {
-		return fmt.Errorf("failed to generate embeddings: %w", err)
+		return report, fmt.Errorf("ingestion cancelled: %w", err)
 	}
-	log.Printf("Generated %d embeddings.", len(embeddings))
-
-	log.Printf("Adding %d documents with embeddings to collection '%s'...", len(allDocs), collectionName)
-	ids, err := uc.store.AddDocuments(ctx, collectionName, allDocs, embeddings)
-	if err != nil {
-		return fmt.Errorf("failed to add documents to vector store: %w", err)
+	if report.FilesIngested == 0 && report.FilesUnchanged == 0 {
+		return report, fmt.Errorf("no documents were successfully ingested from any of the %d files", report.FilesFound)
 	}
 
-	log.Printf("Successfully added %d documents to collection '%s'. Ingestion complete.", len(ids), collectionName)
-	return nil
+	log.Printf("Successfully added %d documents to collection '%s' (%d files unchanged, %d failed). Ingestion complete.", report.ChunksStored, collectionName, report.FilesUnchanged, report.FilesFailed)
+	return report, nil
 }
 
 // ExecutePerPDF executa o processo de ingestão criando uma coleção para cada arquivo PDF
-func (uc *IngestionUseCase) ExecutePerPDF(ctx context.Context, dirPath, filePattern string, vectorSize int) error {
+func (uc *IngestionUseCase) ExecutePerPDF(ctx context.Context, dirPath, filePattern string, vectorSize int) (*IngestionReport, error) {
 	log.Printf("Starting per-PDF ingestion for directory: %s, pattern: %s", dirPath, filePattern)
+	report := newIngestionReport(uc.mode)
 
-	files, err := filepath.Glob(filepath.Join(dirPath, filePattern))
+	patterns, err := parseFilePatterns(filePattern)
 	if err != nil {
-		return fmt.Errorf("failed to list files in '%s' with pattern '%s': %w", dirPath, filePattern, err)
+		return nil, err
 	}
-	if len(files) == 0 {
-		return fmt.Errorf("no files found matching pattern '%s' in directory '%s'", filePattern, dirPath)
+	vectorSize, err = ResolveVectorSize(ctx, uc.embedder, vectorSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkSparse(); err != nil {
+		return nil, err
+	}
+	files, err := uc.listFiles(dirPath, filePattern, patterns)
+	if err != nil {
+		return nil, err
 	}
 	log.Printf("Found %d files to process.", len(files))
+	report.FilesFound = len(files)
+	uc.notify(IngestionEvent{Stage: StageDiscovered, TotalFiles: len(files)})
+
+	tasks := make([]ingestTask, len(files))
+	owners := make(map[string]string, len(files))
+	for i, file := range files {
+		// O caminho relativo distingue arquivos de mesmo nome em subdiretórios
+		collectionName := CollectionNameForFile(file.rel)
+		if collectionName == "" {
+			return nil, fmt.Errorf("cannot derive a collection name from file %s", file.rel)
+		}
+		// Duas tarefas na mesma coleção se sobrescreveriam (em recreate, uma apagaria a outra)
+		if other, ok := owners[collectionName]; ok {
+			return nil, fmt.Errorf("files %s and %s both map to collection '%s'; rename one of them", other, file.rel, collectionName)
+		}
+		owners[collectionName] = file.rel
 
-	for _, filePath := range files {
-		// Obter nome do arquivo sem extensão para usar como nome da coleção
-		fileName := filepath.Base(filePath)
-		fileNameWithoutExt := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+		// Cada arquivo garante (ou recria) sua própria coleção no pipeline
+		tasks[i] = ingestTask{filePath: file.path, relPath: file.rel, collection: collectionName, perFile: true}
+	}
 
-		// Sanitizar o nome da coleção (remover caracteres inválidos)
-		collectionName := sanitizeCollectionName(fileNameWithoutExt)
+	states, err := uc.runPipeline(ctx, tasks, nil, vectorSize)
+	report.finish(states)
+	if err != nil {
+		return report, fmt.Errorf("per-PDF ingestion cancelled: %w", err)
+	}
 
-		log.Printf("Processing file: %s -> collection: %s", filePath, collectionName)
+	// Antes, arquivos ignorados com avisos resultavam em sucesso silencioso
+	if report.FilesIngested == 0 && report.FilesUnchanged == 0 {
+		return report, fmt.Errorf("no documents were successfully ingested from any of the %d files", report.FilesFound)
+	}
 
-		// Garantir que a coleção exista
-		log.Printf("Ensuring collection '%s' exists with vector size %d...", collectionName, vectorSize)
-		if err := uc.store.DeleteCollection(ctx, collectionName); err != nil {
-			log.Printf("Warning: Failed to delete collection '%s': %v", collectionName, err)
-		}
-		if err := uc.store.EnsureCollection(ctx, collectionName, vectorSize); err != nil {
-			log.Printf("Warning: Failed to ensure collection '%s': %v. Skipping file.", collectionName, err)
-			continue
-		}
+	log.Printf("Per-PDF ingestion complete for all files: %d documents added, %d files unchanged, %d failed.", report.ChunksStored, report.FilesUnchanged, report.FilesFailed)
+	return report, nil
+}
+
+// listFiles discovers the files to ingest and, when the loader is a
+// SourceFilter, drops the files it cannot load.
+func (uc *IngestionUseCase) listFiles(dirPath, filePattern string, patterns filePatterns) ([]discoveredFile, error) {
+	files, err := uc.discoverFiles(dirPath, patterns)
+	if err != nil {
+		return nil, err
+	}
 
-		// Carregar e dividir o documento
-		docs, err := uc.loader.Load(ctx, filePath)
-		if err != nil {
-			log.Printf("Warning: Failed to load file %s: %v. Skipping.", filePath, err)
+	filter, _ := uc.loader.(SourceFilter)
+	supported := files[:0]
+	for _, f := range files {
+		if filter != nil && !filter.Supports(f.path) {
+			log.Printf("Skipping unsupported file: %s", f.path)
 			continue
 		}
+		supported = append(supported, f)
+	}
+	if len(supported) == 0 {
+		return nil, fmt.Errorf("no files found matching pattern '%s' in directory '%s'", filePattern, dirPath)
+	}
+	return supported, nil
+}
 
-		splittedDocs, err := uc.splitter.SplitDocuments(ctx, docs)
-		if err != nil {
-			log.Printf("Warning: Failed to split documents from file %s: %v. Skipping.", filePath, err)
+// notify delivers an event to the observer, one at a time.
+func (uc *IngestionUseCase) notify(ev IngestionEvent) {
+	if uc.observer == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	uc.notifyMu.Lock()
+	defer uc.notifyMu.Unlock()
+	uc.observer.OnIngestionEvent(ev)
+}
+
+// existingHashes returns the file hashes already stored in the collection.
+// In recreate mode the collection is always empty, so nothing is looked up.
+func (uc *IngestionUseCase) existingHashes(ctx context.Context, collectionName string) (map[string]string, error) {
+	if uc.mode != IngestionModeIncremental {
+		return map[string]string{}, nil
+	}
+	hashes, err := uc.store.ListSourceHashes(ctx, collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingested sources in collection '%s': %w", collectionName, err)
+	}
+	return hashes, nil
+}
+
+// removeStaleSources deletes the chunks of previously ingested sources that
+// match the ingestion patterns but no longer exist on disk. Sources outside
+// dirPath or the patterns (e.g. other upload directories) are left untouched.
+func (uc *IngestionUseCase) removeStaleSources(ctx context.Context, collectionName, dirPath string, patterns filePatterns, files []discoveredFile, existing map[string]string) []string {
+	var removed []string
+	present := make(map[string]bool, len(files))
+	for _, f := range files {
+		present[f.path] = true
+	}
+
+	for source := range existing {
+		if present[source] {
 			continue
 		}
-
-		if len(splittedDocs) == 0 {
-			log.Printf("No documents were successfully split from file %s. Skipping.", filePath)
+		rel, err := filepath.Rel(dirPath, source)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
 			continue
 		}
-
-		// Extrair textos para embedding
-		var texts []string
-		for _, doc := range splittedDocs {
-			texts = append(texts, doc.PageContent)
+		if !patterns.matches(filepath.ToSlash(rel)) {
+			continue
 		}
-
-		log.Printf("Generating embeddings for %d chunks from %s...", len(texts), filePath)
-		embeddings, err := uc.embedder.EmbedDocuments(ctx, texts)
-		if err != nil {
-			log.Printf("Warning: Failed to generate embeddings for %s: %v. Skipping.", filePath, err)
+		// Files skipped by the selection (e.g. too large) keep their chunks
+		if _, err := os.Lstat(source); err == nil {
 			continue
 		}
-
-		// Adicionar documentos com embeddings à coleção específica
-		log.Printf("Adding %d documents with embeddings to collection '%s'...", len(splittedDocs), collectionName)
-		ids, err := uc.store.AddDocuments(ctx, collectionName, splittedDocs, embeddings)
-		if err != nil {
-			log.Printf("Warning: Failed to add documents to collection '%s': %v", collectionName, err)
+		log.Printf("Source %s was removed. Deleting its chunks from collection '%s'...", source, collectionName)
+		if err := uc.deleteSource(ctx, collectionName, source); err != nil {
+			log.Printf("Warning: Failed to delete chunks of removed source %s: %v", source, err)
 			continue
 		}
+		removed = append(removed, source)
+		uc.notify(IngestionEvent{Stage: StageRemoved, File: source, Collection: collectionName})
+	}
+	return removed
+}
 
-		log.Printf("Successfully added %d documents to collection '%s' from file %s", len(ids), collectionName, filePath)
+// checkSparse verifies that the vector store can hold the sparse vectors of
+// the encoder set with WithSparseEncoder.
+func (uc *IngestionUseCase) checkSparse() error {
+	if uc.sparse == nil {
+		return nil
+	}
+	if _, ok := uc.store.(SparseVectorStore); !ok {
+		return fmt.Errorf("vector store does not support sparse vectors required by hybrid search")
+	}
+	return nil
+}
+
+// deleteCollection deletes the collection and its sparse vocabulary, for
+// recreate mode. Failures are only logged, as the collection is then
+// ensured anyway.
+func (uc *IngestionUseCase) deleteCollection(ctx context.Context, collectionName string) {
+	if err := uc.store.DeleteCollection(ctx, collectionName); err != nil {
+		log.Printf("Warning: Failed to delete collection '%s': %v", collectionName, err)
+	}
+	if uc.sparse != nil {
+		if err := uc.sparse.DeleteCollection(ctx, collectionName); err != nil {
+			log.Printf("Warning: Failed to reset the sparse vocabulary of collection '%s': %v", collectionName, err)
+		}
 	}
+}
 
-	log.Printf("Per-PDF ingestion complete for all files.")
+// deleteSource removes the chunks of source from the collection and from the
+// sparse vocabulary.
+func (uc *IngestionUseCase) deleteSource(ctx context.Context, collectionName, source string) error {
+	if err := uc.store.DeleteBySource(ctx, collectionName, source); err != nil {
+		return err
+	}
+	if uc.sparse != nil {
+		return uc.sparse.RemoveSource(ctx, collectionName, source)
+	}
 	return nil
 }
 
+// split splits the documents loaded from a file into chunks and annotates
+// every chunk with its source, relative path, file hash, index and content hash.
+func (uc *IngestionUseCase) split(ctx context.Context, docs []schema.Document, filePath, relPath, fileHash string) ([]schema.Document, error) {
+	splittedDocs, err := uc.splitter.SplitDocuments(ctx, docs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split documents from file %s: %w", filePath, err)
+	}
+
+	for i := range splittedDocs {
+		if splittedDocs[i].Metadata == nil {
+			splittedDocs[i].Metadata = make(map[string]interface{})
+		}
+		splittedDocs[i].Metadata[MetadataSource] = filePath
+		if relPath != "" {
+			splittedDocs[i].Metadata[MetadataRelativePath] = relPath
+		}
+		splittedDocs[i].Metadata[MetadataFileHash] = fileHash
+		splittedDocs[i].Metadata[MetadataChunkIndex] = i
+		splittedDocs[i].Metadata[MetadataChunkHash] = hashString(splittedDocs[i].PageContent)
+	}
+
+	return splittedDocs, nil
+}
+
+// hashFile returns the hex-encoded SHA-256 of the file contents.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashString returns the hex-encoded SHA-256 of s.
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// CollectionNameForFile retorna o nome da coleção de um arquivo na ingestão
+// por PDF: o caminho relativo ao diretório de ingestão, sem extensão, com os
+// separadores de diretório trocados por underscores ("a/relatorio.pdf" vira
+// "a_relatorio").
+func CollectionNameForFile(relPath string) string {
+	name := strings.TrimSuffix(filepath.ToSlash(relPath), filepath.Ext(relPath))
+	return sanitizeCollectionName(strings.ReplaceAll(name, "/", "_"))
+}
+
 // sanitizeCollectionName sanitiza o nome do arquivo para ser usado como nome de coleção
 func sanitizeCollectionName(name string) string {
 	// Substituir espaços, pontos e outros caracteres por underscores
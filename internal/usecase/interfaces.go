@@ -10,6 +10,12 @@ type DocumentLoader interface {
 	Load(ctx context.Context, source string) ([]schema.Document, error)
 }
 
+// SourceFilter is implemented by loaders that only handle some file types.
+// Files matching the ingestion pattern that the loader does not support are skipped.
+type SourceFilter interface {
+	Supports(source string) bool
+}
+
 type TextSplitter interface {
 	SplitDocuments(ctx context.Context, docs []schema.Document) ([]schema.Document, error)
 }
@@ -19,18 +25,35 @@ type EmbeddingGenerator interface {
 	EmbedQuery(ctx context.Context, text string) ([]float32, error)
 }
 
+// DimensionReporter is implemented by embedders that know the dimension of
+// their vectors. See EmbeddingDimension.
+type DimensionReporter interface {
+	Dimension(ctx context.Context) (int, error)
+}
+
 type VectorStore interface {
 	AddDocuments(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32) ([]string, error)
-	SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int) ([]schema.Document, error)
+	// SimilaritySearch returns the numDocuments documents closest to
+	// queryEmbedding, among those matching the filter of the options.
+	SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int, opts ...SearchOption) ([]schema.Document, error)
 	EnsureCollection(ctx context.Context, collectionName string, vectorSize int) error
 	DeleteCollection(ctx context.Context, collectionName string) error
+	// ListSourceHashes returns the file hash recorded for every source already stored in the collection.
+	ListSourceHashes(ctx context.Context, collectionName string) (map[string]string, error)
+	// DeleteBySource removes every point whose "source" metadata equals source.
+	DeleteBySource(ctx context.Context, collectionName string, source string) error
+	// DeleteByFilter removes every point matching filter, which must not be empty.
+	DeleteByFilter(ctx context.Context, collectionName string, filter *Filter) error
 }
 
+// LLM generates answers. The options tune the call (see CallOptions).
 type LLM interface {
-	Call(ctx context.Context, prompt string, options ...func(map[string]interface{})) (string, error) // Simplified Call interface
-	CallWithStreaming(ctx context.Context, prompt string, callbackFn func(chunk string), options ...func(map[string]interface{})) error
+	Call(ctx context.Context, prompt string, options ...CallOption) (string, error)
+	CallWithStreaming(ctx context.Context, prompt string, callbackFn func(chunk string), options ...CallOption) error
 }
 
+// Retriever returns the documents relevant to a query. The options can
+// restrict them with a Filter (see SearchOptions).
 type Retriever interface {
-	GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error)
+	GetRelevantDocuments(ctx context.Context, query string, opts ...SearchOption) ([]schema.Document, error)
 }
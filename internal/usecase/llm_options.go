@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// CallOptions are the generation parameters of an LLM call. A nil
+// Temperature and zero values leave the backend's default. Options a
+// backend does not support are ignored.
+type CallOptions struct {
+	Temperature *float64
+	TopP        float64
+	TopK        int
+	NumCtx      int // Context window in tokens (Ollama)
+	MaxTokens   int
+	Stop        []string
+	Seed        int
+	// KeepAlive is how long the model stays loaded after the call (Ollama):
+	// a duration such as "10m", "0" to unload it at once or "-1" to keep it.
+	KeepAlive string
+
+	// Reasoning selects what happens to the <think>…</think> reasoning of
+	// thinking models such as deepseek-r1 (empty: the LLM's default).
+	Reasoning ReasoningMode
+	// OnReasoning receives the reasoning with ReasoningSeparate: chunk by
+	// chunk when streaming, at once otherwise. Without it the reasoning is
+	// dropped.
+	OnReasoning func(chunk string)
+}
+
+// ReasoningMode selects how the reasoning trace of thinking models is handled.
+type ReasoningMode string
+
+const (
+	// ReasoningInline leaves the reasoning in the answer, tags included.
+	ReasoningInline ReasoningMode = "inline"
+	// ReasoningHide removes the reasoning from the answer.
+	ReasoningHide ReasoningMode = "hide"
+	// ReasoningSeparate removes the reasoning from the answer and passes it
+	// to CallOptions.OnReasoning.
+	ReasoningSeparate ReasoningMode = "separate"
+	// ReasoningLog removes the reasoning from the answer and logs it.
+	ReasoningLog ReasoningMode = "log"
+)
+
+// Valid reports whether m is a known mode.
+func (m ReasoningMode) Valid() bool {
+	switch m {
+	case ReasoningInline, ReasoningHide, ReasoningSeparate, ReasoningLog:
+		return true
+	}
+	return false
+}
+
+// CallOption adjusts the CallOptions of an LLM call.
+type CallOption func(*CallOptions)
+
+// WithCallOptions overrides the fields set in o (a non-nil Temperature,
+// non-zero values and a non-empty Stop).
+func WithCallOptions(o CallOptions) CallOption {
+	return func(c *CallOptions) {
+		if o.Temperature != nil {
+			t := *o.Temperature
+			c.Temperature = &t
+		}
+		if o.TopP != 0 {
+			c.TopP = o.TopP
+		}
+		if o.TopK != 0 {
+			c.TopK = o.TopK
+		}
+		if o.NumCtx != 0 {
+			c.NumCtx = o.NumCtx
+		}
+		if o.MaxTokens != 0 {
+			c.MaxTokens = o.MaxTokens
+		}
+		if len(o.Stop) > 0 {
+			c.Stop = append([]string(nil), o.Stop...)
+		}
+		if o.Seed != 0 {
+			c.Seed = o.Seed
+		}
+		if o.KeepAlive != "" {
+			c.KeepAlive = o.KeepAlive
+		}
+		if o.Reasoning != "" {
+			c.Reasoning = o.Reasoning
+		}
+		if o.OnReasoning != nil {
+			c.OnReasoning = o.OnReasoning
+		}
+	}
+}
+
+// WithTemperature sets the sampling temperature.
+func WithTemperature(t float64) CallOption {
+	return func(c *CallOptions) {
+		c.Temperature = &t
+	}
+}
+
+// WithReasoning sets how the reasoning of thinking models is handled.
+func WithReasoning(mode ReasoningMode) CallOption {
+	return func(c *CallOptions) {
+		c.Reasoning = mode
+	}
+}
+
+// WithReasoningCallback passes the reasoning of thinking models to fn
+// (see ReasoningSeparate).
+func WithReasoningCallback(fn func(chunk string)) CallOption {
+	return func(c *CallOptions) {
+		c.OnReasoning = fn
+	}
+}
+
+// NewCallOptions applies opts, in order, to empty CallOptions.
+func NewCallOptions(opts ...CallOption) CallOptions {
+	var o CallOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Validate reports the out-of-range options, all together.
+func (o CallOptions) Validate() error {
+	var errs []error
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2) {
+		errs = append(errs, fmt.Errorf("temperature: must be between 0 and 2, got %g", *o.Temperature))
+	}
+	if o.TopP < 0 || o.TopP > 1 {
+		errs = append(errs, fmt.Errorf("top_p: must be between 0 and 1, got %g", o.TopP))
+	}
+	if o.TopK < 0 {
+		errs = append(errs, fmt.Errorf("top_k: must not be negative, got %d", o.TopK))
+	}
+	if o.NumCtx < 0 {
+		errs = append(errs, fmt.Errorf("num_ctx: must not be negative, got %d", o.NumCtx))
+	}
+	if o.MaxTokens < 0 {
+		errs = append(errs, fmt.Errorf("max_tokens: must not be negative, got %d", o.MaxTokens))
+	}
+	if o.KeepAlive != "" {
+		if _, err := strconv.Atoi(o.KeepAlive); err != nil {
+			if _, err := time.ParseDuration(o.KeepAlive); err != nil {
+				errs = append(errs, fmt.Errorf("keep_alive: must be a duration (e.g. 10m) or a number of seconds, got %q", o.KeepAlive))
+			}
+		}
+	}
+	if o.Reasoning != "" && !o.Reasoning.Valid() {
+		errs = append(errs, fmt.Errorf("reasoning: must be inline, hide, separate or log, got %q", o.Reasoning))
+	}
+	return errors.Join(errs...)
+}
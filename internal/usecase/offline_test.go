@@ -0,0 +1,270 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/llm"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/loader"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/splitter"
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/usecase"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// offlineFixture is ingested by the offline tests, one file per entry.
+var offlineFixture = map[string]string{
+	"cats.txt":         "Cats purr when they are content and sleep most of the afternoon.",
+	"guides/dogs.txt":  "Dogs bark at the mailman and fetch sticks in the park.",
+	"guides/birds.txt": "Parrots repeat words and eat seeds from the feeder.",
+}
+
+// memoryStore is an in-memory usecase.VectorStore and usecase.Retriever
+// searching its collections by brute-force cosine similarity. Search
+// filters are ignored; DeleteByFilter supports the Match conditions used by
+// ingestion.
+type memoryStore struct {
+	mu          sync.Mutex
+	embedder    usecase.EmbeddingGenerator
+	collection  string // Collection searched by GetRelevantDocuments
+	k           int
+	collections map[string][]memoryPoint
+}
+
+type memoryPoint struct {
+	doc    schema.Document
+	vector []float32
+}
+
+func newMemoryStore(embedder usecase.EmbeddingGenerator, collection string, k int) *memoryStore {
+	return &memoryStore{embedder: embedder, collection: collection, k: k, collections: make(map[string][]memoryPoint)}
+}
+
+func (s *memoryStore) AddDocuments(ctx context.Context, collectionName string, docs []schema.Document, embeddings [][]float32) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	points, ok := s.collections[collectionName]
+	if !ok {
+		return nil, fmt.Errorf("collection '%s' does not exist", collectionName)
+	}
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		points = append(points, memoryPoint{doc: doc, vector: embeddings[i]})
+		ids[i] = fmt.Sprint(len(points))
+	}
+	s.collections[collectionName] = points
+	return ids, nil
+}
+
+func (s *memoryStore) SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var docs []schema.Document
+	for _, p := range s.collections[collectionName] {
+		metadata := map[string]interface{}{"score": cosine(queryEmbedding, p.vector), "collection": collectionName}
+		for k, v := range p.doc.Metadata {
+			metadata[k] = v
+		}
+		docs = append(docs, schema.Document{PageContent: p.doc.PageContent, Metadata: metadata})
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].Metadata["score"].(float64) > docs[j].Metadata["score"].(float64)
+	})
+	if len(docs) > numDocuments {
+		docs = docs[:numDocuments]
+	}
+	return docs, nil
+}
+
+func (s *memoryStore) EnsureCollection(ctx context.Context, collectionName string, vectorSize int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.collections[collectionName]; !ok {
+		s.collections[collectionName] = nil
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteCollection(ctx context.Context, collectionName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.collections, collectionName)
+	return nil
+}
+
+func (s *memoryStore) ListSourceHashes(ctx context.Context, collectionName string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hashes := make(map[string]string)
+	for _, p := range s.collections[collectionName] {
+		source, _ := p.doc.Metadata[usecase.MetadataSource].(string)
+		hash, _ := p.doc.Metadata[usecase.MetadataFileHash].(string)
+		hashes[source] = hash
+	}
+	return hashes, nil
+}
+
+func (s *memoryStore) DeleteBySource(ctx context.Context, collectionName string, source string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var kept []memoryPoint
+	for _, p := range s.collections[collectionName] {
+		if p.doc.Metadata[usecase.MetadataSource] != source {
+			kept = append(kept, p)
+		}
+	}
+	s.collections[collectionName] = kept
+	return nil
+}
+
+func (s *memoryStore) DeleteByFilter(ctx context.Context, collectionName string, filter *usecase.Filter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if filter.IsEmpty() {
+		return fmt.Errorf("refusing to delete with an empty filter")
+	}
+	matches := func(p memoryPoint, conditions []usecase.Condition, want bool) bool {
+		for _, c := range conditions {
+			if c.Op != usecase.FilterMatch {
+				panic("memoryStore only deletes by Match conditions")
+			}
+			if (p.doc.Metadata[c.Key] == c.Value) != want {
+				return false
+			}
+		}
+		return true
+	}
+	var kept []memoryPoint
+	for _, p := range s.collections[collectionName] {
+		if !matches(p, filter.Must, true) || !matches(p, filter.MustNot, false) {
+			kept = append(kept, p)
+		}
+	}
+	s.collections[collectionName] = kept
+	return nil
+}
+
+func (s *memoryStore) GetRelevantDocuments(ctx context.Context, query string, opts ...usecase.SearchOption) ([]schema.Document, error) {
+	vector, err := s.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.SimilaritySearch(ctx, s.collection, vector, s.k)
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// ingestFixture writes offlineFixture to a temporary directory and ingests
+// it into the "docs" collection of store with the hash embedder.
+func ingestFixture(t *testing.T, embedder usecase.EmbeddingGenerator, store usecase.VectorStore) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range offlineFixture {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ingestion := usecase.NewIngestionUseCase(loader.NewTextLoader(), splitter.NewRecursiveCharacterSplitter(200, 0), embedder, store)
+	report, err := ingestion.Execute(context.Background(), dir, "**/*.txt", "docs", 768)
+	if err != nil {
+		t.Fatalf("Execute ingestion: %v", err)
+	}
+	if report.FilesIngested != len(offlineFixture) || report.ChunksStored != len(offlineFixture) {
+		t.Fatalf("ingested %d files and %d chunks, want %d of each", report.FilesIngested, report.ChunksStored, len(offlineFixture))
+	}
+}
+
+// TestOfflineQuery ingests the fixture with the hash embedder, then checks
+// the chunk retrieved for a question and the prompt sent to the fake LLM.
+func TestOfflineQuery(t *testing.T) {
+	embedder := llm.NewHashEmbedder(768)
+	store := newMemoryStore(embedder, "docs", 1)
+	ingestFixture(t, embedder, store)
+
+	fake := llm.NewFakeLLM(llm.WithResponses("They purr."))
+	query := usecase.NewQueryUseCase(embedder, store, fake)
+	question := "Why do cats purr?"
+	answer, docs, err := query.Execute(context.Background(), question)
+	if err != nil {
+		t.Fatalf("Execute query: %v", err)
+	}
+	if answer != "They purr." {
+		t.Errorf("answer = %q, want the scripted one", answer)
+	}
+
+	if len(docs) != 1 {
+		t.Fatalf("retrieved %d chunks, want 1", len(docs))
+	}
+	if docs[0].PageContent != offlineFixture["cats.txt"] {
+		t.Errorf("retrieved %q, want the cats chunk", docs[0].PageContent)
+	}
+	if got := docs[0].Metadata[usecase.MetadataRelativePath]; got != "cats.txt" {
+		t.Errorf("relative_path = %v, want cats.txt", got)
+	}
+
+	prompts := fake.Prompts()
+	if len(prompts) != 1 {
+		t.Fatalf("the LLM received %d prompts, want 1", len(prompts))
+	}
+	prompt := prompts[0]
+	if !strings.Contains(prompt, offlineFixture["cats.txt"]) {
+		t.Errorf("prompt lacks the retrieved chunk:\n%s", prompt)
+	}
+	if !strings.HasSuffix(prompt, "Answer the question: "+question) {
+		t.Errorf("prompt does not end with the question:\n%s", prompt)
+	}
+	for _, name := range []string{"guides/dogs.txt", "guides/birds.txt"} {
+		if strings.Contains(prompt, offlineFixture[name]) {
+			t.Errorf("prompt holds the unrelated chunk of %s:\n%s", name, prompt)
+		}
+	}
+}
+
+// TestOfflineStreamingQuery checks the streamed answer and the chunks
+// retrieved from a named collection.
+func TestOfflineStreamingQuery(t *testing.T) {
+	embedder := llm.NewHashEmbedder(768)
+	store := newMemoryStore(embedder, "docs", 1)
+	ingestFixture(t, embedder, store)
+
+	fake := llm.NewFakeLLM(llm.WithResponses("Dogs fetch sticks."), llm.WithStreamChunks(4, 0))
+	query := usecase.NewQueryUseCase(embedder, store, fake)
+	var chunks []string
+	docs, err := query.ExecuteWithStreaming(context.Background(), "What do dogs fetch in the park?", "docs", func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("ExecuteWithStreaming: %v", err)
+	}
+	if got := strings.Join(chunks, ""); got != "Dogs fetch sticks." || len(chunks) < 2 {
+		t.Errorf("streamed %q, want the scripted answer in several chunks", chunks)
+	}
+	if len(docs) == 0 || docs[0].PageContent != offlineFixture["guides/dogs.txt"] {
+		t.Errorf("best chunk = %v, want the dogs chunk", docs)
+	}
+	if prompts := fake.Prompts(); len(prompts) != 1 || !strings.Contains(prompts[0], offlineFixture["guides/dogs.txt"]) {
+		t.Errorf("prompts = %q, want one holding the dogs chunk", prompts)
+	}
+}
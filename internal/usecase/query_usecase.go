@@ -10,23 +10,161 @@ import (
 )
 
 type QueryUseCase struct {
-	embedder  EmbeddingGenerator
-	retriever Retriever
-	llm       LLM
+	embedder   EmbeddingGenerator
+	retriever  Retriever
+	llm        LLM
+	llmOptions CallOptions
+	filter     *Filter
+	sparse     SparseEncoder
+	hybrid     HybridOptions
 }
 
-func NewQueryUseCase(e EmbeddingGenerator, r Retriever, l LLM) *QueryUseCase {
-	return &QueryUseCase{
+// QueryOption configures a QueryUseCase.
+type QueryOption func(*QueryUseCase)
+
+// WithLLMOptions sets the default options of the LLM calls. The options
+// passed to each Execute method override them.
+func WithLLMOptions(o CallOptions) QueryOption {
+	return func(uc *QueryUseCase) {
+		uc.llmOptions = o
+	}
+}
+
+// WithSearchFilter restricts the documents retrieved for every query to
+// those matching f.
+func WithSearchFilter(f *Filter) QueryOption {
+	return func(uc *QueryUseCase) {
+		uc.filter = f
+	}
+}
+
+// WithHybridSearch enables hybrid search: the sparse vectors of the queries,
+// encoded with encoder, are searched along with their embeddings and both
+// results are fused as set by opts. The retriever must be a SparseSearcher;
+// collections without sparse vectors fall back to dense search.
+func WithHybridSearch(encoder SparseEncoder, opts HybridOptions) QueryOption {
+	return func(uc *QueryUseCase) {
+		uc.sparse = encoder
+		uc.hybrid = opts
+	}
+}
+
+func NewQueryUseCase(e EmbeddingGenerator, r Retriever, l LLM, opts ...QueryOption) *QueryUseCase {
+	uc := &QueryUseCase{
 		embedder:  e,
 		retriever: r,
 		llm:       l,
 	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// With returns a copy of the use case with opts applied, e.g. to filter the
+// documents of a single request.
+func (uc *QueryUseCase) With(opts ...QueryOption) *QueryUseCase {
+	c := *uc
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
 }
 
-func (uc *QueryUseCase) Execute(ctx context.Context, query string) (string, []schema.Document, error) {
+// searchOptions returns the options of the similarity searches.
+func (uc *QueryUseCase) searchOptions() []SearchOption {
+	if uc.filter.IsEmpty() {
+		return nil
+	}
+	log.Printf("Filtering documents: %s", uc.filter)
+	return []SearchOption{WithFilter(uc.filter)}
+}
+
+// similaritySearcher is implemented by retrievers that search any collection.
+type similaritySearcher interface {
+	SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int, opts ...SearchOption) ([]schema.Document, error)
+}
+
+// retrieve returns the documents relevant to query from the retriever,
+// fusing dense and sparse results with hybrid search.
+func (uc *QueryUseCase) retrieve(ctx context.Context, query string) ([]schema.Document, error) {
+	searchOpts := uc.searchOptions()
+	docs, err := uc.retriever.GetRelevantDocuments(ctx, query, searchOpts...)
+	if err != nil {
+		return nil, err
+	}
+	sparse, vector, ok := uc.sparseQuery(ctx, query)
+	if !ok {
+		return docs, nil
+	}
+	return uc.fuse(docs, func() ([]schema.Document, error) {
+		return sparse.GetRelevantSparseDocuments(ctx, vector, searchOpts...)
+	}, -1), nil
+}
+
+// search returns the numDocuments documents of a collection closest to the
+// query embedding, fused with the results of the sparse vector when sparse
+// is not nil (see sparseQuery).
+func (uc *QueryUseCase) search(ctx context.Context, searcher similaritySearcher, sparse SparseSearcher, collectionName string, queryEmbedding []float32, vector SparseVector, numDocuments int, opts []SearchOption) ([]schema.Document, error) {
+	docs, err := searcher.SimilaritySearch(ctx, collectionName, queryEmbedding, numDocuments, opts...)
+	if err != nil || sparse == nil {
+		return docs, err
+	}
+	return uc.fuse(docs, func() ([]schema.Document, error) {
+		return sparse.SparseSearch(ctx, collectionName, vector, numDocuments, opts...)
+	}, numDocuments), nil
+}
+
+// sparseQuery returns the sparse searcher and the sparse vector of query
+// when hybrid search is enabled. ok is false when only the embeddings are
+// to be searched: without hybrid search, or when the query has no term in
+// the vocabulary.
+func (uc *QueryUseCase) sparseQuery(ctx context.Context, query string) (sparse SparseSearcher, vector SparseVector, ok bool) {
+	if uc.sparse == nil {
+		return nil, SparseVector{}, false
+	}
+	sparse, ok = uc.retriever.(SparseSearcher)
+	if !ok {
+		log.Printf("Warning: Retriever does not support sparse search, searching embeddings only")
+		return nil, SparseVector{}, false
+	}
+	vector, err := uc.sparse.EncodeQuery(ctx, query)
+	if err != nil {
+		log.Printf("Warning: Failed to encode query for sparse search, searching embeddings only: %v", err)
+		return nil, SparseVector{}, false
+	}
+	if vector.IsEmpty() {
+		log.Printf("No query term is in the sparse vocabulary, searching embeddings only.")
+		return nil, SparseVector{}, false
+	}
+	log.Printf("Hybrid search with %d query terms and %s fusion", len(vector.Indices), uc.hybrid.Fusion)
+	return sparse, vector, true
+}
+
+// fuse fuses dense with the results of sparseSearch into at most limit
+// documents (with a negative limit, as many as the longer result list).
+// When the sparse search fails, the dense results are returned alone.
+func (uc *QueryUseCase) fuse(dense []schema.Document, sparseSearch func() ([]schema.Document, error), limit int) []schema.Document {
+	sparse, err := sparseSearch()
+	if err != nil {
+		log.Printf("Warning: Sparse search failed, using the embedding search results only: %v", err)
+		return dense
+	}
+	if limit < 0 {
+		limit = max(len(dense), len(sparse))
+	}
+	return FuseResults(dense, sparse, uc.hybrid, limit)
+}
+
+// callOptions returns the default LLM options followed by the call's own.
+func (uc *QueryUseCase) callOptions(opts []CallOption) []CallOption {
+	return append([]CallOption{WithCallOptions(uc.llmOptions)}, opts...)
+}
+
+func (uc *QueryUseCase) Execute(ctx context.Context, query string, opts ...CallOption) (string, []schema.Document, error) {
 	log.Printf("Executing query: %s", query)
 
-	relevantDocs, err := uc.retriever.GetRelevantDocuments(ctx, query)
+	relevantDocs, err := uc.retrieve(ctx, query)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to retrieve relevant documents: %w", err)
 	}
@@ -35,6 +173,7 @@ func (uc *QueryUseCase) Execute(ctx context.Context, query string) (string, []sc
 	if len(relevantDocs) == 0 {
 		return "No relevant documents found to answer the query.", nil, nil
 	}
+	relevantDocs = parentDocuments(relevantDocs)
 
 	contextStr := ""
 	for _, doc := range relevantDocs {
@@ -45,7 +184,7 @@ func (uc *QueryUseCase) Execute(ctx context.Context, query string) (string, []sc
 
 	log.Println("Generating answer using LLM...")
 
-	answer, err := uc.llm.Call(ctx, prompt)
+	answer, err := uc.llm.Call(ctx, prompt, uc.callOptions(opts)...)
 	if err != nil {
 		return "", relevantDocs, fmt.Errorf("failed to generate answer with LLM: %w", err)
 	}
@@ -71,7 +210,7 @@ func (uc *QueryUseCase) Execute(ctx context.Context, query string) (string, []sc
 }
 
 // ExecuteWithStreaming realiza a busca por documentos relevantes e gera uma resposta com streaming
-func (uc *QueryUseCase) ExecuteWithStreaming(ctx context.Context, query string, collectionName string, callback func(chunk string)) ([]schema.Document, error) {
+func (uc *QueryUseCase) ExecuteWithStreaming(ctx context.Context, query string, collectionName string, callback func(chunk string), opts ...CallOption) ([]schema.Document, error) {
 	log.Printf("Executing streaming query: %s on collection: %s", query, collectionName)
 
 	// Converter a consulta em embedding para buscar documentos relevantes
@@ -82,18 +221,15 @@ func (uc *QueryUseCase) ExecuteWithStreaming(ctx context.Context, query string,
 
 	// Obter o adaptador específico para acessar métodos específicos de coleção
 	// Verificar primeiramente se o retriever possui o método SimilaritySearch
-	type similaritySearcher interface {
-		SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int) ([]schema.Document, error)
-	}
-
 	// Tentar obter o retriever como um searcher
 	searcher, ok := uc.retriever.(similaritySearcher)
 	if !ok {
 		return nil, fmt.Errorf("retriever does not implement SimilaritySearch method")
 	}
 
-	// Buscar documentos relevantes usando similaridade de embedding
-	relevantDocs, err := searcher.SimilaritySearch(ctx, collectionName, queryEmbedding, 4)
+	// Buscar documentos relevantes usando similaridade de embedding (e os vetores esparsos, na busca híbrida)
+	sparse, sparseVector, _ := uc.sparseQuery(ctx, query)
+	relevantDocs, err := uc.search(ctx, searcher, sparse, collectionName, queryEmbedding, sparseVector, 4, uc.searchOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve relevant documents: %w", err)
 	}
@@ -103,6 +239,7 @@ func (uc *QueryUseCase) ExecuteWithStreaming(ctx context.Context, query string,
 		callback("No relevant documents found to answer the query.")
 		return nil, nil
 	}
+	relevantDocs = parentDocuments(relevantDocs)
 
 	// Construir o contexto a partir dos documentos relevantes
 	contextStr := ""
@@ -116,7 +253,7 @@ func (uc *QueryUseCase) ExecuteWithStreaming(ctx context.Context, query string,
 	log.Println("Generating streaming answer using LLM...")
 
 	// Chamar o LLM com streaming, passando o callback para processar os chunks da resposta
-	err = uc.llm.CallWithStreaming(ctx, prompt, callback)
+	err = uc.llm.CallWithStreaming(ctx, prompt, callback, uc.callOptions(opts)...)
 	if err != nil {
 		return relevantDocs, fmt.Errorf("failed to generate streaming answer with LLM: %w", err)
 	}
@@ -125,52 +262,20 @@ func (uc *QueryUseCase) ExecuteWithStreaming(ctx context.Context, query string,
 }
 
 // ExecuteMultiCollection realiza a consulta em todas as coleções disponíveis e combina os resultados
-func (uc *QueryUseCase) ExecuteMultiCollection(ctx context.Context, query string, collections []string, numDocsPerCollection int) (string, []schema.Document, error) {
+func (uc *QueryUseCase) ExecuteMultiCollection(ctx context.Context, query string, collections []string, numDocsPerCollection int, opts ...CallOption) (string, []schema.Document, error) {
 	log.Printf("Executing query across %d collections: %s", len(collections), query)
 
-	var allRelevantDocs []schema.Document
-
-	// Converter a consulta em embedding uma única vez
-	queryEmbedding, err := uc.embedder.EmbedQuery(ctx, query)
+	// Buscar em todas as coleções, com os scores numa mesma escala
+	allRelevantDocs, err := uc.searchCollections(ctx, query, collections, numDocsPerCollection, numDocsPerCollection*2)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to embed query for retrieval: %w", err)
-	}
-
-	// Obter o adaptador específico para acessar métodos específicos de coleção
-	type similaritySearcher interface {
-		SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int) ([]schema.Document, error)
-	}
-
-	// Tentar obter o retriever como um searcher
-	searcher, ok := uc.retriever.(similaritySearcher)
-	if !ok {
-		return "", nil, fmt.Errorf("retriever does not implement SimilaritySearch method")
-	}
-
-	// Para cada coleção, buscar os documentos mais relevantes
-	for _, collectionName := range collections {
-		log.Printf("Searching collection: %s", collectionName)
-		docs, err := searcher.SimilaritySearch(ctx, collectionName, queryEmbedding, numDocsPerCollection)
-		if err != nil {
-			log.Printf("Warning: Failed to search collection %s: %v", collectionName, err)
-			continue
-		}
-
-		allRelevantDocs = append(allRelevantDocs, docs...)
+		return "", nil, err
 	}
 
 	if len(allRelevantDocs) == 0 {
 		return "No relevant documents found across collections to answer the query.", nil, nil
 	}
 
-	// Ordenar todos os documentos por score (assumindo que o score está nos metadados)
-	sortDocumentsByScore(allRelevantDocs)
-
-	// Limitar ao número total desejado de documentos
-	maxDocs := numDocsPerCollection * 2 // Por exemplo, limite máximo de documentos
-	if len(allRelevantDocs) > maxDocs {
-		allRelevantDocs = allRelevantDocs[:maxDocs]
-	}
+	allRelevantDocs = parentDocuments(allRelevantDocs)
 
 	// Construir o contexto a partir dos documentos relevantes
 	contextStr := ""
@@ -184,7 +289,7 @@ func (uc *QueryUseCase) ExecuteMultiCollection(ctx context.Context, query string
 	log.Println("Generating answer using LLM...")
 
 	// Chamar o LLM para gerar a resposta
-	answer, err := uc.llm.Call(ctx, prompt)
+	answer, err := uc.llm.Call(ctx, prompt, uc.callOptions(opts)...)
 	if err != nil {
 		return "", allRelevantDocs, fmt.Errorf("failed to generate answer with LLM: %w", err)
 	}
@@ -194,11 +299,48 @@ func (uc *QueryUseCase) ExecuteMultiCollection(ctx context.Context, query string
 }
 
 // ExecuteWithStreamingMultiCollection realiza a consulta em múltiplas coleções e gera resposta com streaming
-func (uc *QueryUseCase) ExecuteWithStreamingMultiCollection(ctx context.Context, query string, collections []string, numDocsPerCollection int, callback func(chunk string)) ([]schema.Document, error) {
+func (uc *QueryUseCase) ExecuteWithStreamingMultiCollection(ctx context.Context, query string, collections []string, numDocsPerCollection int, callback func(chunk string), opts ...CallOption) ([]schema.Document, error) {
 	log.Printf("Executing streaming query across %d collections: %s", len(collections), query)
 
-	var allRelevantDocs []schema.Document
+	// Buscar em todas as coleções, com os scores numa mesma escala
+	allRelevantDocs, err := uc.searchCollections(ctx, query, collections, numDocsPerCollection, numDocsPerCollection*2)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(allRelevantDocs) == 0 {
+		callback("No relevant documents found across collections to answer the query.")
+		return nil, nil
+	}
 
+	allRelevantDocs = parentDocuments(allRelevantDocs)
+
+	// Construir o contexto a partir dos documentos relevantes
+	contextStr := ""
+	for _, doc := range allRelevantDocs {
+		contextStr += doc.PageContent + "\n\n"
+	}
+
+	// Criar o prompt para o LLM com o contexto e a pergunta
+	prompt := fmt.Sprintf("Based on the following context from multiple documents:\n\n%s\n\nAnswer the question: %s", contextStr, query)
+
+	log.Println("Generating streaming answer using LLM...")
+
+	// Chamar o LLM com streaming, passando o callback para processar os chunks da resposta
+	err = uc.llm.CallWithStreaming(ctx, prompt, callback, uc.callOptions(opts)...)
+	if err != nil {
+		return allRelevantDocs, fmt.Errorf("failed to generate streaming answer with LLM: %w", err)
+	}
+
+	return allRelevantDocs, nil
+}
+
+// searchCollections returns the at most maxDocs documents of collections
+// closest to query, searching numDocsPerCollection documents in each. With
+// hybrid search, the dense and the sparse results of all the collections
+// are fused once, so that every returned score is on the same scale.
+// Collections that fail to be searched are skipped with a warning.
+func (uc *QueryUseCase) searchCollections(ctx context.Context, query string, collections []string, numDocsPerCollection, maxDocs int) ([]schema.Document, error) {
 	// Converter a consulta em embedding uma única vez
 	queryEmbedding, err := uc.embedder.EmbedQuery(ctx, query)
 	if err != nil {
@@ -206,60 +348,86 @@ func (uc *QueryUseCase) ExecuteWithStreamingMultiCollection(ctx context.Context,
 	}
 
 	// Obter o adaptador específico para acessar métodos específicos de coleção
-	type similaritySearcher interface {
-		SimilaritySearch(ctx context.Context, collectionName string, queryEmbedding []float32, numDocuments int) ([]schema.Document, error)
-	}
-
-	// Tentar obter o retriever como um searcher
 	searcher, ok := uc.retriever.(similaritySearcher)
 	if !ok {
 		return nil, fmt.Errorf("retriever does not implement SimilaritySearch method")
 	}
 
-	// Para cada coleção, buscar os documentos mais relevantes
+	// Para cada coleção, buscar os documentos mais relevantes (e que atendem ao filtro)
+	searchOpts := uc.searchOptions()
+	sparse, sparseVector, _ := uc.sparseQuery(ctx, query)
+	var dense, sparseDocs []schema.Document
+	sparseSearched := false
 	for _, collectionName := range collections {
 		log.Printf("Searching collection: %s", collectionName)
-		docs, err := searcher.SimilaritySearch(ctx, collectionName, queryEmbedding, numDocsPerCollection)
+		docs, err := searcher.SimilaritySearch(ctx, collectionName, queryEmbedding, numDocsPerCollection, searchOpts...)
 		if err != nil {
 			log.Printf("Warning: Failed to search collection %s: %v", collectionName, err)
 			continue
 		}
+		dense = append(dense, docs...)
 
-		allRelevantDocs = append(allRelevantDocs, docs...)
-	}
-
-	if len(allRelevantDocs) == 0 {
-		callback("No relevant documents found across collections to answer the query.")
-		return nil, nil
+		if sparse == nil {
+			continue
+		}
+		docs, err = sparse.SparseSearch(ctx, collectionName, sparseVector, numDocsPerCollection, searchOpts...)
+		if err != nil {
+			log.Printf("Warning: Sparse search failed on collection %s, using its embedding search results only: %v", collectionName, err)
+			continue
+		}
+		sparseDocs = append(sparseDocs, docs...)
+		sparseSearched = true
 	}
 
-	// Ordenar todos os documentos por score
-	sortDocumentsByScore(allRelevantDocs)
-
-	// Limitar ao número total desejado de documentos
-	maxDocs := numDocsPerCollection * 2
-	if len(allRelevantDocs) > maxDocs {
-		allRelevantDocs = allRelevantDocs[:maxDocs]
+	// Cosine similarities, and BM25 scores over the shared vocabulary, are
+	// comparable across collections: rank each list as a whole, then fuse
+	sortDocumentsByScore(dense)
+	if sparseSearched {
+		sortDocumentsByScore(sparseDocs)
+		return FuseResults(dense, sparseDocs, uc.hybrid, maxDocs), nil
 	}
-
-	// Construir o contexto a partir dos documentos relevantes
-	contextStr := ""
-	for _, doc := range allRelevantDocs {
-		contextStr += doc.PageContent + "\n\n"
+	if len(dense) > maxDocs {
+		dense = dense[:maxDocs]
 	}
+	return dense, nil
+}
 
-	// Criar o prompt para o LLM com o contexto e a pergunta
-	prompt := fmt.Sprintf("Based on the following context from multiple documents:\n\n%s\n\nAnswer the question: %s", contextStr, query)
-
-	log.Println("Generating streaming answer using LLM...")
+// parentDocuments replaces chunks ingested with parent-child retrieval by
+// their parent section (see MetadataParentID), keeping the first occurrence
+// of each parent, so the LLM receives every section once with its full
+// context. Chunks without a parent are kept as they are.
+func parentDocuments(docs []schema.Document) []schema.Document {
+	seen := make(map[string]bool)
+	children := 0
+	result := make([]schema.Document, 0, len(docs))
+	for _, doc := range docs {
+		id, _ := doc.Metadata[MetadataParentID].(string)
+		text, ok := doc.Metadata[MetadataParentText].(string)
+		if id == "" || !ok {
+			result = append(result, doc)
+			continue
+		}
+		if collection, _ := doc.Metadata["collection"].(string); collection != "" {
+			id = collection + "/" + id
+		}
+		children++
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
 
-	// Chamar o LLM com streaming, passando o callback para processar os chunks da resposta
-	err = uc.llm.CallWithStreaming(ctx, prompt, callback)
-	if err != nil {
-		return allRelevantDocs, fmt.Errorf("failed to generate streaming answer with LLM: %w", err)
+		metadata := make(map[string]interface{}, len(doc.Metadata))
+		for k, v := range doc.Metadata {
+			if k != MetadataParentText {
+				metadata[k] = v
+			}
+		}
+		result = append(result, schema.Document{PageContent: text, Metadata: metadata})
 	}
-
-	return allRelevantDocs, nil
+	if children > 0 {
+		log.Printf("Replaced %d retrieved chunks with %d parent sections.", children, len(seen))
+	}
+	return result
 }
 
 // Função auxiliar para ordenar documentos por score
@@ -288,9 +456,9 @@ func sortDocumentsByScore(docs []schema.Document) {
 }
 
 // ExecuteStreaming realiza uma consulta ao sistema RAG e envia a resposta via streaming
-func (uc *QueryUseCase) ExecuteStreaming(ctx context.Context, query string, callback func(chunk string)) error {
+func (uc *QueryUseCase) ExecuteStreaming(ctx context.Context, query string, callback func(chunk string), opts ...CallOption) error {
 	// Recuperar documentos relevantes do retriever
-	relevantDocs, err := uc.retriever.GetRelevantDocuments(ctx, query)
+	relevantDocs, err := uc.retrieve(ctx, query)
 	if err != nil {
 		return fmt.Errorf("falha ao recuperar documentos: %w", err)
 	}
@@ -299,6 +467,7 @@ func (uc *QueryUseCase) ExecuteStreaming(ctx context.Context, query string, call
 		callback("Não foram encontrados documentos relevantes para sua consulta.")
 		return nil
 	}
+	relevantDocs = parentDocuments(relevantDocs)
 
 	// Construir o prompt combinando a consulta com os documentos relevantes
 	var context string
@@ -317,7 +486,7 @@ Se os documentos não tiverem informações suficientes para responder, diga "N
 		context, query)
 
 	// Usar o LLM para gerar uma resposta via streaming
-	err = uc.llm.CallWithStreaming(ctx, prompt, callback)
+	err = uc.llm.CallWithStreaming(ctx, prompt, callback, uc.callOptions(opts)...)
 	if err != nil {
 		return fmt.Errorf("falha no streaming da resposta: %w", err)
 	}
@@ -2,12 +2,10 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"path/filepath"
 
+	"github.com/DjonatanS/rag-ollama-qdrant-go/internal/infra/qdrant"
 	"github.com/DjonatanS/rag-ollama-qdrant-go/pgk/loader"
 	"github.com/DjonatanS/rag-ollama-qdrant-go/pgk/store"
 
@@ -122,30 +120,18 @@ func main() {
 
 // deleteQdrantCollection deletes a collection if it exists
 func deleteQdrantCollection(ctx context.Context, baseURL string, collectionName string) error {
-	url := fmt.Sprintf("%s/collections/%s", baseURL, collectionName)
-
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	client, err := qdrant.NewClient(baseURL)
 	if err != nil {
 		return err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// A missing collection is fine
+	existed, err := client.DeleteCollection(ctx, collectionName)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	// 404 means collection doesn't exist, which is fine
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
-		bodyBytes := []byte{}
-		if resp.Body != nil {
-			bodyBytes, _ = io.ReadAll(resp.Body)
-		}
-		return fmt.Errorf("failed to delete collection, status: %d, response: %s", resp.StatusCode, string(bodyBytes))
-	}
 
-	if resp.StatusCode == http.StatusOK {
+	if existed {
 		log.Printf("Collection '%s' deleted successfully", collectionName)
 	}
 
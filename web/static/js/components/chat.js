@@ -57,6 +57,7 @@ const Chat = {
             }
 
             let fullAnswer = '';
+            let reasoning = '';
             
             this.elements.answerDiv.innerHTML = '';
             this.elements.answerDiv.classList.add('placeholder-text');
@@ -91,9 +92,15 @@ const Chat = {
                     }
                     
                     fullAnswer += chunk;
-                    this._renderAnswer(fullAnswer); // Render incrementally
+                    this._renderAnswer(fullAnswer, reasoning); // Render incrementally
                 };
 
+                // Reasoning of thinking models, sent apart from the answer
+                this.eventSource.addEventListener('reasoning', (event) => {
+                    reasoning += event.data;
+                    this._renderAnswer(fullAnswer, reasoning);
+                });
+
                 this.eventSource.onerror = (err) => {
                     console.error("EventSource failed:", err);
                     
@@ -186,14 +193,17 @@ const Chat = {
 
     /**
      * Render answer with formatted think sections
-     * @param {string} answerText - Answer text with <think> tags
+     * @param {string} answerText - Answer text, possibly with inline <think> tags
+     * @param {string} [reasoning] - Reasoning received apart from the answer
      * @private
      */
-    _renderAnswer(answerText) {
+    _renderAnswer(answerText, reasoning = '') {
         if (!this.elements.answerDiv) return;
         
         this.elements.answerDiv.classList.remove('placeholder-text');
-        this.elements.answerDiv.innerHTML = Helpers.renderThinkSections(answerText);
+        this.elements.answerDiv.innerHTML =
+            (reasoning ? Helpers.renderReasoning(reasoning) : '') +
+            Helpers.renderThinkSections(answerText);
     }
 };
 
@@ -1,9 +1,10 @@
 /**
  * Document Uploader Component
- * Handles uploading and ingestion of PDF documents
+ * Handles uploading and ingestion of documents (PDF, Office, Markdown, text, HTML)
  */
 import DOM from '../utils/dom.js';
 import API from '../utils/api.js';
+import Helpers from '../utils/helpers.js';
 import Config from '../config.js';
 
 const Uploader = {
@@ -13,9 +14,12 @@ const Uploader = {
         createPerPdf: null,
         ingestStatusDiv: null,
         dropZone: null,
-        dropZonePrompt: null
+        dropZonePrompt: null,
+        historyList: null
     },
 
+    eventSource: null,
+
     /**
      * Initialize the uploader component
      */
@@ -26,7 +30,8 @@ const Uploader = {
             fileInput: DOM.getById('file-input'),
             createPerPdf: DOM.getById('create-per-pdf'),
             ingestStatusDiv: DOM.getById('ingest-status'),
-            dropZone: DOM.getById('drop-zone')
+            dropZone: DOM.getById('drop-zone'),
+            historyList: DOM.getById('ingest-history-list')
         };
 
         if (this.elements.dropZone) {
@@ -37,6 +42,7 @@ const Uploader = {
         this._initDropZone();
         this._initFileInput();
         this._initUploadForm();
+        this._loadHistory();
     },
 
     /**
@@ -104,13 +110,13 @@ const Uploader = {
             if (this.elements.fileInput.files.length === 0) {
                 DOM.showStatus(
                     this.elements.ingestStatusDiv, 
-                    "Please select at least one PDF file.", 
+                    "Please select at least one document.", 
                     'error'
                 );
                 return;
             }
 
-            DOM.showStatus(this.elements.ingestStatusDiv, "Processing documents...", 'info');
+            DOM.showStatus(this.elements.ingestStatusDiv, "Uploading documents...", 'info');
             
             const submitButton = this.elements.uploadForm.querySelector('button[type="submit"]');
             if (submitButton) submitButton.disabled = true;
@@ -123,22 +129,18 @@ const Uploader = {
                     formData.append('pdfs', file);
                 }
                 
-                // Add option to create separate collection per PDF if checked
+                // Add option to create separate collection per document if checked
                 if (this.elements.createPerPdf) {
                     formData.append('perPdf', this.elements.createPerPdf.checked);
                 }
 
-                const response = await API.upload(Config.api.ingest, formData);
-
-                DOM.showStatus(
-                    this.elements.ingestStatusDiv, 
-                    `Success: ${response.message || 'Documents processed.'}`, 
-                    'success',
-                    5000
-                );
+                // The server answers with the queued job; ingestion runs in the background
+                const job = await API.upload(Config.api.ingest, formData);
                 
                 // Reset form after successful upload
                 this._resetDropZone();
+                this._renderJob(job);
+                this._followJob(job.id);
             } catch (error) {
                 console.error("Ingest error:", error);
                 
@@ -147,12 +149,164 @@ const Uploader = {
                     `Error: ${error.message || 'Failed to process documents.'}`, 
                     'error'
                 );
+                this._renderFailures(this._failedFiles(error.data));
             } finally {
                 if (submitButton) submitButton.disabled = false;
             }
         });
     },
 
+    /**
+     * Extract the files that failed from an ingestion response
+     * @param {object} response - A job record or an /api/ingest error body
+     * @returns {Array} - Files with stage "failed"
+     * @private
+     */
+    _failedFiles(response) {
+        if (!response || !Array.isArray(response.files)) return [];
+        return response.files.filter(file => file.stage === 'failed');
+    },
+
+    /**
+     * Follow the progress of an ingestion job through server-sent events
+     * @param {string} jobId - The job ID returned by the ingest API
+     * @private
+     */
+    _followJob(jobId) {
+        if (this.eventSource) {
+            this.eventSource.close();
+        }
+
+        this.eventSource = API.createEventSource(`${Config.api.jobs}/${jobId}/events`);
+        let lastJob = null;
+
+        const onUpdate = (event) => {
+            lastJob = JSON.parse(event.data).job;
+            this._renderJob(lastJob);
+        };
+        this.eventSource.addEventListener('status', onUpdate);
+        this.eventSource.addEventListener('progress', onUpdate);
+
+        this.eventSource.onmessage = (event) => {
+            if (event.data === "[DONE]") {
+                this.eventSource.close();
+                this.eventSource = null;
+                this._loadHistory();
+            }
+        };
+
+        this.eventSource.onerror = () => {
+            // Connection lost: fall back to the last known state
+            this.eventSource.close();
+            this.eventSource = null;
+            if (!lastJob) {
+                DOM.showStatus(this.elements.ingestStatusDiv, "Error: Lost connection to the ingestion job.", 'error');
+            }
+            this._loadHistory();
+        };
+    },
+
+    /**
+     * Render the status, progress and failures of a job in the status area
+     * @param {object} job - The job record
+     * @private
+     */
+    _renderJob(job) {
+        const statusDiv = this.elements.ingestStatusDiv;
+        if (!statusDiv || !job) return;
+
+        const total = job.files.length;
+        const done = job.files.filter(file => ['completed', 'unchanged', 'failed'].includes(file.stage)).length;
+        const stored = job.files.reduce((sum, file) => sum + file.stored, 0);
+
+        switch (job.status) {
+            case 'queued':
+            case 'running':
+                DOM.showStatus(statusDiv, `Processing documents (${job.status}): ${done}/${total} files, ${stored} chunks stored`, 'info');
+                statusDiv.appendChild(DOM.createElement('button', {
+                    type: 'button',
+                    className: 'btn btn-secondary',
+                    onclick: () => this._cancelJob(job.id)
+                }, 'Cancel'));
+                break;
+            case 'succeeded':
+                DOM.showStatus(statusDiv, `Success: ${job.message || 'Documents processed.'}`, 'success');
+                break;
+            case 'cancelled':
+                DOM.showStatus(statusDiv, `Cancelled: ${job.message || ''}`, 'error');
+                break;
+            default:
+                DOM.showStatus(statusDiv, `Error: ${job.error || 'Failed to process documents.'}`, 'error');
+        }
+
+        this._renderFailures(this._failedFiles(job));
+    },
+
+    /**
+     * Cancel a running ingestion job
+     * @param {string} jobId - The job ID
+     * @private
+     */
+    async _cancelJob(jobId) {
+        try {
+            await API.delete(`${Config.api.jobs}/${jobId}`);
+        } catch (error) {
+            console.error("Cancel error:", error);
+        }
+    },
+
+    /**
+     * Load and render the recent ingestion history
+     * @private
+     */
+    async _loadHistory() {
+        const list = this.elements.historyList;
+        if (!list) return;
+
+        try {
+            const jobs = await API.get(Config.api.jobs);
+            list.innerHTML = '';
+
+            if (jobs.length === 0) {
+                list.appendChild(DOM.createElement('li', { className: 'placeholder-text' }, 'No ingestion jobs yet.'));
+                return;
+            }
+
+            jobs.forEach(job => {
+                const names = job.files.map(file => file.name).join(', ');
+                list.appendChild(DOM.createElement('li', { className: `history-item ${job.status}` }, [
+                    DOM.createElement('strong', {}, Helpers.formatDate(job.created_at, 'dd/mm/yyyy hh:mm')),
+                    ` ${job.status}`,
+                    job.message ? ` - ${job.message}` : '',
+                    DOM.createElement('div', { className: 'history-files' }, Helpers.truncate(names, 120))
+                ]));
+            });
+        } catch (error) {
+            console.error("History error:", error);
+        }
+    },
+
+    /**
+     * Append the list of failed files and their reasons to the status area
+     * @param {Array} failed - Files with stage "failed"
+     * @private
+     */
+    _renderFailures(failed) {
+        const statusDiv = this.elements.ingestStatusDiv;
+        if (!statusDiv || failed.length === 0) return;
+
+        const items = failed.map(file => DOM.createElement('li', {}, [
+            DOM.createElement('strong', {}, file.name),
+            `: ${file.error || 'unknown error'}`
+        ]));
+
+        statusDiv.classList.add('has-report');
+        statusDiv.appendChild(DOM.createElement('div', { className: 'ingest-report' }, [
+            `${failed.length} ${failed.length === 1 ? 'file' : 'files'} failed:`,
+            DOM.createElement('ul', {}, items)
+        ]));
+    },
+
     /**
      * Update the file counter display
      * @private
@@ -166,7 +320,7 @@ const Uploader = {
         if (fileCount > 0) {
             dropZonePrompt.textContent = `${fileCount} ${fileCount === 1 ? 'file selected' : 'files selected'}`;
         } else {
-            dropZonePrompt.innerHTML = 'Drag PDF files here or <strong>click to select</strong>';
+            dropZonePrompt.innerHTML = 'Drag documents here (PDF, Office, Markdown, text, HTML) or <strong>click to select</strong>';
         }
     },
 
@@ -7,6 +7,7 @@ const Config = {
     api: {
         stream: '/api/stream',
         ingest: '/api/ingest',
+        jobs: '/api/jobs',
         collections: '/api/collections'
     },
     
@@ -48,6 +48,27 @@ const API = {
         }
     },
 
+    /**
+     * Make a DELETE request
+     * @param {string} url - The URL of the resource to delete
+     * @returns {Promise} - Promise that resolves with the response data
+     */
+    delete: async (url) => {
+        try {
+            const response = await fetch(url, { method: 'DELETE' });
+            
+            if (!response.ok) {
+                const data = await response.json().catch(() => null);
+                throw new Error((data && data.error) || `HTTP error! Status: ${response.status}`);
+            }
+            
+            return await response.json();
+        } catch (error) {
+            console.error("API DELETE error:", error);
+            throw error;
+        }
+    },
+
     /**
      * Upload files using FormData
      * @param {string} url - The URL to upload to
@@ -62,7 +83,11 @@ const API = {
             });
             
             if (!response.ok) {
-                throw new Error(`HTTP error! Status: ${response.status}`);
+                // Keep the JSON body (e.g. the ingestion report) available to the caller
+                const data = await response.json().catch(() => null);
+                const error = new Error((data && data.error) || `HTTP error! Status: ${response.status}`);
+                error.data = data;
+                throw error;
             }
             
             return await response.json();
@@ -134,15 +134,24 @@ const Helpers = {
             if (seg.type === 'text') {
                 html += `<span>${escaped}</span>`;
             } else if (seg.type === 'think') {
-                html +=
-                    `<div class="think">` +
-                        `<button class="toggle-btn">Show Internal Processing</button>` +
-                        `<div class="think-content hidden"><pre>${escaped}</pre></div>` +
-                    `</div>`;
+                html += Helpers.renderReasoning(seg.content);
             }
         });
         
         return html;
+    },
+
+    /**
+     * Render reasoning as a collapsible section
+     * @param {string} reasoning - The reasoning text
+     * @returns {string} - HTML with a collapsible section
+     */
+    renderReasoning: (reasoning) => {
+        const escaped = Helpers.escapeHtml(reasoning);
+        return `<div class="think">` +
+                `<button class="toggle-btn">Show Internal Processing</button>` +
+                `<div class="think-content hidden"><pre>${escaped}</pre></div>` +
+            `</div>`;
     }
 };
 